@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"example_consumer/internal/infra"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreSink string
+	restoreFrom string
+	restoreTo   string
+	archiveCmd  = &cobra.Command{
+		Use:   "archive",
+		Short: "Work with archived log records",
+	}
+	archiveRestoreCmd = &cobra.Command{
+		Use:   "restore --deployment={local|dev|prod|...} --from=... --to=...",
+		Short: "Re-import archived log records into MongoDB",
+		Long: "Re-import log records archived by a mongo sink back into its collection. Only records with " +
+			"timestamp in the given window are restored, records still present in the collection are skipped.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := parseRestoreTime(restoreFrom, false)
+			if err != nil {
+				return err
+			}
+			to, err := parseRestoreTime(restoreTo, true)
+			if err != nil {
+				return err
+			}
+			if !from.Before(to) {
+				return fmt.Errorf("--from must be before --to")
+			}
+			return infra.RestoreArchive(deployment, restoreSink, from, to)
+		},
+	}
+)
+
+func init() {
+	archiveRestoreCmd.Flags().StringVar(&deployment, "deployment", "",
+		"deployment environment, e.g. local, prod (it should match your configuration filename)")
+	archiveRestoreCmd.Flags().StringVar(&restoreFrom, "from", "",
+		"start of the window, RFC3339 timestamp or date (2006-01-02)")
+	archiveRestoreCmd.Flags().StringVar(&restoreTo, "to", "",
+		"end of the window (exclusive), RFC3339 timestamp or date (the whole day is included)")
+	archiveRestoreCmd.Flags().StringVar(&restoreSink, "sink", "",
+		"name of mongo sink to restore into, defaults to the first mongo sink with archive enabled")
+	_ = archiveRestoreCmd.MarkFlagRequired("deployment")
+	_ = archiveRestoreCmd.MarkFlagRequired("from")
+	_ = archiveRestoreCmd.MarkFlagRequired("to")
+	archiveCmd.AddCommand(archiveRestoreCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
+
+// parseRestoreTime accepts RFC3339 timestamps and plain dates, date of the window end means the end of that day
+func parseRestoreTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 timestamp or date", value)
+	}
+	if endOfDay {
+		t = t.Add(24 * time.Hour)
+	}
+	return t, nil
+}
@@ -50,11 +50,84 @@ func Start(_ context.Context, di *di.DI) {
 }
 
 func apiRoutes(e *echo.Echo, di *di.DI) {
+	admin := adminMiddleware(di)
 	e.GET("/api/version", internal.GetVersion())
+	e.GET("/metrics", internal.GetMetrics(), admin...)
 	contacts := e.Group("/api/contacts")
 	contacts.POST("", internal.CreateContact(di.UseCases))
 	contacts.GET("", internal.ListAllContacts(di.UseCases))
 	contacts.PUT("/:id", internal.UpdateContact(di.UseCases))
 	contacts.GET("/:id", internal.GetContact(di.UseCases))
 	contacts.DELETE("/:id", internal.DeleteContact(di.UseCases))
+	tenant := tenantMiddleware(di)
+	logs := e.Group("/api/logs", tenant...)
+	logs.POST("/grok/test", internal.TestGrokPattern(di.UseCases))
+	logs.GET("/patterns", internal.ListLogPatterns(di.UseCases))
+	logs.POST("/search", internal.SearchLogs(di.UseCases))
+	logs.POST("/aggregate", internal.AggregateLogs(di.UseCases))
+	searches := e.Group("/api/searches", tenant...)
+	searches.POST("", internal.CreateSavedSearch(di.UseCases))
+	searches.GET("", internal.ListSavedSearches(di.UseCases))
+	searches.PUT("/:id", internal.UpdateSavedSearch(di.UseCases))
+	searches.GET("/:id", internal.GetSavedSearch(di.UseCases))
+	searches.DELETE("/:id", internal.DeleteSavedSearch(di.UseCases))
+	searches.GET("/:id/results", internal.RunSavedSearch(di.UseCases))
+	dashboards := e.Group("/api/dashboards", tenant...)
+	dashboards.POST("", internal.CreateDashboard(di.UseCases))
+	dashboards.GET("", internal.ListDashboards(di.UseCases))
+	dashboards.PUT("/:id", internal.UpdateDashboard(di.UseCases))
+	dashboards.GET("/:id", internal.GetDashboard(di.UseCases))
+	dashboards.DELETE("/:id", internal.DeleteDashboard(di.UseCases))
+	dashboards.GET("/:id/results", internal.RunDashboard(di.UseCases))
+	sampling := e.Group("/api/pipeline/sampling", admin...)
+	sampling.GET("", internal.ListSamplingRules(di.UseCases))
+	sampling.PUT("/:source", internal.SaveSamplingRule(di.UseCases))
+	sampling.DELETE("/:source", internal.DeleteSamplingRule(di.UseCases))
+	routes := e.Group("/api/pipeline/routes", admin...)
+	routes.GET("", internal.ListRoutes(di.UseCases))
+	routes.POST("/reload", internal.ReloadRoutes(di.UseCases))
+	e.GET("/api/erasures/:customerNumber", internal.GetErasure(di.UseCases), tenant...)
+	e.GET("/api/customers/:customerNumber/export", internal.ExportCustomerData(di.UseCases), tenant...)
+	ingestRoutes(e, di, tenant)
+}
+
+// tenantMiddleware scopes requests of log queries, ingestion and customer data to tenant of their API key and
+// rejects requests without known key, nothing if tenants are disabled
+func tenantMiddleware(di *di.DI) []echo.MiddlewareFunc {
+	tc := &di.Config.Tenants
+	if !tc.Enabled {
+		return nil
+	}
+	return []echo.MiddlewareFunc{internal.TenantMiddleware(di.UseCases, tc.HeaderOr("X-API-Key"))}
+}
+
+// adminMiddleware restricts requests to endpoints covering all tenants to admin API keys, nothing if tenants are
+// disabled
+func adminMiddleware(di *di.DI) []echo.MiddlewareFunc {
+	tc := &di.Config.Tenants
+	if !tc.Enabled {
+		return nil
+	}
+	return []echo.MiddlewareFunc{internal.AdminMiddleware(di.UseCases, tc.HeaderOr("X-API-Key"))}
+}
+
+// ingestRoutes registers enabled inputs speaking protocols of other log systems
+func ingestRoutes(e *echo.Echo, di *di.DI, tenant []echo.MiddlewareFunc) {
+	ic := &di.Config.Ingest
+	if ic.Loki.Enabled {
+		e.POST("/loki/api/v1/push", internal.PushLokiLogs(di.UseCases, ic.Loki.TopicOr("loki")), tenant...)
+	}
+	if ic.Elasticsearch.Enabled {
+		version := ic.Elasticsearch.Version
+		if version == "" {
+			version = "8.11.0"
+		}
+		topic := ic.Elasticsearch.TopicOr("elasticsearch")
+		e.GET("/", internal.GetElasticInfo(version))
+		e.POST("/_bulk", internal.BulkIndexLogs(di.UseCases, topic), tenant...)
+		e.POST("/:index/_bulk", internal.BulkIndexLogs(di.UseCases, topic), tenant...)
+	}
+	if ic.Otlp.Enabled {
+		e.POST("/v1/logs", internal.ExportOtlpLogs(di.UseCases, ic.Otlp.TopicOr("otlp")), tenant...)
+	}
 }
@@ -0,0 +1,94 @@
+// Package elastic parses requests of the Elasticsearch bulk API (POST /_bulk) as sent by Beats and Fluent Bit
+package elastic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	maxLineBytes = 16 << 20
+	// MaxBodyBytes limits bulk requests, compressed and decompressed
+	MaxBodyBytes = 32 << 20
+)
+
+// ErrBodyTooLarge is returned by readers of LimitBody, and ParseBulk reading from them, after MaxBodyBytes
+var ErrBodyTooLarge = fmt.Errorf("bulk request exceeds %d bytes", MaxBodyBytes)
+
+// BulkItem is one action of a bulk request, Source is set for index and create actions
+type BulkItem struct {
+	Action string // index | create | update | delete
+	Index  string
+	ID     string
+	Source []byte
+	Err    error // item could not be parsed, it is reported with status 400
+}
+
+type bulkActionMeta struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+// ParseBulk reads NDJSON action and source lines and calls fn for every item. Index defaults to defaultIndex.
+// Error is returned only if the body itself can not be read or an action line is broken, so the
+// following lines can not be assigned.
+func ParseBulk(body io.Reader, defaultIndex string, fn func(item *BulkItem)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var action map[string]bulkActionMeta
+		if err := json.Unmarshal(line, &action); err != nil || len(action) != 1 {
+			return fmt.Errorf("malformed action/metadata line [%s]", line)
+		}
+		item := &BulkItem{}
+		for name, meta := range action {
+			item.Action = name
+			item.Index = meta.Index
+			item.ID = meta.ID
+		}
+		if item.Index == "" {
+			item.Index = defaultIndex
+		}
+		switch item.Action {
+		case "index", "create", "update":
+			if !scanner.Scan() {
+				return fmt.Errorf("%s action without source", item.Action)
+			}
+			item.Source = append([]byte(nil), bytes.TrimSpace(scanner.Bytes())...)
+			if !json.Valid(item.Source) {
+				item.Err = fmt.Errorf("failed to parse source of document")
+			}
+		case "delete":
+		default:
+			return fmt.Errorf("unknown action [%s]", item.Action)
+		}
+		fn(item)
+	}
+	return scanner.Err()
+}
+
+type limitedBody struct {
+	r io.Reader
+	n int64
+}
+
+// LimitBody returns reader of body that fails with ErrBodyTooLarge once more than MaxBodyBytes are read
+func LimitBody(body io.Reader) io.Reader {
+	return &limitedBody{r: io.LimitReader(body, MaxBodyBytes+1)}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > MaxBodyBytes {
+		return 0, ErrBodyTooLarge
+	}
+	return n, err
+}
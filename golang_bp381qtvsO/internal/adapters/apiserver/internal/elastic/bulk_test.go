@@ -0,0 +1,41 @@
+package elastic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBulk(t *testing.T) {
+	body := `{"index": {"_index": "app", "_id": "1"}}
+{"message": "hello"}
+{"create": {}}
+{"message": broken}
+{"delete": {"_id": "2"}}
+`
+	var items []*BulkItem
+	if err := ParseBulk(strings.NewReader(body), "default", func(item *BulkItem) { items = append(items, item) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+	if items[0].Index != "app" || items[0].ID != "1" || string(items[0].Source) != `{"message": "hello"}` {
+		t.Errorf("index item = %+v", items[0])
+	}
+	if items[1].Index != "default" || items[1].Err == nil {
+		t.Errorf("create item = %+v", items[1])
+	}
+	if items[2].Action != "delete" || items[2].Source != nil {
+		t.Errorf("delete item = %+v", items[2])
+	}
+}
+
+func TestParseBulkBodyLimit(t *testing.T) {
+	line := `{"index": {}}` + "\n" + `{"message": "` + strings.Repeat("x", 1<<20) + `"}` + "\n"
+	body := strings.Repeat(line, MaxBodyBytes/len(line)+1)
+	err := ParseBulk(LimitBody(strings.NewReader(body)), "default", func(*BulkItem) {})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("err = %v, want %v", err, ErrBodyTooLarge)
+	}
+}
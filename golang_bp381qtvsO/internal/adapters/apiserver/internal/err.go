@@ -31,3 +31,14 @@ func NewBadRequestErrResponse(err error) *ErrResponse {
 		ErrorText:      err.Error(),
 	}
 }
+
+var UnauthorizedErrResponse = &ErrResponse{HTTPStatusCode: 401, StatusText: "Unauthorized."}
+
+func NewTooManyRequestsErrResponse(err error) *ErrResponse {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusTooManyRequests,
+		StatusText:     "Too many requests",
+		ErrorText:      err.Error(),
+	}
+}
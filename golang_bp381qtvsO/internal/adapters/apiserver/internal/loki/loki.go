@@ -0,0 +1,274 @@
+// Package loki decodes push requests of the Loki HTTP API (POST /loki/api/v1/push), either JSON or
+// snappy compressed protobuf as sent by promtail and other Loki clients.
+package loki
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const maxBodyBytes = 32 << 20
+
+// Stream is a set of entries sharing the same labels
+type Stream struct {
+	Labels  map[string]string
+	Entries []Entry
+}
+
+type Entry struct {
+	Timestamp time.Time
+	Line      string
+	Metadata  map[string]string // structured metadata of the entry
+}
+
+// DecodePushRequest reads streams from push request body according to its content type and encoding
+func DecodePushRequest(r *http.Request) ([]*Stream, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBodyBytes {
+		return nil, fmt.Errorf("push request exceeds %d bytes", maxBodyBytes)
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/json":
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			if body, err = gunzip(body); err != nil {
+				return nil, err
+			}
+		}
+		return decodeJson(body)
+	case "", "application/x-protobuf":
+		// length is taken from the header of the payload, it is checked before the buffer is allocated
+		n, err := snappy.DecodedLen(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snappy payload: %w", err)
+		}
+		if n > maxBodyBytes {
+			return nil, fmt.Errorf("decompressed push request exceeds %d bytes", maxBodyBytes)
+		}
+		if body, err = snappy.Decode(nil, body); err != nil {
+			return nil, fmt.Errorf("invalid snappy payload: %w", err)
+		}
+		return decodeProtobuf(body)
+	default:
+		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+}
+
+func gunzip(body []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip payload: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, maxBodyBytes))
+}
+
+// {"streams": [{"stream": {"app": "foo"}, "values": [["<unix ns>", "line", {"traceID": "..."}]]}]}
+type pushRequestJson struct {
+	Streams []struct {
+		Stream map[string]string   `json:"stream"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"streams"`
+}
+
+func decodeJson(body []byte) ([]*Stream, error) {
+	var req pushRequestJson
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid push request: %w", err)
+	}
+	streams := make([]*Stream, 0, len(req.Streams))
+	for _, s := range req.Streams {
+		stream := &Stream{Labels: s.Stream, Entries: make([]Entry, 0, len(s.Values))}
+		for _, v := range s.Values {
+			if len(v) < 2 {
+				return nil, errors.New("invalid push request: entry needs timestamp and line")
+			}
+			var ts, line string
+			if err := json.Unmarshal(v[0], &ts); err != nil {
+				return nil, fmt.Errorf("invalid entry timestamp: %w", err)
+			}
+			if err := json.Unmarshal(v[1], &line); err != nil {
+				return nil, fmt.Errorf("invalid entry line: %w", err)
+			}
+			ns, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry timestamp: %w", err)
+			}
+			entry := Entry{Timestamp: time.Unix(0, ns).UTC(), Line: line}
+			if len(v) > 2 {
+				if err = json.Unmarshal(v[2], &entry.Metadata); err != nil {
+					return nil, fmt.Errorf("invalid entry metadata: %w", err)
+				}
+			}
+			stream.Entries = append(stream.Entries, entry)
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// decodeProtobuf reads logproto.PushRequest:
+//
+//	PushRequest   { repeated StreamAdapter streams = 1; }
+//	StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	EntryAdapter  { Timestamp timestamp = 1; string line = 2; repeated LabelPairAdapter structuredMetadata = 3; }
+func decodeProtobuf(b []byte) ([]*Stream, error) {
+	var streams []*Stream
+	err := forEachField(b, func(num protowire.Number, v []byte) error {
+		if num != 1 {
+			return nil
+		}
+		stream := &Stream{}
+		err := forEachField(v, func(num protowire.Number, v []byte) error {
+			switch num {
+			case 1:
+				labels, err := ParseLabels(string(v))
+				stream.Labels = labels
+				return err
+			case 2:
+				entry, err := decodeProtobufEntry(v)
+				stream.Entries = append(stream.Entries, entry)
+				return err
+			}
+			return nil
+		})
+		streams = append(streams, stream)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid push request: %w", err)
+	}
+	return streams, nil
+}
+
+func decodeProtobufEntry(b []byte) (Entry, error) {
+	var entry Entry
+	err := forEachField(b, func(num protowire.Number, v []byte) error {
+		switch num {
+		case 1:
+			ts, err := decodeProtobufTimestamp(v)
+			entry.Timestamp = ts
+			return err
+		case 2:
+			entry.Line = string(v)
+		case 3:
+			var name, value string
+			err := forEachField(v, func(num protowire.Number, v []byte) error {
+				switch num {
+				case 1:
+					name = string(v)
+				case 2:
+					value = string(v)
+				}
+				return nil
+			})
+			if entry.Metadata == nil {
+				entry.Metadata = make(map[string]string)
+			}
+			entry.Metadata[name] = value
+			return err
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// google.protobuf.Timestamp { int64 seconds = 1; int32 nanos = 2; }
+func decodeProtobufTimestamp(b []byte) (time.Time, error) {
+	var seconds, nanos int64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return time.Time{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return time.Time{}, protowire.ParseError(n)
+			}
+			switch num {
+			case 1:
+				seconds = int64(v)
+			case 2:
+				nanos = int64(int32(v))
+			}
+			b = b[n:]
+			continue
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+			return time.Time{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+// forEachField calls fn with content of every length-delimited field of message b, other fields are skipped
+func forEachField(b []byte, fn func(num protowire.Number, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// ParseLabels parses labels in Prometheus text format, e.g. {app="foo", env="prod"}
+func ParseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid labels: %s", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid labels: missing '=' in %s", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		rest := strings.TrimSpace(s[eq+1:])
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of label %s: %w", name, err)
+		}
+		if labels[name], err = strconv.Unquote(quoted); err != nil {
+			return nil, fmt.Errorf("invalid value of label %s: %w", name, err)
+		}
+		s = strings.TrimSpace(rest[len(quoted):])
+		s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+	}
+	return labels, nil
+}
@@ -0,0 +1,140 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func protobufPushRequest(labels string, ts time.Time, line string, metadata [][2]string) []byte {
+	var timestamp []byte
+	timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(ts.Unix()))
+	timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, uint64(ts.Nanosecond()))
+	var entry []byte
+	entry = appendBytesField(entry, 1, timestamp)
+	entry = appendBytesField(entry, 2, []byte(line))
+	for _, m := range metadata {
+		var pair []byte
+		pair = appendBytesField(pair, 1, []byte(m[0]))
+		pair = appendBytesField(pair, 2, []byte(m[1]))
+		entry = appendBytesField(entry, 3, pair)
+	}
+	var stream []byte
+	stream = appendBytesField(stream, 1, []byte(labels))
+	stream = appendBytesField(stream, 2, entry)
+	return appendBytesField(nil, 1, stream)
+}
+
+func gzipped(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(b)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestDecodePushRequest(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123, time.UTC)
+	jsonBody := []byte(`{"streams": [{"stream": {"app": "foo"}, "values": [
+		["1714557600000000123", "first line"],
+		["1714557601000000000", "second line", {"traceID": "abc"}]
+	]}]}`)
+	jsonWant := []*Stream{{
+		Labels: map[string]string{"app": "foo"},
+		Entries: []Entry{
+			{Timestamp: ts, Line: "first line"},
+			{Timestamp: ts.Add(time.Second - 123), Line: "second line", Metadata: map[string]string{"traceID": "abc"}},
+		},
+	}}
+	tests := []struct {
+		name        string
+		contentType string
+		encoding    string
+		body        []byte
+		want        []*Stream
+		wantErr     bool
+	}{
+		{name: "json", contentType: "application/json", body: jsonBody, want: jsonWant},
+		{name: "gzip json", contentType: "application/json; charset=utf-8", encoding: "gzip", body: gzipped(jsonBody), want: jsonWant},
+		{
+			name:        "snappy protobuf",
+			contentType: "application/x-protobuf",
+			body:        snappy.Encode(nil, protobufPushRequest(`{app="foo", env="prod"}`, ts, "line", [][2]string{{"traceID", "abc"}})),
+			want: []*Stream{{
+				Labels:  map[string]string{"app": "foo", "env": "prod"},
+				Entries: []Entry{{Timestamp: ts, Line: "line", Metadata: map[string]string{"traceID": "abc"}}},
+			}},
+		},
+		{
+			name: "protobuf without content type",
+			body: snappy.Encode(nil, protobufPushRequest(`{app="bar"}`, ts, "line", nil)),
+			want: []*Stream{{Labels: map[string]string{"app": "bar"}, Entries: []Entry{{Timestamp: ts, Line: "line"}}}},
+		},
+		{name: "entry without line", contentType: "application/json", body: []byte(`{"streams": [{"values": [["1"]]}]}`), wantErr: true},
+		{name: "timestamp not a number", contentType: "application/json", body: []byte(`{"streams": [{"values": [["now", "x"]]}]}`), wantErr: true},
+		{name: "protobuf not snappy", contentType: "application/x-protobuf", body: []byte("plain"), wantErr: true},
+		// header announces 64MB decompressed
+		{name: "snappy payload too large", contentType: "application/x-protobuf", body: []byte{0x80, 0x80, 0x80, 0x20, 0x00}, wantErr: true},
+		{name: "truncated protobuf", contentType: "application/x-protobuf", body: snappy.Encode(nil, []byte{0x0a, 0x10, 0x01}), wantErr: true},
+		{name: "invalid labels", body: snappy.Encode(nil, protobufPushRequest(`app="foo"`, ts, "line", nil)), wantErr: true},
+		{name: "unsupported content type", contentType: "text/plain", body: []byte("line"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/loki/api/v1/push", bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.encoding != "" {
+				r.Header.Set("Content-Encoding", tt.encoding)
+			}
+			got, err := DecodePushRequest(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodePushRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodePushRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{in: `{}`, want: map[string]string{}},
+		{in: `{app="foo"}`, want: map[string]string{"app": "foo"}},
+		{in: ` { app = "foo" , env="prod", } `, want: map[string]string{"app": "foo", "env": "prod"}},
+		{in: `{msg="say \"hi\", bye"}`, want: map[string]string{"msg": `say "hi", bye`}},
+		{in: `app="foo"`, wantErr: true},
+		{in: `{app}`, wantErr: true},
+		{in: `{app=foo}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLabels(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+// Package otlp decodes OTLP/HTTP log export requests (POST /v1/logs) in protobuf and JSON encoding
+// as sent by OpenTelemetry SDKs and collectors.
+package otlp
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const maxBodyBytes = 32 << 20
+
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJson     = "application/json"
+)
+
+// LogRecord is an OTel log record together with attributes of its resource and instrumentation scope
+type LogRecord struct {
+	Timestamp      time.Time // time of the event, observed time if the event time is not known
+	SeverityNumber int32
+	SeverityText   string
+	Body           any // string, bool, int64, float64, []byte, []any or map[string]any
+	Attributes     map[string]any
+	TraceID        string // hex, empty if not set
+	SpanID         string // hex, empty if not set
+	Resource       map[string]any
+	Scope          string
+}
+
+// DecodeLogsRequest reads log records from body of export request according to its content type and
+// encoding, it returns the content type the response has to be written in
+func DecodeLogsRequest(r *http.Request) ([]*LogRecord, string, error) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != ContentTypeProtobuf && contentType != ContentTypeJson {
+		return nil, ContentTypeJson, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, contentType, fmt.Errorf("invalid gzip payload: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	}
+	b, err := io.ReadAll(io.LimitReader(body, maxBodyBytes+1))
+	if err != nil {
+		return nil, contentType, err
+	}
+	if len(b) > maxBodyBytes {
+		return nil, contentType, fmt.Errorf("export request exceeds %d bytes", maxBodyBytes)
+	}
+	var records []*LogRecord
+	if contentType == ContentTypeJson {
+		records, err = decodeJson(b)
+	} else {
+		records, err = decodeProtobuf(b)
+	}
+	if err != nil {
+		return nil, contentType, fmt.Errorf("invalid export request: %w", err)
+	}
+	return records, contentType, nil
+}
+
+// decodeProtobuf reads ExportLogsServiceRequest:
+//
+//	ExportLogsServiceRequest { repeated ResourceLogs resource_logs = 1; }
+//	ResourceLogs             { Resource resource = 1; repeated ScopeLogs scope_logs = 2; }
+//	Resource                 { repeated KeyValue attributes = 1; }
+//	ScopeLogs                { InstrumentationScope scope = 1; repeated LogRecord log_records = 2; }
+//	InstrumentationScope     { string name = 1; }
+//	LogRecord                { fixed64 time_unix_nano = 1; SeverityNumber severity_number = 2; string severity_text = 3;
+//	                           AnyValue body = 5; repeated KeyValue attributes = 6; bytes trace_id = 9;
+//	                           bytes span_id = 10; fixed64 observed_time_unix_nano = 11; }
+func decodeProtobuf(b []byte) ([]*LogRecord, error) {
+	var records []*LogRecord
+	err := forEachField(b, func(num protowire.Number, _ uint64, v []byte) error {
+		if num != 1 {
+			return nil
+		}
+		resource := map[string]any{}
+		var scopes [][]byte
+		err := forEachField(v, func(num protowire.Number, _ uint64, v []byte) error {
+			switch num {
+			case 1:
+				return forEachField(v, func(num protowire.Number, _ uint64, v []byte) error {
+					if num == 1 {
+						return decodeProtobufKeyValue(v, resource)
+					}
+					return nil
+				})
+			case 2:
+				// resource may follow its scopes, they are decoded once the resource is known
+				scopes = append(scopes, v)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, s := range scopes {
+			if records, err = decodeProtobufScopeLogs(s, resource, records); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func decodeProtobufScopeLogs(b []byte, resource map[string]any, records []*LogRecord) ([]*LogRecord, error) {
+	var scope string
+	var logs []*LogRecord
+	err := forEachField(b, func(num protowire.Number, _ uint64, v []byte) error {
+		switch num {
+		case 1:
+			return forEachField(v, func(num protowire.Number, _ uint64, v []byte) error {
+				if num == 1 {
+					scope = string(v)
+				}
+				return nil
+			})
+		case 2:
+			rec, err := decodeProtobufLogRecord(v)
+			logs = append(logs, rec)
+			return err
+		}
+		return nil
+	})
+	for _, rec := range logs {
+		rec.Resource = resource
+		rec.Scope = scope
+	}
+	return append(records, logs...), err
+}
+
+func decodeProtobufLogRecord(b []byte) (*LogRecord, error) {
+	rec := &LogRecord{Attributes: map[string]any{}}
+	var observed uint64
+	err := forEachField(b, func(num protowire.Number, u uint64, v []byte) (err error) {
+		switch num {
+		case 1:
+			rec.Timestamp = unixNano(u)
+		case 2:
+			rec.SeverityNumber = int32(u)
+		case 3:
+			rec.SeverityText = string(v)
+		case 5:
+			rec.Body, err = decodeProtobufAnyValue(v)
+		case 6:
+			err = decodeProtobufKeyValue(v, rec.Attributes)
+		case 9:
+			rec.TraceID = idHex(v)
+		case 10:
+			rec.SpanID = idHex(v)
+		case 11:
+			observed = u
+		}
+		return err
+	})
+	if rec.Timestamp.IsZero() && observed != 0 {
+		rec.Timestamp = unixNano(observed)
+	}
+	return rec, err
+}
+
+// KeyValue { string key = 1; AnyValue value = 2; }
+func decodeProtobufKeyValue(b []byte, into map[string]any) error {
+	var key string
+	var value any
+	err := forEachField(b, func(num protowire.Number, _ uint64, v []byte) (err error) {
+		switch num {
+		case 1:
+			key = string(v)
+		case 2:
+			value, err = decodeProtobufAnyValue(v)
+		}
+		return err
+	})
+	into[key] = value
+	return err
+}
+
+// AnyValue { oneof { string string_value = 1; bool bool_value = 2; int64 int_value = 3; double double_value = 4;
+// ArrayValue array_value = 5; KeyValueList kvlist_value = 6; bytes bytes_value = 7; } }, ArrayValue and
+// KeyValueList hold their items in field 1
+func decodeProtobufAnyValue(b []byte) (any, error) {
+	var value any
+	err := forEachField(b, func(num protowire.Number, u uint64, v []byte) error {
+		switch num {
+		case 1:
+			value = string(v)
+		case 2:
+			value = u != 0
+		case 3:
+			value = int64(u)
+		case 4:
+			value = math.Float64frombits(u)
+		case 5:
+			values := []any{}
+			value = values
+			return forEachField(v, func(num protowire.Number, _ uint64, v []byte) error {
+				if num != 1 {
+					return nil
+				}
+				item, err := decodeProtobufAnyValue(v)
+				values = append(values, item)
+				value = values
+				return err
+			})
+		case 6:
+			values := map[string]any{}
+			value = values
+			return forEachField(v, func(num protowire.Number, _ uint64, v []byte) error {
+				if num == 1 {
+					return decodeProtobufKeyValue(v, values)
+				}
+				return nil
+			})
+		case 7:
+			value = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// forEachField calls fn for every field of message b, u is the value of varint and fixed size fields
+// and v the content of length-delimited ones
+func forEachField(b []byte, fn func(num protowire.Number, u uint64, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var u uint64
+		var v []byte
+		switch typ {
+		case protowire.VarintType:
+			u, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			u, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var u32 uint32
+			u32, n = protowire.ConsumeFixed32(b)
+			u = uint64(u32)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, u, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// OTLP/JSON is the protobuf JSON mapping with camelCase names, 64-bit integers may be strings and
+// trace and span ids are hex instead of base64
+type exportRequestJson struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []keyValueJson `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano         json.Number    `json:"timeUnixNano"`
+				ObservedTimeUnixNano json.Number    `json:"observedTimeUnixNano"`
+				SeverityNumber       int32          `json:"severityNumber"`
+				SeverityText         string         `json:"severityText"`
+				Body                 *anyValueJson  `json:"body"`
+				Attributes           []keyValueJson `json:"attributes"`
+				TraceID              string         `json:"traceId"`
+				SpanID               string         `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type keyValueJson struct {
+	Key   string        `json:"key"`
+	Value *anyValueJson `json:"value"`
+}
+
+type anyValueJson struct {
+	StringValue *string      `json:"stringValue"`
+	BoolValue   *bool        `json:"boolValue"`
+	IntValue    *json.Number `json:"intValue"`
+	DoubleValue *json.Number `json:"doubleValue"`
+	ArrayValue  *struct {
+		Values []*anyValueJson `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []keyValueJson `json:"values"`
+	} `json:"kvlistValue"`
+	BytesValue *string `json:"bytesValue"`
+}
+
+func decodeJson(b []byte) ([]*LogRecord, error) {
+	var req exportRequestJson
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, err
+	}
+	var records []*LogRecord
+	for _, rl := range req.ResourceLogs {
+		resource, err := keyValuesJson(rl.Resource.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		for _, sl := range rl.ScopeLogs {
+			for _, l := range sl.LogRecords {
+				rec := &LogRecord{
+					SeverityNumber: l.SeverityNumber,
+					SeverityText:   l.SeverityText,
+					TraceID:        l.TraceID,
+					SpanID:         l.SpanID,
+					Resource:       resource,
+					Scope:          sl.Scope.Name,
+				}
+				for _, ts := range []json.Number{l.TimeUnixNano, l.ObservedTimeUnixNano} {
+					if ts == "" || !rec.Timestamp.IsZero() {
+						continue
+					}
+					ns, err := strconv.ParseUint(string(ts), 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid timestamp %s: %w", ts, err)
+					}
+					rec.Timestamp = unixNano(ns)
+				}
+				if rec.Body, err = l.Body.value(); err != nil {
+					return nil, err
+				}
+				if rec.Attributes, err = keyValuesJson(l.Attributes); err != nil {
+					return nil, err
+				}
+				records = append(records, rec)
+			}
+		}
+	}
+	return records, nil
+}
+
+func keyValuesJson(kvs []keyValueJson) (map[string]any, error) {
+	values := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		v, err := kv.Value.value()
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of attribute %s: %w", kv.Key, err)
+		}
+		values[kv.Key] = v
+	}
+	return values, nil
+}
+
+func (a *anyValueJson) value() (any, error) {
+	switch {
+	case a == nil:
+		return nil, nil
+	case a.StringValue != nil:
+		return *a.StringValue, nil
+	case a.BoolValue != nil:
+		return *a.BoolValue, nil
+	case a.IntValue != nil:
+		return a.IntValue.Int64()
+	case a.DoubleValue != nil:
+		return a.DoubleValue.Float64()
+	case a.ArrayValue != nil:
+		values := make([]any, 0, len(a.ArrayValue.Values))
+		for _, item := range a.ArrayValue.Values {
+			v, err := item.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case a.KvlistValue != nil:
+		return keyValuesJson(a.KvlistValue.Values)
+	case a.BytesValue != nil:
+		return base64.StdEncoding.DecodeString(*a.BytesValue)
+	}
+	return nil, nil
+}
+
+func unixNano(ns uint64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ns)).UTC()
+}
+
+// idHex returns hex of trace or span id, ids consisting of zeros only are invalid and treated as not set
+func idHex(id []byte) string {
+	for _, b := range id {
+		if b != 0 {
+			return hex.EncodeToString(id)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func field(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func varintField(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func fixed64Field(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func keyValue(key string, value []byte) []byte {
+	return field(field(nil, 1, []byte(key)), 2, value)
+}
+
+// protobufExportRequest encodes the same request as jsonExportRequest, the resource follows its scope logs
+func protobufExportRequest() []byte {
+	var rec []byte
+	rec = fixed64Field(rec, 1, uint64(time.Date(2024, 5, 1, 10, 0, 0, 5, time.UTC).UnixNano()))
+	rec = varintField(rec, 2, 17)
+	rec = field(rec, 3, []byte("ERROR"))
+	rec = field(rec, 5, field(nil, 1, []byte("payment failed")))
+	rec = field(rec, 6, keyValue("retries", varintField(nil, 3, 3)))
+	rec = field(rec, 6, keyValue("ratio", fixed64Field(nil, 4, math.Float64bits(0.5))))
+	rec = field(rec, 6, keyValue("ok", varintField(nil, 2, 0)))
+	list := field(nil, 1, field(nil, 1, []byte("a")))
+	list = field(list, 1, varintField(nil, 3, 2))
+	rec = field(rec, 6, keyValue("items", field(nil, 5, list)))
+	rec = field(rec, 6, keyValue("http", field(nil, 6, field(nil, 1, keyValue("method", field(nil, 1, []byte("POST")))))))
+	rec = field(rec, 9, []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c})
+	rec = field(rec, 10, make([]byte, 8))
+
+	var observedOnly []byte
+	observedOnly = fixed64Field(observedOnly, 11, uint64(time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC).UnixNano()))
+	observedOnly = field(observedOnly, 5, field(nil, 1, []byte("second")))
+
+	scope := field(nil, 1, field(nil, 1, []byte("io.example.billing")))
+	scope = field(scope, 2, rec)
+	scope = field(scope, 2, observedOnly)
+	var resourceLogs []byte
+	resourceLogs = field(resourceLogs, 2, scope)
+	resourceLogs = field(resourceLogs, 1, field(nil, 1, keyValue("service.name", field(nil, 1, []byte("billing")))))
+	return field(nil, 1, resourceLogs)
+}
+
+const jsonExportRequest = `{"resourceLogs": [{
+	"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "billing"}}]},
+	"scopeLogs": [{
+		"scope": {"name": "io.example.billing"},
+		"logRecords": [{
+			"timeUnixNano": "1714557600000000005",
+			"severityNumber": 17,
+			"severityText": "ERROR",
+			"body": {"stringValue": "payment failed"},
+			"attributes": [
+				{"key": "retries", "value": {"intValue": "3"}},
+				{"key": "ratio", "value": {"doubleValue": 0.5}},
+				{"key": "ok", "value": {"boolValue": false}},
+				{"key": "items", "value": {"arrayValue": {"values": [{"stringValue": "a"}, {"intValue": 2}]}}},
+				{"key": "http", "value": {"kvlistValue": {"values": [{"key": "method", "value": {"stringValue": "POST"}}]}}}
+			],
+			"traceId": "5b8efff798038103d269b633813fc60c",
+			"spanId": ""
+		}, {
+			"observedTimeUnixNano": 1714557601000000000,
+			"body": {"stringValue": "second"}
+		}]
+	}]
+}]}`
+
+func expectedRecords() []*LogRecord {
+	resource := map[string]any{"service.name": "billing"}
+	return []*LogRecord{
+		{
+			Timestamp:      time.Date(2024, 5, 1, 10, 0, 0, 5, time.UTC),
+			SeverityNumber: 17,
+			SeverityText:   "ERROR",
+			Body:           "payment failed",
+			Attributes: map[string]any{
+				"retries": int64(3),
+				"ratio":   0.5,
+				"ok":      false,
+				"items":   []any{"a", int64(2)},
+				"http":    map[string]any{"method": "POST"},
+			},
+			TraceID:  "5b8efff798038103d269b633813fc60c",
+			Resource: resource,
+			Scope:    "io.example.billing",
+		},
+		{
+			Timestamp:  time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC),
+			Body:       "second",
+			Attributes: map[string]any{},
+			Resource:   resource,
+			Scope:      "io.example.billing",
+		},
+	}
+}
+
+func gzipped(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(b)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeLogsRequest(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		encoding        string
+		body            []byte
+		want            []*LogRecord
+		wantContentType string
+		wantErr         bool
+	}{
+		{name: "protobuf", contentType: ContentTypeProtobuf, body: protobufExportRequest(), want: expectedRecords(), wantContentType: ContentTypeProtobuf},
+		{name: "json", contentType: ContentTypeJson, body: []byte(jsonExportRequest), want: expectedRecords(), wantContentType: ContentTypeJson},
+		{name: "gzip protobuf", contentType: ContentTypeProtobuf, encoding: "gzip", body: gzipped(protobufExportRequest()), want: expectedRecords(), wantContentType: ContentTypeProtobuf},
+		{name: "empty protobuf", contentType: ContentTypeProtobuf, body: nil, want: nil, wantContentType: ContentTypeProtobuf},
+		{name: "truncated protobuf", contentType: ContentTypeProtobuf, body: protobufExportRequest()[:20], wantContentType: ContentTypeProtobuf, wantErr: true},
+		{name: "invalid json", contentType: ContentTypeJson, body: []byte(`{"resourceLogs": [`), wantContentType: ContentTypeJson, wantErr: true},
+		{name: "invalid json int", contentType: ContentTypeJson, body: []byte(`{"resourceLogs": [{"resource": {"attributes": [{"key": "n", "value": {"intValue": "x"}}]}}]}`), wantContentType: ContentTypeJson, wantErr: true},
+		{name: "invalid gzip", contentType: ContentTypeJson, encoding: "gzip", body: []byte("{}"), wantContentType: ContentTypeJson, wantErr: true},
+		{name: "unsupported content type", contentType: "text/plain", body: []byte("x"), wantContentType: ContentTypeJson, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/logs", bytes.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			if tt.encoding != "" {
+				r.Header.Set("Content-Encoding", tt.encoding)
+			}
+			got, contentType, err := DecodeLogsRequest(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeLogsRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("DecodeLogsRequest() content type = %s, want %s", contentType, tt.wantContentType)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeLogsRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
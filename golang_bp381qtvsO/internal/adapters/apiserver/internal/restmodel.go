@@ -5,12 +5,14 @@ import (
 	"example_consumer/internal/core/model"
 	"fmt"
 	"github.com/samber/lo"
+	"time"
 )
 
 type ContactToSaveRest struct {
-	FirstName string      `json:"first_name"`
-	LastName  string      `json:"last_name"`
-	Phones    []PhoneRest `json:"phones"`
+	CustomerNumber string      `json:"customer_number,omitempty"`
+	FirstName      string      `json:"first_name"`
+	LastName       string      `json:"last_name"`
+	Phones         []PhoneRest `json:"phones"`
 }
 
 type PhoneRest struct {
@@ -19,10 +21,11 @@ type PhoneRest struct {
 }
 
 type ContactRest struct {
-	ID        string      `json:"id"`
-	FirstName string      `json:"first_name"`
-	LastName  string      `json:"last_name"`
-	Phones    []PhoneRest `json:"phones"`
+	ID             string      `json:"id"`
+	CustomerNumber string      `json:"customer_number,omitempty"`
+	FirstName      string      `json:"first_name"`
+	LastName       string      `json:"last_name"`
+	Phones         []PhoneRest `json:"phones"`
 }
 
 func (r *ContactToSaveRest) toModel() (*model.ContactToSave, error) {
@@ -41,9 +44,10 @@ func (r *ContactToSaveRest) toModel() (*model.ContactToSave, error) {
 		phones[i] = phoneModel
 	}
 	return &model.ContactToSave{
-		FirstName: r.FirstName,
-		LastName:  r.LastName,
-		Phones:    phones,
+		CustomerNumber: r.CustomerNumber,
+		FirstName:      r.FirstName,
+		LastName:       r.LastName,
+		Phones:         phones,
 	}, nil
 }
 
@@ -93,10 +97,11 @@ func contactModelToRest(m *model.Contact) *ContactRest {
 
 	})
 	return &ContactRest{
-		ID:        m.ID,
-		FirstName: m.FirstName,
-		LastName:  m.LastName,
-		Phones:    phones,
+		ID:             m.ID,
+		CustomerNumber: m.CustomerNumber,
+		FirstName:      m.FirstName,
+		LastName:       m.LastName,
+		Phones:         phones,
 	}
 }
 
@@ -105,3 +110,485 @@ type VersionRest struct {
 	Version string `json:"version"`
 	Build   string `json:"build"`
 }
+
+type GrokTestRest struct {
+	Pattern string   `json:"pattern"`
+	Lines   []string `json:"lines"`
+}
+
+type GrokMatchRest struct {
+	Line    string         `json:"line"`
+	Matched bool           `json:"matched"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+func grokMatchModelToRest(m *model.GrokMatch) *GrokMatchRest {
+	return &GrokMatchRest{
+		Line:    m.Line,
+		Matched: m.Matched,
+		Fields:  m.Fields,
+	}
+}
+
+type ErasureRest struct {
+	CustomerNumber  string                 `json:"customer_number"`
+	Tenant          string                 `json:"tenant,omitempty"`
+	Status          string                 `json:"status"`
+	Action          string                 `json:"action"`
+	RequestedAt     time.Time              `json:"requested_at"`
+	CompletedAt     *time.Time             `json:"completed_at,omitempty"`
+	ContactsDeleted int                    `json:"contacts_deleted"`
+	Logs            []ErasureLogResultRest `json:"logs"`
+	NotCovered      []string               `json:"not_covered,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+type ErasureLogResultRest struct {
+	Store   string `json:"store"`
+	Matched int64  `json:"matched"`
+	Erased  int64  `json:"erased"`
+}
+
+func erasureModelToRest(m *model.Erasure) *ErasureRest {
+	return &ErasureRest{
+		CustomerNumber:  m.CustomerNumber,
+		Tenant:          m.Tenant,
+		Status:          string(m.Status),
+		Action:          string(m.Action),
+		RequestedAt:     m.RequestedAt,
+		CompletedAt:     m.CompletedAt,
+		ContactsDeleted: m.ContactsDeleted,
+		Logs: lo.Map(m.Logs, func(item *model.ErasureLogResult, _ int) ErasureLogResultRest {
+			return ErasureLogResultRest{
+				Store:   item.Store,
+				Matched: item.Matched,
+				Erased:  item.Erased,
+			}
+		}),
+		NotCovered: m.NotCovered,
+		Error:      m.Error,
+	}
+}
+
+type LogRecordRest struct {
+	ID        string         `json:"id"`
+	Store     string         `json:"store,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	Topic     string         `json:"topic,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Source    string         `json:"source"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+func logRecordModelToRest(m *model.LogRecord) *LogRecordRest {
+	return &LogRecordRest{
+		ID:        m.ID,
+		Timestamp: m.Timestamp,
+		Topic:     m.Topic,
+		Tenant:    m.Tenant,
+		Source:    m.Source,
+		Level:     string(m.Level),
+		Message:   m.Message,
+		Fields:    m.Fields,
+	}
+}
+
+type ExportManifestRest struct {
+	CustomerNumber string                   `json:"customer_number"`
+	CreatedAt      time.Time                `json:"created_at"`
+	Files          []ExportManifestFileRest `json:"files"`
+	Complete       bool                     `json:"complete"`
+	Error          string                   `json:"error,omitempty"`
+}
+
+type ExportManifestFileRest struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+}
+
+type SamplingRuleRest struct {
+	Source     string   `json:"source"`
+	Rate       float64  `json:"rate,omitempty"`
+	Burst      int      `json:"burst,omitempty"`
+	Levels     []string `json:"levels,omitempty"`
+	SampleRate float64  `json:"sample_rate,omitempty"`
+	SampleBy   string   `json:"sample_by,omitempty"`
+}
+
+func (r *SamplingRuleRest) toModel() *model.SamplingRule {
+	return &model.SamplingRule{
+		Source:     r.Source,
+		Rate:       r.Rate,
+		Burst:      r.Burst,
+		Levels:     lo.Map(r.Levels, func(item string, _ int) model.LogLevel { return model.ParseLogLevel(item) }),
+		SampleRate: r.SampleRate,
+		SampleBy:   r.SampleBy,
+	}
+}
+
+func samplingRuleModelToRest(m *model.SamplingRule) *SamplingRuleRest {
+	return &SamplingRuleRest{
+		Source:     m.Source,
+		Rate:       m.Rate,
+		Burst:      m.Burst,
+		Levels:     lo.Map(m.Levels, func(item model.LogLevel, _ int) string { return string(item) }),
+		SampleRate: m.SampleRate,
+		SampleBy:   m.SampleBy,
+	}
+}
+
+type RouteRest struct {
+	Name     string            `json:"name"`
+	Topic    string            `json:"topic,omitempty"`
+	Source   string            `json:"source,omitempty"`
+	Levels   []string          `json:"levels,omitempty"`
+	Match    map[string]string `json:"match,omitempty"`
+	Sinks    []string          `json:"sinks"`
+	Continue bool              `json:"continue,omitempty"`
+}
+
+func routeModelToRest(m *model.Route) *RouteRest {
+	return &RouteRest{
+		Name:     m.Name,
+		Topic:    m.Topic,
+		Source:   m.Source,
+		Levels:   lo.Map(m.Levels, func(item model.LogLevel, _ int) string { return string(item) }),
+		Match:    m.Match,
+		Sinks:    lo.Ternary(m.Sinks == nil, []string{}, m.Sinks),
+		Continue: m.Continue,
+	}
+}
+
+type LogPatternRest struct {
+	Fingerprint string    `json:"fingerprint"`
+	Source      string    `json:"source"`
+	Pattern     string    `json:"pattern"`
+	Count       int64     `json:"count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+func logPatternModelToRest(m *model.LogPattern) *LogPatternRest {
+	return &LogPatternRest{
+		Fingerprint: m.Fingerprint,
+		Source:      m.Source,
+		Pattern:     m.Pattern,
+		Count:       m.Count,
+		FirstSeen:   m.FirstSeen,
+		LastSeen:    m.LastSeen,
+	}
+}
+
+type ElasticInfoRest struct {
+	Name        string             `json:"name"`
+	ClusterName string             `json:"cluster_name"`
+	Version     ElasticVersionRest `json:"version"`
+	Tagline     string             `json:"tagline"`
+}
+
+type ElasticVersionRest struct {
+	Number string `json:"number"`
+}
+
+type ElasticBulkRest struct {
+	Took   int64                             `json:"took"`
+	Errors bool                              `json:"errors"`
+	Items  []map[string]*ElasticBulkItemRest `json:"items"`
+}
+
+type ElasticBulkItemRest struct {
+	Index   string                 `json:"_index"`
+	ID      string                 `json:"_id"`
+	Version int                    `json:"_version,omitempty"`
+	Result  string                 `json:"result,omitempty"`
+	Status  int                    `json:"status"`
+	Error   *ElasticErrorCauseRest `json:"error,omitempty"`
+}
+
+type ElasticErrorRest struct {
+	Error  ElasticErrorCauseRest `json:"error"`
+	Status int                   `json:"status"`
+}
+
+type ElasticErrorCauseRest struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// LogQueryRest selects log records, time range is either relative (last, e.g. 15m) or absolute (from, to)
+type LogQueryRest struct {
+	Store   string            `json:"store,omitempty"`
+	Text    string            `json:"text,omitempty"`
+	Sources []string          `json:"sources,omitempty"`
+	Topics  []string          `json:"topics,omitempty"`
+	Levels  []string          `json:"levels,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Last    string            `json:"last,omitempty"`
+	From    *time.Time        `json:"from,omitempty"`
+	To      *time.Time        `json:"to,omitempty"`
+	Limit   int               `json:"limit,omitempty"`
+}
+
+func (r *LogQueryRest) toModel() (model.LogQuery, model.TimeRange, error) {
+	q := model.LogQuery{
+		Store:   r.Store,
+		Text:    r.Text,
+		Sources: r.Sources,
+		Topics:  r.Topics,
+		Fields:  r.Fields,
+		Limit:   r.Limit,
+	}
+	for _, level := range r.Levels {
+		if model.ParseLogLevel(level) != model.LogLevel(level) {
+			return q, model.TimeRange{}, fmt.Errorf("invalid level: %s", level)
+		}
+		q.Levels = append(q.Levels, model.LogLevel(level))
+	}
+	var tr model.TimeRange
+	if r.Last != "" {
+		if r.From != nil || r.To != nil {
+			return q, tr, errors.New("last can not be combined with from and to")
+		}
+		last, err := time.ParseDuration(r.Last)
+		if err != nil || last <= 0 {
+			return q, tr, fmt.Errorf("invalid last: %s", r.Last)
+		}
+		tr.Last = last
+	}
+	if r.From != nil {
+		tr.From = r.From.UTC()
+	}
+	if r.To != nil {
+		tr.To = r.To.UTC()
+	}
+	return q, tr, nil
+}
+
+func logQueryModelToRest(q *model.LogQuery, tr model.TimeRange) *LogQueryRest {
+	r := &LogQueryRest{
+		Store:   q.Store,
+		Text:    q.Text,
+		Sources: q.Sources,
+		Topics:  q.Topics,
+		Levels:  lo.Map(q.Levels, func(item model.LogLevel, _ int) string { return string(item) }),
+		Fields:  q.Fields,
+		Limit:   q.Limit,
+	}
+	if tr.Last > 0 {
+		r.Last = tr.Last.String()
+	}
+	if !tr.From.IsZero() {
+		r.From = &tr.From
+	}
+	if !tr.To.IsZero() {
+		r.To = &tr.To
+	}
+	return r
+}
+
+type LogAggregationRest struct {
+	LogQueryRest
+	GroupBy  string `json:"group_by,omitempty"`
+	Interval string `json:"interval,omitempty"`
+}
+
+func (r *LogAggregationRest) toModel(now time.Time) (*model.LogAggregation, error) {
+	q, tr, err := r.LogQueryRest.toModel()
+	if err != nil {
+		return nil, err
+	}
+	q.From, q.To = tr.Resolve(now)
+	agg := &model.LogAggregation{Query: q, GroupBy: r.GroupBy}
+	if agg.Interval, err = parseInterval(r.Interval); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+func parseInterval(interval string) (time.Duration, error) {
+	if interval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid interval, at least 1s is required: %s", interval)
+	}
+	return d, nil
+}
+
+type LogBucketRest struct {
+	Key   string     `json:"key,omitempty"`
+	Time  *time.Time `json:"time,omitempty"`
+	Count int64      `json:"count"`
+}
+
+func logBucketModelToRest(m *model.LogBucket) *LogBucketRest {
+	r := &LogBucketRest{Key: m.Key, Count: m.Count}
+	if !m.Time.IsZero() {
+		r.Time = &m.Time
+	}
+	return r
+}
+
+type SavedSearchToSaveRest struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Query       LogQueryRest `json:"query"`
+}
+
+type SavedSearchRest struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Query       *LogQueryRest `json:"query"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+func (r *SavedSearchToSaveRest) toModel() (*model.SavedSearch, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	q, tr, err := r.Query.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &model.SavedSearch{
+		Name:        r.Name,
+		Description: r.Description,
+		Query:       q,
+		TimeRange:   tr,
+	}, nil
+}
+
+func savedSearchModelToRest(m *model.SavedSearch) *SavedSearchRest {
+	return &SavedSearchRest{
+		ID:          m.ID,
+		Name:        m.Name,
+		Description: m.Description,
+		Query:       logQueryModelToRest(&m.Query, m.TimeRange),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+type DashboardToSaveRest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	Panels      []*DashboardPanelRest `json:"panels"`
+}
+
+type DashboardRest struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	Panels      []*DashboardPanelRest `json:"panels"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// DashboardPanelRest visualization is one of logs, count, bar (needs group_by) and timeseries
+type DashboardPanelRest struct {
+	Title         string        `json:"title"`
+	Visualization string        `json:"visualization"`
+	Query         *LogQueryRest `json:"query"`
+	GroupBy       string        `json:"group_by,omitempty"`
+	Interval      string        `json:"interval,omitempty"`
+}
+
+func (r *DashboardToSaveRest) toModel() (*model.Dashboard, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	d := &model.Dashboard{
+		Name:        r.Name,
+		Description: r.Description,
+		Panels:      make([]*model.DashboardPanel, 0, len(r.Panels)),
+	}
+	for i, p := range r.Panels {
+		panel, err := p.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("panel %d: %w", i+1, err)
+		}
+		d.Panels = append(d.Panels, panel)
+	}
+	return d, nil
+}
+
+func (r *DashboardPanelRest) toModel() (*model.DashboardPanel, error) {
+	p := &model.DashboardPanel{
+		Title:         r.Title,
+		Visualization: model.PanelVisualization(r.Visualization),
+		GroupBy:       r.GroupBy,
+	}
+	switch p.Visualization {
+	case model.PanelVisualizationLogs, model.PanelVisualizationCount, model.PanelVisualizationTimeSeries:
+	case model.PanelVisualizationBar:
+		if p.GroupBy == "" {
+			return nil, errors.New("bar panel needs group_by")
+		}
+	default:
+		return nil, fmt.Errorf("invalid visualization: %s", r.Visualization)
+	}
+	var err error
+	if p.Interval, err = parseInterval(r.Interval); err != nil {
+		return nil, err
+	}
+	if r.Query != nil {
+		if p.Query, p.TimeRange, err = r.Query.toModel(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func dashboardModelToRest(m *model.Dashboard) *DashboardRest {
+	return &DashboardRest{
+		ID:          m.ID,
+		Name:        m.Name,
+		Description: m.Description,
+		Panels: lo.Map(m.Panels, func(item *model.DashboardPanel, _ int) *DashboardPanelRest {
+			r := &DashboardPanelRest{
+				Title:         item.Title,
+				Visualization: string(item.Visualization),
+				Query:         logQueryModelToRest(&item.Query, item.TimeRange),
+				GroupBy:       item.GroupBy,
+			}
+			if item.Interval > 0 {
+				r.Interval = item.Interval.String()
+			}
+			return r
+		}),
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+type PanelResultRest struct {
+	Title         string           `json:"title"`
+	Visualization string           `json:"visualization"`
+	From          *time.Time       `json:"from,omitempty"`
+	To            *time.Time       `json:"to,omitempty"`
+	Records       []*LogRecordRest `json:"records,omitempty"`
+	Buckets       []*LogBucketRest `json:"buckets,omitempty"`
+	Error         string           `json:"error,omitempty"`
+}
+
+func panelResultModelToRest(m *model.PanelResult) *PanelResultRest {
+	r := &PanelResultRest{
+		Title:         m.Title,
+		Visualization: string(m.Visualization),
+		Records:       lo.Map(m.Records, func(item *model.LogRecord, _ int) *LogRecordRest { return logRecordModelToRest(item) }),
+		Buckets:       lo.Map(m.Buckets, func(item *model.LogBucket, _ int) *LogBucketRest { return logBucketModelToRest(item) }),
+		Error:         m.Error,
+	}
+	if !m.From.IsZero() {
+		r.From = &m.From
+	}
+	if !m.To.IsZero() {
+		r.To = &m.To
+	}
+	return r
+}
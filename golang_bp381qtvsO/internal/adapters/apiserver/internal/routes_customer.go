@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/usecase"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// ExportCustomerData streams ZIP with contacts, log records and events of the customer and a manifest.
+// Records are written as they are read, response status can not be changed once streaming started, so
+// the manifest is written last and tells whether the export is complete. If reading fails, the manifest
+// says so and the ZIP is closed; if the client connection fails, the ZIP has no manifest at all.
+func ExportCustomerData(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		customerNumber := c.Param("customerNumber")
+		contacts, err := uc.LoadCustomerContacts(ctx, customerNumber)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+
+		fileName := fmt.Sprintf("customer-%s-export.zip", unsafeFileNameChars.ReplaceAllString(customerNumber, "_"))
+		c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+		c.Response().WriteHeader(http.StatusOK)
+
+		export := &customerExport{
+			zip: zip.NewWriter(c.Response()),
+			manifest: ExportManifestRest{
+				CustomerNumber: customerNumber,
+				CreatedAt:      time.Now().UTC(),
+			},
+		}
+		if err = export.write(c, uc, contacts); err != nil {
+			app.Logger(ctx).Errorf("Export of customer %s failed: %v", customerNumber, err)
+		}
+		return nil
+	}
+}
+
+type customerExport struct {
+	zip      *zip.Writer
+	manifest ExportManifestRest
+}
+
+func (e *customerExport) write(c echo.Context, uc *usecase.UseCases, contacts []*model.Contact) error {
+	err := e.writeFiles(c, uc, contacts)
+	if err != nil {
+		e.manifest.Error = "export failed, files are missing or incomplete"
+	}
+	e.manifest.Complete = err == nil
+	w, merr := e.zip.Create("manifest.json")
+	if merr == nil {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		merr = enc.Encode(e.manifest)
+	}
+	if merr == nil {
+		merr = e.zip.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return merr
+}
+
+func (e *customerExport) writeFiles(c echo.Context, uc *usecase.UseCases, contacts []*model.Contact) error {
+	ctx := c.Request().Context()
+	customerNumber := e.manifest.CustomerNumber
+	contactRestList := make([]*ContactRest, len(contacts))
+	for i, contact := range contacts {
+		contactRestList[i] = contactModelToRest(contact)
+	}
+
+	w, err := e.zip.Create("contacts.json")
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(w).Encode(contactRestList); err != nil {
+		return err
+	}
+	e.addFile("contacts.json", len(contactRestList))
+
+	if w, err = e.zip.Create("contacts.vcf"); err != nil {
+		return err
+	}
+	for _, contact := range contactRestList {
+		if err = writeVCard(w, contact); err != nil {
+			return err
+		}
+	}
+	e.addFile("contacts.vcf", len(contactRestList))
+
+	for _, part := range []struct {
+		name   string
+		events bool
+	}{{"logs.ndjson", false}, {"events.ndjson", true}} {
+		if w, err = e.zip.Create(part.name); err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		count := 0
+		err = uc.StreamCustomerLogs(ctx, customerNumber, part.events, func(store string, rec *model.LogRecord) error {
+			r := logRecordModelToRest(rec)
+			r.Store = store
+			count++
+			return enc.Encode(r)
+		})
+		// records written so far are listed with the failed file
+		e.addFile(part.name, count)
+		if err != nil {
+			return err
+		}
+		c.Response().Flush()
+	}
+	return nil
+}
+
+func (e *customerExport) addFile(name string, records int) {
+	e.manifest.Files = append(e.manifest.Files, ExportManifestFileRest{Name: name, Records: records})
+}
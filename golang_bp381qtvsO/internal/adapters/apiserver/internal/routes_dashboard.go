@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func CreateSavedSearch(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(SavedSearchToSaveRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		search, err := req.toModel()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		saved, err := uc.AddSavedSearch(c.Request().Context(), search)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		return c.JSON(http.StatusCreated, savedSearchModelToRest(saved))
+	}
+}
+
+func UpdateSavedSearch(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(SavedSearchToSaveRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		search, err := req.toModel()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		updated, found, err := uc.UpdateSavedSearch(c.Request().Context(), c.Param("id"), search)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.JSON(http.StatusOK, savedSearchModelToRest(updated))
+	}
+}
+
+func ListSavedSearches(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		searches, err := uc.LoadSavedSearches(c.Request().Context())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		searchRestList := make([]*SavedSearchRest, len(searches))
+		for i, search := range searches {
+			searchRestList[i] = savedSearchModelToRest(search)
+		}
+		return c.JSON(http.StatusOK, searchRestList)
+	}
+}
+
+func GetSavedSearch(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		search, err := uc.LoadSavedSearchByID(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if search == nil {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.JSON(http.StatusOK, savedSearchModelToRest(search))
+	}
+}
+
+func DeleteSavedSearch(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		found, err := uc.DeleteSavedSearch(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func RunSavedSearch(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		records, found, err := uc.RunSavedSearch(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return logQueryError(err)
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		recordRestList := make([]*LogRecordRest, len(records))
+		for i, rec := range records {
+			recordRestList[i] = logRecordModelToRest(rec)
+		}
+		return c.JSON(http.StatusOK, recordRestList)
+	}
+}
+
+func CreateDashboard(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(DashboardToSaveRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		dashboard, err := req.toModel()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		saved, err := uc.AddDashboard(c.Request().Context(), dashboard)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		return c.JSON(http.StatusCreated, dashboardModelToRest(saved))
+	}
+}
+
+func UpdateDashboard(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(DashboardToSaveRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		dashboard, err := req.toModel()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		updated, found, err := uc.UpdateDashboard(c.Request().Context(), c.Param("id"), dashboard)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.JSON(http.StatusOK, dashboardModelToRest(updated))
+	}
+}
+
+func ListDashboards(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		dashboards, err := uc.LoadDashboards(c.Request().Context())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		dashboardRestList := make([]*DashboardRest, len(dashboards))
+		for i, dashboard := range dashboards {
+			dashboardRestList[i] = dashboardModelToRest(dashboard)
+		}
+		return c.JSON(http.StatusOK, dashboardRestList)
+	}
+}
+
+func GetDashboard(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		dashboard, err := uc.LoadDashboardByID(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if dashboard == nil {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.JSON(http.StatusOK, dashboardModelToRest(dashboard))
+	}
+}
+
+func DeleteDashboard(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		found, err := uc.DeleteDashboard(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// RunDashboard executes all panels of the dashboard, failing panels report their error in their result
+func RunDashboard(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		results, found, err := uc.RunDashboard(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		resultRestList := make([]*PanelResultRest, len(results))
+		for i, result := range results {
+			resultRestList[i] = panelResultModelToRest(result)
+		}
+		return c.JSON(http.StatusOK, resultRestList)
+	}
+}
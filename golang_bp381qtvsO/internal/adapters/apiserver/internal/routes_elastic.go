@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"example_consumer/internal/adapters/apiserver/internal/elastic"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/core/usecase"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const elasticProductHeader = "X-Elastic-Product"
+
+// GetElasticInfo answers the cluster info request shippers send before they start
+func GetElasticInfo(version string) func(echo.Context) error {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(elasticProductHeader, "Elasticsearch")
+		return c.JSON(http.StatusOK, ElasticInfoRest{
+			Name:        "logservice",
+			ClusterName: "logservice",
+			Version:     ElasticVersionRest{Number: version},
+			Tagline:     "You Know, for Search",
+		})
+	}
+}
+
+// BulkIndexLogs implements Elasticsearch bulk API, documents of index and create actions are ingested.
+// Log records are immutable, update and delete actions fail with status 400.
+func BulkIndexLogs(uc *usecase.UseCases, topic string) func(echo.Context) error {
+	return func(c echo.Context) error {
+		start := time.Now()
+		c.Response().Header().Set(elasticProductHeader, "Elasticsearch")
+		resp := &ElasticBulkRest{}
+		var records []*model.LogRecord
+		body := elastic.LimitBody(c.Request().Body)
+		if c.Request().Header.Get(echo.HeaderContentEncoding) == "gzip" {
+			// Beats compress bulk requests by default
+			zr, err := gzip.NewReader(body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+			}
+			defer zr.Close()
+			body = elastic.LimitBody(zr)
+		}
+		err := elastic.ParseBulk(body, c.Param("index"), func(item *elastic.BulkItem) {
+			result := &ElasticBulkItemRest{Index: item.Index, ID: item.ID}
+			var rec *model.LogRecord
+			var err error
+			switch {
+			case item.Err != nil:
+				err = item.Err
+			case item.Action == "update" || item.Action == "delete":
+				err = fmt.Errorf("action [%s] is not supported, log records can not be changed", item.Action)
+			default:
+				rec, err = elasticDocumentRecord(topic, item)
+			}
+			if err != nil {
+				resp.Errors = true
+				result.Status = http.StatusBadRequest
+				result.Error = &ElasticErrorCauseRest{Type: "mapper_parsing_exception", Reason: err.Error()}
+			} else {
+				records = append(records, rec)
+				result.ID = rec.ID
+				result.Status = http.StatusCreated
+				result.Result = "created"
+				result.Version = 1
+			}
+			resp.Items = append(resp.Items, map[string]*ElasticBulkItemRest{item.Action: result})
+		})
+		if errors.Is(err, elastic.ErrBodyTooLarge) {
+			return c.JSON(http.StatusRequestEntityTooLarge, ElasticErrorRest{
+				Error:  ElasticErrorCauseRest{Type: "content_too_long_exception", Reason: err.Error()},
+				Status: http.StatusRequestEntityTooLarge,
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ElasticErrorRest{
+				Error:  ElasticErrorCauseRest{Type: "illegal_argument_exception", Reason: err.Error()},
+				Status: http.StatusBadRequest,
+			})
+		}
+		if err = uc.IngestLogs(c.Request().Context(), records...); err != nil {
+			if _, ok := quotaExceeded(c, err); ok {
+				// shippers retry bulk requests rejected with this error later
+				return c.JSON(http.StatusTooManyRequests, ElasticErrorRest{
+					Error:  ElasticErrorCauseRest{Type: "es_rejected_execution_exception", Reason: err.Error()},
+					Status: http.StatusTooManyRequests,
+				})
+			}
+			return ingestError(c, err)
+		}
+		resp.Took = time.Since(start).Milliseconds()
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// elasticDocumentRecord maps document onto log record. Besides the usual field names ECS fields log.level and
+// service.name and the log field Fluent Bit puts lines into are recognized. Source defaults to the index.
+func elasticDocumentRecord(topic string, item *elastic.BulkItem) (*model.LogRecord, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(item.Source, &doc); err != nil || doc == nil {
+		return nil, errors.New("document must be a JSON object")
+	}
+	switch l := doc["log"].(type) {
+	case map[string]any:
+		if level, ok := l["level"]; ok && doc["level"] == nil {
+			doc["level"] = level
+			delete(l, "level")
+			if len(l) == 0 {
+				delete(doc, "log")
+			}
+		}
+	case string:
+		if doc["message"] == nil {
+			doc["message"] = l
+			delete(doc, "log")
+		}
+	}
+	if service, ok := doc["service"].(map[string]any); ok && doc["source"] == nil {
+		if name, ok := service["name"].(string); ok {
+			doc["source"] = name
+		}
+	}
+	doc["index"] = item.Index
+
+	rec := &model.LogRecord{Topic: topic, Level: model.LogLevelInfo}
+	pipeline.ApplyFields(rec, doc)
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+	if rec.Source == "" {
+		rec.Source = item.Index
+	}
+	if pipeline.IsRecordID(item.ID) {
+		rec.ID = item.ID
+	} else {
+		rec.ID = pipeline.NewRecordID()
+	}
+	return rec, nil
+}
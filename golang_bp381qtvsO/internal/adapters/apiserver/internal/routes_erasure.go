@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func GetErasure(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		customerNumber := c.Param("customerNumber")
+		erasure, err := uc.LoadErasure(c.Request().Context(), customerNumber)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		if erasure == nil {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.JSON(http.StatusOK, erasureModelToRest(erasure))
+	}
+}
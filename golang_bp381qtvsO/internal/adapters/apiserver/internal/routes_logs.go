@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"errors"
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+func TestGrokPattern(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(GrokTestRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		if req.Pattern == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(errors.New("pattern is required")))
+		}
+		matches, err := uc.TestGrokPattern(c.Request().Context(), req.Pattern, req.Lines)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		matchRestList := make([]*GrokMatchRest, len(matches))
+		for i, match := range matches {
+			matchRestList[i] = grokMatchModelToRest(match)
+		}
+		return c.JSON(http.StatusOK, matchRestList)
+	}
+}
+
+const defaultPatternsLimit = 20
+
+func ListLogPatterns(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		limit := defaultPatternsLimit
+		if v := c.QueryParam("limit"); v != "" {
+			var err error
+			if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(errors.New("limit must be a positive number")))
+			}
+		}
+		patterns := uc.LoadLogPatterns(c.Request().Context(), c.QueryParam("source"), limit)
+		patternRestList := make([]*LogPatternRest, len(patterns))
+		for i, pattern := range patterns {
+			patternRestList[i] = logPatternModelToRest(pattern)
+		}
+		return c.JSON(http.StatusOK, patternRestList)
+	}
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"example_consumer/internal/adapters/apiserver/internal/loki"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// PushLokiLogs accepts Loki push requests, stream labels and structured metadata become record fields
+// (well-known ones like app or level fill source and level), JSON lines are split into fields as well.
+// Streams without app, service or source label take the source from service_name or job label.
+func PushLokiLogs(uc *usecase.UseCases, topic string) func(echo.Context) error {
+	return func(c echo.Context) error {
+		streams, err := loki.DecodePushRequest(c.Request())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		var records []*model.LogRecord
+		for _, stream := range streams {
+			source := stream.Labels["service_name"]
+			if source == "" {
+				source = stream.Labels["job"]
+			}
+			for _, entry := range stream.Entries {
+				rec := pipeline.DecodeRecord(topic, source, entry.Timestamp, []byte(entry.Line))
+				pipeline.ApplyFields(rec, lokiFields(stream.Labels, entry.Metadata))
+				records = append(records, rec)
+			}
+		}
+		if err = uc.IngestLogs(c.Request().Context(), records...); err != nil {
+			return ingestError(c, err)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func lokiFields(labels map[string]string, metadata map[string]string) map[string]any {
+	fields := make(map[string]any, len(labels)+len(metadata))
+	for k, v := range labels {
+		fields[k] = v
+	}
+	for k, v := range metadata {
+		fields[k] = v
+	}
+	return fields
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"example_consumer/internal/core/metrics"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// GetMetrics renders service metrics in Prometheus text format
+func GetMetrics() func(c echo.Context) error {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, prometheusContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		return metrics.Default.WriteText(c.Response())
+	}
+}
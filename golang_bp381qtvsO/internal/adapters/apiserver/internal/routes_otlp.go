@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"encoding/json"
+	"example_consumer/internal/adapters/apiserver/internal/otlp"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/core/usecase"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// ExportOtlpLogs accepts OTLP/HTTP log export requests. Resource attribute service.name is the source
+// (topic if missing), severity sets the level and attributes become record fields together with
+// traceId, spanId, scope and the resource attributes.
+func ExportOtlpLogs(uc *usecase.UseCases, topic string) func(echo.Context) error {
+	return func(c echo.Context) error {
+		logs, contentType, err := otlp.DecodeLogsRequest(c.Request())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		records := make([]*model.LogRecord, 0, len(logs))
+		for _, l := range logs {
+			records = append(records, otlpRecord(topic, l))
+		}
+		if err = uc.IngestLogs(c.Request().Context(), records...); err != nil {
+			return ingestError(c, err)
+		}
+		// empty ExportLogsServiceResponse means all records were accepted
+		if contentType == otlp.ContentTypeProtobuf {
+			return c.Blob(http.StatusOK, otlp.ContentTypeProtobuf, nil)
+		}
+		return c.JSON(http.StatusOK, struct{}{})
+	}
+}
+
+func otlpRecord(topic string, l *otlp.LogRecord) *model.LogRecord {
+	source, _ := l.Resource["service.name"].(string)
+	var rec *model.LogRecord
+	switch body := l.Body.(type) {
+	case string:
+		rec = pipeline.DecodeRecord(topic, source, l.Timestamp, []byte(body))
+	case map[string]any:
+		rec = pipeline.DecodeRecord(topic, source, l.Timestamp, nil)
+		pipeline.ApplyFields(rec, body)
+	case nil:
+		rec = pipeline.DecodeRecord(topic, source, l.Timestamp, nil)
+	default:
+		msg, err := json.Marshal(body)
+		if err != nil {
+			msg = []byte(fmt.Sprint(body))
+		}
+		rec = pipeline.DecodeRecord(topic, source, l.Timestamp, msg)
+	}
+	pipeline.ApplyFields(rec, l.Attributes)
+	if l.SeverityNumber > 0 {
+		rec.Level = otlpSeverityLevel(l.SeverityNumber)
+	} else if l.SeverityText != "" {
+		rec.Level = model.ParseLogLevel(l.SeverityText)
+	}
+	if source != "" {
+		rec.Source = source
+	}
+	if rec.Fields == nil {
+		rec.Fields = make(map[string]any)
+	}
+	if l.TraceID != "" {
+		rec.Fields["traceId"] = l.TraceID
+	}
+	if l.SpanID != "" {
+		rec.Fields["spanId"] = l.SpanID
+	}
+	if l.Scope != "" {
+		rec.Fields["scope"] = l.Scope
+	}
+	if len(l.Resource) > 0 {
+		// records of a resource share its attributes, later stages must be able to change them per record
+		resource := make(map[string]any, len(l.Resource))
+		for k, v := range l.Resource {
+			resource[k] = v
+		}
+		rec.Fields["resource"] = resource
+	}
+	return rec
+}
+
+// otlpSeverityLevel maps severity number ranges TRACE 1-4, DEBUG 5-8, INFO 9-12, WARN 13-16, ERROR 17-20
+// and FATAL 21-24 onto levels
+func otlpSeverityLevel(n int32) model.LogLevel {
+	switch {
+	case n <= 8:
+		return model.LogLevelDebug
+	case n <= 12:
+		return model.LogLevelInfo
+	case n <= 16:
+		return model.LogLevelWarn
+	case n <= 20:
+		return model.LogLevelError
+	default:
+		return model.LogLevelFatal
+	}
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func ListRoutes(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		routes := uc.LoadRoutes(c.Request().Context())
+		routeRestList := make([]*RouteRest, len(routes))
+		for i, route := range routes {
+			routeRestList[i] = routeModelToRest(route)
+		}
+		return c.JSON(http.StatusOK, routeRestList)
+	}
+}
+
+func ReloadRoutes(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		routes, err := uc.ReloadRoutes(c.Request().Context())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+		}
+		routeRestList := make([]*RouteRest, len(routes))
+		for i, route := range routes {
+			routeRestList[i] = routeModelToRest(route)
+		}
+		return c.JSON(http.StatusOK, routeRestList)
+	}
+}
@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func ListSamplingRules(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		rules := uc.LoadSamplingRules(c.Request().Context())
+		ruleRestList := make([]*SamplingRuleRest, len(rules))
+		for i, rule := range rules {
+			ruleRestList[i] = samplingRuleModelToRest(rule)
+		}
+		return c.JSON(http.StatusOK, ruleRestList)
+	}
+}
+
+func SaveSamplingRule(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(SamplingRuleRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		req.Source = c.Param("source")
+		rule, err := uc.SaveSamplingRule(c.Request().Context(), req.toModel())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		return c.JSON(http.StatusOK, samplingRuleModelToRest(rule))
+	}
+}
+
+func DeleteSamplingRule(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		if !uc.DeleteSamplingRule(c.Request().Context(), c.Param("source")) {
+			return echo.NewHTTPError(http.StatusNotFound, NotFoundErrResponse)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"errors"
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+func SearchLogs(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(LogQueryRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		q, tr, err := req.toModel()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		q.From, q.To = tr.Resolve(time.Now().UTC())
+		records, err := uc.SearchLogs(c.Request().Context(), &q)
+		if err != nil {
+			return logQueryError(err)
+		}
+		recordRestList := make([]*LogRecordRest, len(records))
+		for i, rec := range records {
+			recordRestList[i] = logRecordModelToRest(rec)
+		}
+		return c.JSON(http.StatusOK, recordRestList)
+	}
+}
+
+func AggregateLogs(uc *usecase.UseCases) func(echo.Context) error {
+	return func(c echo.Context) error {
+		req := new(LogAggregationRest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		agg, err := req.toModel(time.Now().UTC())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+		}
+		buckets, err := uc.AggregateLogs(c.Request().Context(), agg)
+		if err != nil {
+			return logQueryError(err)
+		}
+		bucketRestList := make([]*LogBucketRest, len(buckets))
+		for i, bucket := range buckets {
+			bucketRestList[i] = logBucketModelToRest(bucket)
+		}
+		return c.JSON(http.StatusOK, bucketRestList)
+	}
+}
+
+func logQueryError(err error) error {
+	if errors.Is(err, usecase.ErrUnknownLogStore) {
+		return echo.NewHTTPError(http.StatusBadRequest, NewBadRequestErrResponse(err))
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+}
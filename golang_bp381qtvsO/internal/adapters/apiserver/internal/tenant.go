@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/usecase"
+	"github.com/labstack/echo/v4"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// TenantMiddleware adds tenant owning API key of request to its context, requests without known key are rejected
+func TenantMiddleware(uc *usecase.UseCases, header string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tenant, ok := uc.TenantByAPIKey(c.Request().Header.Get(header))
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, UnauthorizedErrResponse)
+			}
+			req := c.Request()
+			c.SetRequest(req.WithContext(app.ContextWithTenant(req.Context(), tenant)))
+			return next(c)
+		}
+	}
+}
+
+// AdminMiddleware rejects requests without admin API key, they may access data and settings of all tenants
+func AdminMiddleware(uc *usecase.UseCases, header string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !uc.IsAdminAPIKey(c.Request().Header.Get(header)) {
+				return echo.NewHTTPError(http.StatusUnauthorized, UnauthorizedErrResponse)
+			}
+			return next(c)
+		}
+	}
+}
+
+// quotaExceeded returns quota error of ingestion and sets Retry-After header of response
+func quotaExceeded(c echo.Context, err error) (*usecase.QuotaExceededError, bool) {
+	var quotaErr *usecase.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return nil, false
+	}
+	retryAfter := int(math.Ceil(quotaErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return quotaErr, true
+}
+
+// ingestError maps error of ingestion onto response, exceeded quota is reported as 429 with Retry-After
+func ingestError(c echo.Context, err error) error {
+	if _, ok := quotaExceeded(c, err); ok {
+		return echo.NewHTTPError(http.StatusTooManyRequests, NewTooManyRequestsErrResponse(err))
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, NewInternalServerErrResponse(err))
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+var vCardPhoneTypes = map[string]string{
+	"mobile": "cell",
+	"home":   "home",
+	"work":   "work",
+}
+
+// writeVCard writes contact as vCard 4.0 (RFC 6350)
+func writeVCard(w io.Writer, c *ContactRest) error {
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+		fmt.Sprintf("UID:urn:contact:%s", vCardEscaper.Replace(c.ID)),
+		fmt.Sprintf("N:%s;%s;;;", vCardEscaper.Replace(c.LastName), vCardEscaper.Replace(c.FirstName)),
+		fmt.Sprintf("FN:%s", vCardEscaper.Replace(strings.TrimSpace(c.FirstName+" "+c.LastName))),
+	}
+	for _, phone := range c.Phones {
+		lines = append(lines, fmt.Sprintf("TEL;TYPE=%s:%s", vCardPhoneTypes[phone.PhoneType], vCardEscaper.Replace(phone.PhoneNumber)))
+	}
+	if c.CustomerNumber != "" {
+		lines = append(lines, fmt.Sprintf("X-CUSTOMER-NUMBER:%s", vCardEscaper.Replace(c.CustomerNumber)))
+	}
+	lines = append(lines, "END:VCARD")
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
@@ -22,6 +22,7 @@ func NewInMemCache() outport.Cache {
 type inMemCacheChunk struct {
 	partition *outport.CachePartition
 	cache     *internal.TinyLFU
+	counters  *internal.Counters
 }
 
 func (adp *inMemCacheAdapter) Close() {
@@ -37,6 +38,7 @@ func (adp *inMemCacheAdapter) Register(partition *outport.CachePartition) {
 	adp.chunks[ns] = inMemCacheChunk{
 		partition: partition,
 		cache:     c,
+		counters:  internal.NewCounters(partition.LocalMaxItems, partition.Ttl),
 	}
 }
 
@@ -70,6 +72,12 @@ func (adp *inMemCacheAdapter) Get(_ context.Context, key outport.CacheKey, value
 	return false
 }
 
+func (adp *inMemCacheAdapter) Incr(_ context.Context, key outport.CacheKey, delta int64) (int64, bool) {
+	zap.S().Debugf("Increment counter in in-mem cache by cacheKey=%s", key)
+	chunk := adp.mustGetCacheChunk(key.Namespace)
+	return chunk.counters.Incr(key.EncodedKey, delta), true
+}
+
 func (adp *inMemCacheAdapter) Del(_ context.Context, key outport.CacheKey) {
 	zap.S().Debugf("Delete item in in-mem cache by cacheKey=%s", key)
 	chunk := adp.mustGetCacheChunk(key.Namespace)
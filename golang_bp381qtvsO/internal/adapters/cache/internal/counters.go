@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+type counter struct {
+	value   int64
+	expires time.Time
+}
+
+// Counters keeps integer counters which expire ttl after they were created,
+// they are kept apart from TinyLFU so that counters are never evicted before they expire
+type Counters struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxItems int
+	items    map[string]*counter
+}
+
+func NewCounters(maxItems int, ttl time.Duration) *Counters {
+	return &Counters{
+		ttl:      ttl,
+		maxItems: maxItems,
+		items:    make(map[string]*counter),
+	}
+}
+
+// Incr adds delta to counter of key and returns its new value
+func (c *Counters) Incr(key string, delta int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	item, ok := c.items[key]
+	if !ok || (c.ttl > 0 && now.After(item.expires)) {
+		if !ok && c.maxItems > 0 && len(c.items) >= c.maxItems {
+			c.purgeExpired(now)
+		}
+		item = &counter{expires: now.Add(c.ttl)}
+		c.items[key] = item
+	}
+	item.value += delta
+	return item.value
+}
+
+func (c *Counters) purgeExpired(now time.Time) {
+	for key, item := range c.items {
+		if now.After(item.expires) {
+			delete(c.items, key)
+		}
+	}
+}
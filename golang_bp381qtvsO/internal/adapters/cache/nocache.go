@@ -33,6 +33,11 @@ func (adp *noCacheAdapter) Del(_ context.Context, key outport.CacheKey) {
 	adp.mustHaveCacheChunk(key.Namespace)
 }
 
+func (adp *noCacheAdapter) Incr(_ context.Context, key outport.CacheKey, _ int64) (int64, bool) {
+	adp.mustHaveCacheChunk(key.Namespace)
+	return 0, false
+}
+
 func (adp *noCacheAdapter) Register(partition *outport.CachePartition) {
 	ns := partition.Namespace
 	if _, ok := adp.chunks[ns]; ok {
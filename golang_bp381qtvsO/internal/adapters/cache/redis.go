@@ -122,6 +122,23 @@ func (adp *redisCacheAdapter) Get(ctx context.Context, key outport.CacheKey, val
 	return true
 }
 
+func (adp *redisCacheAdapter) Incr(ctx context.Context, key outport.CacheKey, delta int64) (int64, bool) {
+	app.Logger(ctx).Debugf("Increment counter in redis by key=%s", key)
+	chunk := adp.mustGetCacheChunk(key.Namespace)
+	value, err := adp.client.IncrBy(ctx, key.EncodedKey, delta).Result()
+	if err != nil {
+		app.Logger(ctx).Errorf("Increment counter in redis by key=%s failed with error: %v", key, err)
+		return 0, false
+	}
+	if value == delta && chunk.partition.Ttl > 0 {
+		// counter was created by this call
+		if err = adp.client.Expire(ctx, key.EncodedKey, chunk.partition.Ttl).Err(); err != nil {
+			app.Logger(ctx).Errorf("Set expiration of counter in redis by key=%s failed with error: %v", key, err)
+		}
+	}
+	return value, true
+}
+
 func (adp *redisCacheAdapter) Del(ctx context.Context, key outport.CacheKey) {
 	encodedKey := key.EncodedKey
 	app.Logger(ctx).Debugf("Delete item in redis by key=%s", key)
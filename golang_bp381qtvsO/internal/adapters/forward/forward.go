@@ -0,0 +1,470 @@
+// Package forward implements a TCP listener for the Fluentd Forward protocol v1 as spoken by the
+// forward outputs of Fluentd and Fluent Bit. Shared key authentication (handshake) is not supported.
+package forward
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/core/usecase"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+const (
+	// eventTimeExt is the msgpack extension type of EventTime, seconds and nanoseconds as big endian uint32
+	eventTimeExt = 0
+
+	maxMessageBytes      = 8 << 20
+	maxDecompressedBytes = 64 << 20
+	// readTimeout bounds the time a message may take to arrive, idle connections are closed after it
+	readTimeout = time.Minute
+)
+
+// Server accepts forward connections and passes the events to the log pipeline
+type Server struct {
+	listener net.Listener
+	topic    string
+	tenant   string
+	uc       *usecase.UseCases
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// Listen opens the TCP listener, records are routed by topic and belong to tenant unless it is empty
+func Listen(addr string, topic string, tenant string, uc *usecase.UseCases) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error listening for forward connections on %s: %w", addr, err)
+	}
+	return &Server{
+		listener: l,
+		topic:    topic,
+		tenant:   tenant,
+		uc:       uc,
+		conns:    make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// Run accepts connections until Close is called
+func (s *Server) Run(ctx context.Context) {
+	app.Logger(ctx).Infof("Accepting forward connections on %s", s.listener.Addr())
+	if s.tenant != "" {
+		ctx = app.ContextWithTenant(ctx, s.tenant)
+	}
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				app.Logger(ctx).Errorf("Failed to accept forward connection: %v", err)
+			}
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serve(ctx, conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops accepting and closes open connections, events of a message that was not read completely
+// are not acknowledged, so clients send them again
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	dec := newDecoder(conn, maxMessageBytes)
+	enc := msgpack.NewEncoder(conn)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return
+		}
+		dec.limit(maxMessageBytes)
+		records, chunk, err := s.decodeMessage(dec)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				app.Logger(ctx).Warnf("Closing forward connection from %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		// closing without ack makes the client send the chunk again later
+		if err = s.uc.IngestLogs(ctx, records...); err != nil {
+			app.Logger(ctx).Warnf("Closing forward connection from %s, %d records were not ingested: %v",
+				conn.RemoteAddr(), len(records), err)
+			return
+		}
+		if chunk != "" {
+			if err = enc.Encode(map[string]string{"ack": chunk}); err != nil {
+				app.Logger(ctx).Warnf("Failed to acknowledge chunk to %s: %v", conn.RemoteAddr(), err)
+				return
+			}
+		}
+	}
+}
+
+// decodeMessage reads one message in any of the modes and returns its records and the chunk id to acknowledge
+//
+//	Message                 [tag, time, record, option?]
+//	Forward                 [tag, [[time, record], ...], option?]
+//	PackedForward           [tag, <concatenated [time, record] entries>, option?]
+//	CompressedPackedForward [tag, <gzip of entries>, {"compressed": "gzip"}]
+func (s *Server) decodeMessage(dec *decoder) ([]*model.LogRecord, string, error) {
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, "", err
+	}
+	if n < 2 || n > 4 {
+		return nil, "", fmt.Errorf("invalid message with %d elements", n)
+	}
+	tag, err := dec.DecodeString()
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid tag: %w", err)
+	}
+	c, err := dec.PeekCode()
+	if err != nil {
+		return nil, "", err
+	}
+	var records []*model.LogRecord
+	var packed []byte
+	var rest int // elements left for option
+	switch {
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		entries, err := dec.DecodeArrayLen()
+		if err != nil {
+			return nil, "", err
+		}
+		if err = dec.check(entries); err != nil {
+			return nil, "", err
+		}
+		for i := 0; i < entries; i++ {
+			rec, err := s.decodeEntry(dec, tag)
+			if err != nil {
+				return nil, "", err
+			}
+			records = append(records, rec)
+		}
+		rest = n - 2
+	case msgpcode.IsString(c) || msgpcode.IsBin(c):
+		if packed, err = dec.decodeBytes(); err != nil {
+			return nil, "", fmt.Errorf("invalid packed entries: %w", err)
+		}
+		rest = n - 2
+	default:
+		if n < 3 {
+			return nil, "", errors.New("message mode needs time and record")
+		}
+		ts, err := decodeTime(dec)
+		if err != nil {
+			return nil, "", err
+		}
+		record, err := decodeRecord(dec)
+		if err != nil {
+			return nil, "", err
+		}
+		records = append(records, s.logRecord(tag, ts, record))
+		rest = n - 3
+	}
+	var option struct {
+		Chunk      string `msgpack:"chunk"`
+		Compressed string `msgpack:"compressed"`
+	}
+	if rest > 0 {
+		if err = dec.Decode(&option); err != nil {
+			return nil, "", fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	if packed != nil {
+		if records, err = s.decodePacked(packed, option.Compressed, tag); err != nil {
+			return nil, "", err
+		}
+	}
+	return records, option.Chunk, nil
+}
+
+func (s *Server) decodePacked(packed []byte, compressed string, tag string) ([]*model.LogRecord, error) {
+	var dec *decoder
+	switch compressed {
+	case "", "text":
+		dec = newDecoder(bytes.NewReader(packed), len(packed))
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(packed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid compressed entries: %w", err)
+		}
+		defer zr.Close()
+		dec = newDecoder(zr, maxDecompressedBytes)
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compressed)
+	}
+	var records []*model.LogRecord
+	for {
+		if _, err := dec.PeekCode(); errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		rec, err := s.decodeEntry(dec, tag)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// decodeEntry reads [time, record]
+func (s *Server) decodeEntry(dec *decoder, tag string) (*model.LogRecord, error) {
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry: %w", err)
+	}
+	if n != 2 {
+		return nil, fmt.Errorf("invalid entry with %d elements", n)
+	}
+	ts, err := decodeTime(dec)
+	if err != nil {
+		return nil, err
+	}
+	record, err := decodeRecord(dec)
+	if err != nil {
+		return nil, err
+	}
+	return s.logRecord(tag, ts, record), nil
+}
+
+// decodeTime reads EventTime or unix seconds
+func decodeTime(dec *decoder) (time.Time, error) {
+	c, err := dec.PeekCode()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if msgpcode.IsExt(c) {
+		id, n, err := dec.DecodeExtHeader()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if id != eventTimeExt || n != 8 {
+			return time.Time{}, fmt.Errorf("invalid event time extension %d of %d bytes", id, n)
+		}
+		b := make([]byte, 8)
+		if err = dec.ReadFull(b); err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), int64(binary.BigEndian.Uint32(b[4:]))).UTC(), nil
+	}
+	v, err := dec.decodeValue()
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch t := v.(type) {
+	case int64:
+		return time.Unix(t, 0).UTC(), nil
+	case uint64:
+		return time.Unix(int64(t), 0).UTC(), nil
+	case float64:
+		return time.Unix(0, int64(t*float64(time.Second))).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid event time %v", v)
+}
+
+func decodeRecord(dec *decoder) (map[string]any, error) {
+	v, err := dec.decodeValue()
+	if err != nil {
+		return nil, fmt.Errorf("invalid record: %w", err)
+	}
+	record, ok := v.(map[string]any)
+	if !ok || record == nil {
+		return nil, errors.New("record must be a map")
+	}
+	return record, nil
+}
+
+// decoder reads msgpack values of at most limit bytes. Lengths of maps, arrays, strings and binary values
+// are checked against the bytes left before anything is allocated for them.
+type decoder struct {
+	*msgpack.Decoder
+	r *limitedReader
+}
+
+func newDecoder(r io.Reader, limit int) *decoder {
+	lr := &limitedReader{r: bufio.NewReader(r), n: limit}
+	return &decoder{Decoder: msgpack.NewDecoder(lr), r: lr}
+}
+
+// limit sets the bytes left for the next message
+func (d *decoder) limit(n int) {
+	d.r.n = n
+}
+
+// check fails if n elements or bytes cannot fit into the bytes left, every element takes at least one byte
+func (d *decoder) check(n int) error {
+	if n > d.r.n {
+		return errMessageTooLarge
+	}
+	return nil
+}
+
+// decodeValue reads any value, binary values are converted to strings as Fluentd sends strings as bin when
+// they are not valid UTF-8
+func (d *decoder) decodeValue() (any, error) {
+	c, err := d.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		n, err := d.DecodeMapLen()
+		if err != nil {
+			return nil, err
+		}
+		if err = d.check(2 * n); err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			k, err := d.decodeValue()
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(k)], err = d.decodeValue(); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		n, err := d.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		if err = d.check(n); err != nil {
+			return nil, err
+		}
+		a := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := d.decodeValue()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case msgpcode.IsString(c) || msgpcode.IsBin(c):
+		b, err := d.decodeBytes()
+		return string(b), err
+	case msgpcode.IsExt(c):
+		return nil, errors.New("extension types are not supported in records")
+	default:
+		return d.DecodeInterfaceLoose()
+	}
+}
+
+// decodeBytes reads string or binary value
+func (d *decoder) decodeBytes() ([]byte, error) {
+	n, err := d.DecodeBytesLen()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	if err = d.check(n); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if err = d.ReadFull(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+var errMessageTooLarge = errors.New("message exceeds size limit")
+
+// limitedReader fails reading beyond n bytes. It is a byte scanner, so the msgpack decoder does not buffer
+// bytes of the following message.
+type limitedReader struct {
+	r *bufio.Reader
+	n int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, l.exceeded()
+	}
+	if len(p) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= n
+	return n, err
+}
+
+func (l *limitedReader) ReadByte() (byte, error) {
+	if l.n <= 0 {
+		return 0, l.exceeded()
+	}
+	b, err := l.r.ReadByte()
+	if err == nil {
+		l.n--
+	}
+	return b, err
+}
+
+// exceeded returns io.EOF at the end of input, so that input of exactly n bytes can be read completely
+func (l *limitedReader) exceeded() error {
+	if _, err := l.r.Peek(1); err != nil {
+		return err
+	}
+	return errMessageTooLarge
+}
+
+func (l *limitedReader) UnreadByte() error {
+	err := l.r.UnreadByte()
+	if err == nil {
+		l.n++
+	}
+	return err
+}
+
+// logRecord maps event onto log record. Lines of container logs (log field) are split into fields like
+// kafka messages. The source is taken from the record, kubernetes.container_name or the tag.
+func (s *Server) logRecord(tag string, ts time.Time, record map[string]any) *model.LogRecord {
+	source := tag
+	if k8s, ok := record["kubernetes"].(map[string]any); ok {
+		if name, ok := k8s["container_name"].(string); ok && name != "" {
+			source = name
+		}
+	}
+	var rec *model.LogRecord
+	if line, ok := record["log"].(string); ok && record["message"] == nil && record["msg"] == nil {
+		delete(record, "log")
+		rec = pipeline.DecodeRecord(s.topic, source, ts, []byte(line))
+	} else {
+		rec = pipeline.DecodeRecord(s.topic, source, ts, nil)
+	}
+	record["tag"] = tag
+	pipeline.ApplyFields(rec, record)
+	return rec
+}
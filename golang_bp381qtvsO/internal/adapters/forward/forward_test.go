@@ -0,0 +1,154 @@
+package forward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func pack(t *testing.T, values ...any) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// eventTime encodes EventTime extension
+func eventTime(sec, nsec uint32) msgpack.RawMessage {
+	return msgpack.RawMessage{0xd7, eventTimeExt,
+		byte(sec >> 24), byte(sec >> 16), byte(sec >> 8), byte(sec),
+		byte(nsec >> 24), byte(nsec >> 16), byte(nsec >> 8), byte(nsec)}
+}
+
+func gzipped(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeMessage(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	unix := ts.Unix()
+	entries := pack(t, []any{unix, map[string]any{"message": "first"}}, []any{unix + 1, map[string]any{"message": "second"}})
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantMsgs  []string
+		wantTimes []time.Time
+		wantChunk string
+		wantErr   bool
+	}{
+		{
+			name:      "message",
+			data:      pack(t, []any{"app.web", unix, map[string]any{"message": "hello"}}),
+			wantMsgs:  []string{"hello"},
+			wantTimes: []time.Time{ts},
+		},
+		{
+			name:      "message with event time and chunk",
+			data:      pack(t, []any{"app.web", eventTime(uint32(unix), 500), map[string]any{"message": "hello"}, map[string]any{"chunk": "c1"}}),
+			wantMsgs:  []string{"hello"},
+			wantTimes: []time.Time{ts.Add(500)},
+			wantChunk: "c1",
+		},
+		{
+			name: "forward",
+			data: pack(t, []any{"app.web", []any{
+				[]any{unix, map[string]any{"message": "first"}},
+				[]any{unix + 1, map[string]any{"message": "second"}},
+			}}),
+			wantMsgs:  []string{"first", "second"},
+			wantTimes: []time.Time{ts, ts.Add(time.Second)},
+		},
+		{
+			name:      "packed forward",
+			data:      pack(t, []any{"app.web", entries, map[string]any{"chunk": "c2"}}),
+			wantMsgs:  []string{"first", "second"},
+			wantTimes: []time.Time{ts, ts.Add(time.Second)},
+			wantChunk: "c2",
+		},
+		{
+			name:      "compressed packed forward",
+			data:      pack(t, []any{"app.web", gzipped(t, entries), map[string]any{"compressed": "gzip"}}),
+			wantMsgs:  []string{"first", "second"},
+			wantTimes: []time.Time{ts, ts.Add(time.Second)},
+		},
+		{name: "too few elements", data: pack(t, []any{"app.web"}), wantErr: true},
+		{name: "message without record", data: pack(t, []any{"app.web", unix}), wantErr: true},
+		{name: "record is not a map", data: pack(t, []any{"app.web", unix, "hello"}), wantErr: true},
+		{name: "unknown compression", data: pack(t, []any{"app.web", entries, map[string]any{"compressed": "zstd"}}), wantErr: true},
+		{name: "invalid entry", data: pack(t, []any{"app.web", []any{[]any{unix}}}), wantErr: true},
+	}
+	s := &Server{topic: "forward"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, chunk, err := s.decodeMessage(newDecoder(bytes.NewReader(tt.data), maxMessageBytes))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if chunk != tt.wantChunk {
+				t.Errorf("chunk = %q, want %q", chunk, tt.wantChunk)
+			}
+			if len(records) != len(tt.wantMsgs) {
+				t.Fatalf("got %d records, want %d", len(records), len(tt.wantMsgs))
+			}
+			for i, rec := range records {
+				if rec.Message != tt.wantMsgs[i] || !rec.Timestamp.Equal(tt.wantTimes[i]) {
+					t.Errorf("record %d = %q at %v, want %q at %v", i, rec.Message, rec.Timestamp, tt.wantMsgs[i], tt.wantTimes[i])
+				}
+				if rec.Topic != "forward" || rec.Source != "app.web" || rec.Fields["tag"] != "app.web" {
+					t.Errorf("record %d has topic %s, source %s and fields %v", i, rec.Topic, rec.Source, rec.Fields)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeMessageLimit(t *testing.T) {
+	msg := pack(t, []any{"app.web", int64(0), map[string]any{"message": "hello"}})
+	// array of three elements, tag and time, followed by the record header
+	prefix := append([]byte{0x93}, pack(t, "app.web", int64(0))...)
+	prefix = prefix[:len(prefix):len(prefix)]
+	tests := []struct {
+		name    string
+		data    []byte
+		limit   int
+		wantErr error
+	}{
+		{name: "exactly at limit", data: msg, limit: len(msg)},
+		{name: "beyond limit", data: msg, limit: len(msg) - 1, wantErr: errMessageTooLarge},
+		// map32 header claiming 2^31 entries
+		{name: "huge map header", data: append(prefix, 0xdf, 0x80, 0, 0, 0), limit: 1 << 10, wantErr: errMessageTooLarge},
+		// str32 header claiming 2^31 bytes
+		{name: "huge string header", data: append(prefix, 0x81, 0xdb, 0x80, 0, 0, 0), limit: 1 << 10, wantErr: errMessageTooLarge},
+	}
+	s := &Server{topic: "forward"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.decodeMessage(newDecoder(bytes.NewReader(tt.data), tt.limit))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeMessage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+// Package gelf implements UDP and TCP listeners for the Graylog Extended Log Format as sent by
+// the Docker gelf logging driver and Graylog clients.
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/core/usecase"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxDatagramBytes = 64 << 10
+	maxMessageBytes  = 8 << 20
+	// maxChunks is the limit of chunks per message set by the GELF specification
+	maxChunks = 128
+	// maxPendingMessages and maxPendingBytes bound memory used by chunked messages that are not complete yet
+	maxPendingMessages = 1000
+	maxPendingBytes    = 32 << 20
+	// readTimeout bounds the time a TCP message may take to arrive, idle connections are closed after it
+	readTimeout = time.Minute
+
+	defaultChunkTimeout = 5 * time.Second
+)
+
+var chunkMagic = []byte{0x1e, 0x0f}
+
+// Server receives GELF messages over UDP (optionally chunked and gzip or zlib compressed) and
+// null-delimited TCP and passes them to the log pipeline
+type Server struct {
+	udp          net.PacketConn
+	tcp          net.Listener
+	topic        string
+	tenant       string
+	chunkTimeout time.Duration
+	uc           *usecase.UseCases
+
+	mu           sync.Mutex
+	pending      map[string]*chunkedMessage
+	pendingBytes int
+	conns        map[net.Conn]struct{}
+	wg           sync.WaitGroup
+	stop         chan struct{}
+}
+
+type chunkedMessage struct {
+	chunks   [][]byte
+	received int
+	bytes    int
+	first    time.Time
+}
+
+// Listen opens UDP and TCP listeners on addr, records are routed by topic and belong to tenant unless it is
+// empty. Chunks of a message that is not complete within chunkTimeout are dropped.
+func Listen(
+	addr string,
+	topic string,
+	tenant string,
+	chunkTimeout time.Duration,
+	uc *usecase.UseCases,
+) (*Server, error) {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error listening for GELF datagrams on %s: %w", addr, err)
+	}
+	tcp, err := net.Listen("tcp", addr)
+	if err != nil {
+		_ = udp.Close()
+		return nil, fmt.Errorf("error listening for GELF connections on %s: %w", addr, err)
+	}
+	if chunkTimeout <= 0 {
+		chunkTimeout = defaultChunkTimeout
+	}
+	return &Server{
+		udp:          udp,
+		tcp:          tcp,
+		topic:        topic,
+		tenant:       tenant,
+		chunkTimeout: chunkTimeout,
+		uc:           uc,
+		pending:      make(map[string]*chunkedMessage),
+		conns:        make(map[net.Conn]struct{}),
+		stop:         make(chan struct{}),
+	}, nil
+}
+
+// Run receives messages until Close is called
+func (s *Server) Run(ctx context.Context) {
+	app.Logger(ctx).Infof("Accepting GELF messages on udp and tcp %s", s.tcp.Addr())
+	if s.tenant != "" {
+		ctx = app.ContextWithTenant(ctx, s.tenant)
+	}
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.receiveDatagrams(ctx)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.expireLoop(ctx)
+	}()
+	s.acceptConnections(ctx)
+}
+
+// Close stops the listeners and closes open connections, pending chunks are dropped
+func (s *Server) Close() {
+	close(s.stop)
+	_ = s.udp.Close()
+	_ = s.tcp.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) receiveDatagrams(ctx context.Context) {
+	buf := make([]byte, maxDatagramBytes)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				app.Logger(ctx).Errorf("Failed to receive GELF datagram: %v", err)
+			}
+			return
+		}
+		data := buf[:n]
+		if bytes.HasPrefix(data, chunkMagic) {
+			if data = s.addChunk(addr.String(), data); data == nil {
+				continue
+			}
+		} else {
+			data = append([]byte(nil), data...)
+		}
+		s.ingest(ctx, data)
+	}
+}
+
+// addChunk stores chunk and returns the whole message once all its chunks arrived. Messages growing beyond
+// maxMessageBytes are dropped, chunks exceeding maxPendingBytes of all pending messages are ignored.
+//
+//	0x1e 0x0f | message id (8 bytes) | sequence number | sequence count | payload
+func (s *Server) addChunk(sender string, chunk []byte) []byte {
+	if len(chunk) < 12 {
+		return nil
+	}
+	seq, count := int(chunk[10]), int(chunk[11])
+	if count == 0 || count > maxChunks || seq >= count {
+		return nil
+	}
+	// ids are only unique per sender
+	id := sender + "/" + string(chunk[2:10])
+	payload := chunk[12:]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pendingBytes+len(payload) > maxPendingBytes {
+		return nil
+	}
+	m := s.pending[id]
+	if m == nil {
+		if len(s.pending) >= maxPendingMessages {
+			return nil
+		}
+		m = &chunkedMessage{chunks: make([][]byte, count), first: time.Now()}
+		s.pending[id] = m
+	}
+	if len(m.chunks) != count || m.chunks[seq] != nil {
+		return nil
+	}
+	if m.bytes+len(payload) > maxMessageBytes {
+		s.dropPending(id, m)
+		return nil
+	}
+	m.chunks[seq] = append([]byte(nil), payload...)
+	m.received++
+	m.bytes += len(payload)
+	s.pendingBytes += len(payload)
+	if m.received < count {
+		return nil
+	}
+	s.dropPending(id, m)
+	return bytes.Join(m.chunks, nil)
+}
+
+func (s *Server) dropPending(id string, m *chunkedMessage) {
+	delete(s.pending, id)
+	s.pendingBytes -= m.bytes
+}
+
+func (s *Server) expireLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.chunkTimeout / 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for id, m := range s.pending {
+				if now.Sub(m.first) >= s.chunkTimeout {
+					app.Logger(ctx).Debugf("Dropping incomplete GELF message %q with %d of %d chunks",
+						id, m.received, len(m.chunks))
+					s.dropPending(id, m)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Server) acceptConnections(ctx context.Context) {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				app.Logger(ctx).Errorf("Failed to accept GELF connection: %v", err)
+			}
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serve(ctx, conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// serve reads null-delimited uncompressed messages
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return
+		}
+		if !scanner.Scan() {
+			break
+		}
+		if msg := bytes.TrimSpace(scanner.Bytes()); len(msg) > 0 {
+			s.ingest(ctx, append([]byte(nil), msg...))
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		app.Logger(ctx).Warnf("Closing GELF connection from %s: %v", conn.RemoteAddr(), err)
+	}
+}
+
+func (s *Server) ingest(ctx context.Context, data []byte) {
+	data, err := decompress(data)
+	if err != nil {
+		app.Logger(ctx).Warnf("Dropping GELF message: %v", err)
+		return
+	}
+	rec, err := s.decodeMessage(data)
+	if err != nil {
+		app.Logger(ctx).Warnf("Dropping GELF message: %v", err)
+		return
+	}
+	// GELF has no acknowledgements, senders do not learn about dropped messages
+	if err = s.uc.IngestLogs(ctx, rec); err != nil {
+		var quotaErr *usecase.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			// counted per tenant, logging every message would flood the log
+			app.Logger(ctx).Debugf("Dropping GELF message: %v", err)
+			return
+		}
+		app.Logger(ctx).Warnf("Dropping GELF message: %v", err)
+	}
+}
+
+// decompress detects gzip and zlib by their magic bytes, other data is taken as uncompressed
+func decompress(data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case len(data) > 2 && data[0] == 0x78 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed message: %w", err)
+	}
+	defer r.Close()
+	data, err = io.ReadAll(io.LimitReader(r, maxMessageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed message: %w", err)
+	}
+	if len(data) > maxMessageBytes {
+		return nil, fmt.Errorf("message exceeds %d bytes", maxMessageBytes)
+	}
+	return data, nil
+}
+
+// decodeMessage maps GELF payload onto log record. short_message is the message (JSON lines are split
+// into fields), level the syslog severity and host or Docker's _container_name the source.
+// Additional fields lose their _ prefix and become record fields like full_message and host.
+func (s *Server) decodeMessage(data []byte) (*model.LogRecord, error) {
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil || msg == nil {
+		return nil, errors.New("message must be a JSON object")
+	}
+	short, ok := msg["short_message"].(string)
+	if !ok {
+		return nil, errors.New("message without short_message")
+	}
+	rec := &model.LogRecord{Topic: s.topic, Level: model.LogLevelInfo}
+	fields := make(map[string]any, len(msg))
+	for k, v := range msg {
+		switch k {
+		case "short_message", "version":
+		case "timestamp":
+			if ts, ok := v.(float64); ok {
+				sec, frac := math.Modf(ts)
+				rec.Timestamp = time.Unix(int64(sec), int64(frac*1e9)).Round(time.Microsecond).UTC()
+			}
+		case "level":
+			if level, ok := v.(float64); ok {
+				rec.Level = syslogLevel(int(level))
+			}
+		case "host":
+			rec.Source, _ = v.(string)
+			fields[k] = v
+		default:
+			fields[strings.TrimPrefix(k, "_")] = v
+		}
+	}
+	if name, ok := fields["container_name"].(string); ok && name != "" {
+		rec.Source = name
+	}
+	// fields of the line itself win over fields of the GELF envelope
+	pipeline.ApplyFields(rec, fields)
+	pipeline.ApplyPayload(rec, []byte(short))
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+	if rec.Source == "" {
+		rec.Source = s.topic
+	}
+	return rec, nil
+}
+
+// syslogLevel maps syslog severities emergency 0 to debug 7
+func syslogLevel(severity int) model.LogLevel {
+	switch {
+	case severity <= 2:
+		return model.LogLevelFatal
+	case severity == 3:
+		return model.LogLevelError
+	case severity == 4:
+		return model.LogLevelWarn
+	case severity == 7:
+		return model.LogLevelDebug
+	default:
+		return model.LogLevelInfo
+	}
+}
@@ -0,0 +1,199 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"example_consumer/internal/core/model"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *model.LogRecord
+		wantErr bool
+	}{
+		{
+			name: "plain text",
+			data: `{"version": "1.1", "host": "web-1", "short_message": "disk full", "timestamp": 1714557600.25, "level": 3, "_user_id": 42}`,
+			want: &model.LogRecord{
+				Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 250000000, time.UTC),
+				Topic:     "gelf",
+				Source:    "web-1",
+				Level:     model.LogLevelError,
+				Message:   "disk full",
+				Fields:    map[string]any{"host": "web-1", "user_id": 42.0},
+				Raw:       true,
+			},
+		},
+		{
+			name: "docker container with JSON line",
+			data: `{"host": "node", "short_message": "{\"msg\": \"started\", \"level\": \"warn\", \"port\": 8080}", "timestamp": 1714557600, "_container_name": "billing"}`,
+			want: &model.LogRecord{
+				Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Topic:     "gelf",
+				Source:    "billing",
+				Level:     model.LogLevelWarn,
+				Message:   "started",
+				Fields:    map[string]any{"host": "node", "container_name": "billing", "port": 8080.0},
+			},
+		},
+		{
+			name: "syslog debug without host",
+			data: `{"short_message": "tick", "timestamp": 1714557600, "level": 7}`,
+			want: &model.LogRecord{
+				Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Topic:     "gelf",
+				Source:    "gelf",
+				Level:     model.LogLevelDebug,
+				Message:   "tick",
+				Raw:       true,
+			},
+		},
+		{name: "missing short_message", data: `{"host": "web-1"}`, wantErr: true},
+		{name: "not an object", data: `["short_message"]`, wantErr: true},
+		{name: "not JSON", data: `short_message`, wantErr: true},
+	}
+	s := &Server{topic: "gelf"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.decodeMessage([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeMessage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyslogLevel(t *testing.T) {
+	want := []model.LogLevel{
+		model.LogLevelFatal, model.LogLevelFatal, model.LogLevelFatal, model.LogLevelError,
+		model.LogLevelWarn, model.LogLevelInfo, model.LogLevelInfo, model.LogLevelDebug,
+	}
+	for severity, level := range want {
+		if got := syslogLevel(severity); got != level {
+			t.Errorf("syslogLevel(%d) = %s, want %s", severity, got, level)
+		}
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	payload := []byte(`{"short_message": "hello"}`)
+	var gz, zl bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(payload)
+	_ = gw.Close()
+	zw := zlib.NewWriter(&zl)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+	var bomb bytes.Buffer
+	bw := gzip.NewWriter(&bomb)
+	_, _ = bw.Write(make([]byte, maxMessageBytes+1))
+	_ = bw.Close()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "uncompressed", data: payload, want: payload},
+		{name: "gzip", data: gz.Bytes(), want: payload},
+		{name: "zlib", data: zl.Bytes(), want: payload},
+		{name: "corrupt gzip", data: gz.Bytes()[:12], wantErr: true},
+		{name: "exceeds size limit", data: bomb.Bytes(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompress(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decompress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.want) {
+				t.Errorf("decompress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func chunk(id byte, seq, count byte, payload string) []byte {
+	c := append([]byte{0x1e, 0x0f}, id, 0, 0, 0, 0, 0, 0, 0, seq, count)
+	return append(c, payload...)
+}
+
+func TestAddChunk(t *testing.T) {
+	tests := []struct {
+		name   string
+		sender []string // sender of every chunk, "a" if empty
+		chunks [][]byte
+		want   []byte // result of the last chunk
+	}{
+		{name: "single chunk", chunks: [][]byte{chunk(1, 0, 1, "abc")}, want: []byte("abc")},
+		{name: "in order", chunks: [][]byte{chunk(1, 0, 2, "ab"), chunk(1, 1, 2, "cd")}, want: []byte("abcd")},
+		{name: "out of order", chunks: [][]byte{chunk(1, 2, 3, "ef"), chunk(1, 0, 3, "ab"), chunk(1, 1, 3, "cd")}, want: []byte("abcdef")},
+		{name: "incomplete", chunks: [][]byte{chunk(1, 0, 2, "ab")}, want: nil},
+		{name: "duplicate chunk", chunks: [][]byte{chunk(1, 0, 2, "ab"), chunk(1, 0, 2, "ab")}, want: nil},
+		{name: "count changes", chunks: [][]byte{chunk(1, 0, 2, "ab"), chunk(1, 1, 3, "cd")}, want: nil},
+		{name: "sequence out of range", chunks: [][]byte{chunk(1, 2, 2, "ab")}, want: nil},
+		{name: "too many chunks", chunks: [][]byte{chunk(1, 0, maxChunks+1, "ab")}, want: nil},
+		{name: "header too short", chunks: [][]byte{{0x1e, 0x0f, 1}}, want: nil},
+		{
+			name:   "ids are per sender",
+			sender: []string{"a", "b"},
+			chunks: [][]byte{chunk(1, 0, 2, "ab"), chunk(1, 1, 2, "cd")},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{pending: make(map[string]*chunkedMessage)}
+			var got []byte
+			for i, c := range tt.chunks {
+				sender := "a"
+				if i < len(tt.sender) {
+					sender = tt.sender[i]
+				}
+				got = s.addChunk(sender, c)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("addChunk() = %q, want %q", got, tt.want)
+			}
+			if tt.want != nil && (len(s.pending) != 0 || s.pendingBytes != 0) {
+				t.Errorf("%d messages with %d bytes still pending", len(s.pending), s.pendingBytes)
+			}
+		})
+	}
+}
+
+func TestAddChunkLimits(t *testing.T) {
+	large := strings.Repeat("x", maxMessageBytes/2)
+	s := &Server{pending: make(map[string]*chunkedMessage)}
+	s.addChunk("a", chunk(1, 0, 3, large))
+	s.addChunk("a", chunk(1, 1, 3, large))
+	if got := s.addChunk("a", chunk(1, 2, 3, "x")); got != nil || len(s.pending) != 0 || s.pendingBytes != 0 {
+		t.Errorf("message larger than %d bytes kept: %d pending with %d bytes", maxMessageBytes, len(s.pending), s.pendingBytes)
+	}
+
+	for id := byte(0); s.pendingBytes+len(large) <= maxPendingBytes; id++ {
+		s.addChunk("a", chunk(id, 0, 2, large))
+	}
+	full := len(s.pending)
+	s.addChunk("b", chunk(1, 0, 2, large))
+	if len(s.pending) != full || s.pendingBytes > maxPendingBytes {
+		t.Errorf("chunk beyond %d pending bytes stored: %d pending with %d bytes", maxPendingBytes, len(s.pending), s.pendingBytes)
+	}
+	if got := s.addChunk("a", chunk(0, 1, 2, "")); !bytes.Equal(got, []byte(large)) {
+		t.Errorf("pending message was not completed")
+	}
+	if s.addChunk("b", chunk(1, 0, 2, large)); len(s.pending) != full {
+		t.Errorf("chunk not stored after completed message freed its bytes")
+	}
+}
@@ -0,0 +1,61 @@
+package lookup
+
+import (
+	"context"
+	"encoding/csv"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"os"
+)
+
+type csvSource struct {
+	file      string
+	keyColumn string
+}
+
+// NewCSVSource reads lookup table from CSV file with header row, the file is read again on every load
+func NewCSVSource(file, keyColumn string) outport.LookupSource {
+	return &csvSource{file: file, keyColumn: keyColumn}
+}
+
+func (s *csvSource) LoadLookupTable(_ context.Context) (model.LookupTable, error) {
+	f, err := os.Open(s.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", s.file, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s has no header row", s.file)
+	}
+	header := rows[0]
+	keyIdx := -1
+	for i, column := range header {
+		if column == s.keyColumn {
+			keyIdx = i
+		}
+	}
+	if keyIdx < 0 {
+		return nil, fmt.Errorf("%s has no column %s", s.file, s.keyColumn)
+	}
+	table := make(model.LookupTable, len(rows)-1)
+	for _, row := range rows[1:] {
+		if keyIdx >= len(row) {
+			continue
+		}
+		values := make(map[string]string, len(header))
+		for i, v := range row {
+			if i < len(header) {
+				values[header[i]] = v
+			}
+		}
+		table[row[keyIdx]] = values
+	}
+	return table, nil
+}
@@ -54,6 +54,16 @@ func (a *addrBookAdapter) LoadContactByID(ctx context.Context, ID string) (*mode
 	return m, nil
 }
 
+func (a *addrBookAdapter) LoadContactsByCustomerNumber(ctx context.Context, customerNumber string) ([]*model.Contact, error) {
+	entities, err := a.repo.SelectContactsByCustomerNumber(ctx, customerNumber)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(entities, func(item *repo.ContactWithPhonesEntity, _ int) *model.Contact {
+		return mapper.ContactEntityToModel(item)
+	}), nil
+}
+
 func (a *addrBookAdapter) AddContact(ctx context.Context, c *model.ContactToSave) (*model.Contact, error) {
 	entity := mapper.ContactToSaveModelToEntity(c)
 	entity, err := a.repo.AddContact(ctx, entity)
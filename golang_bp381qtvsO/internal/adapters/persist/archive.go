@@ -0,0 +1,268 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/archive"
+	"example_consumer/internal/adapters/persist/internal/mapper"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultArchiveDir   = "./archive"
+	defaultArchiveDelay = time.Hour
+	restoreBatchSize    = 1000
+)
+
+// archiver exports records of completed days into compressed NDJSON files, one file per day and source,
+// so they are still available after retention deleted them from the collection
+type archiver struct {
+	repo        *repo.LogRepo
+	dir         string
+	compression string
+	delay       time.Duration
+
+	mu sync.Mutex // serializes archiving and erasure rewriting files
+}
+
+func newArchiver(r *repo.LogRepo, cfg *app.MongoSinkConfig) *archiver {
+	ac := cfg.Archive
+	if !ac.Enabled {
+		return nil
+	}
+	a := &archiver{
+		repo:        r,
+		dir:         archiveDir(cfg),
+		compression: ac.Compression,
+		delay:       ac.Delay,
+	}
+	if a.compression == "" {
+		a.compression = archive.CompressionZstd
+	}
+	if a.delay <= 0 {
+		a.delay = defaultArchiveDelay
+	}
+	return a
+}
+
+func archiveDir(cfg *app.MongoSinkConfig) string {
+	dir := cfg.Archive.Dir
+	if dir == "" {
+		dir = defaultArchiveDir
+	}
+	return filepath.Join(dir, cfg.Collection)
+}
+
+// run archives every day that ended at least delay ago and was not archived yet, and records inserted
+// late for days archived before. It returns the end of the last archived day, zero if nothing was
+// archived so far, and the insertion time before which records of those days are archived.
+func (a *archiver) run(ctx context.Context) (archivedUntil time.Time, checkedUntil time.Time, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	manifest, err := archive.LoadManifest(a.dir)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	// records inserted from now on are left to the next run
+	now := time.Now().UTC()
+	day := manifest.ArchivedUntil
+	if day.IsZero() {
+		oldest, err := a.repo.OldestLogTimestamp(ctx)
+		if err != nil || oldest.IsZero() {
+			return time.Time{}, time.Time{}, err
+		}
+		day = oldest.UTC().Truncate(24 * time.Hour)
+	} else {
+		if err = a.archiveLate(ctx, manifest, now); err != nil {
+			return manifest.ArchivedUntil, manifest.CheckedUntil, err
+		}
+	}
+	manifest.CheckedUntil = now
+	if err = manifest.Save(a.dir); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	for {
+		next := day.Add(24 * time.Hour)
+		if time.Since(next) < a.delay {
+			return manifest.ArchivedUntil, manifest.CheckedUntil, nil
+		}
+		filter := bson.M{"timestamp": bson.M{"$gte": day, "$lt": next}, "$or": insertedBefore(now)}
+		if err = a.archive(ctx, manifest, filter, ""); err != nil {
+			return manifest.ArchivedUntil, manifest.CheckedUntil, err
+		}
+		manifest.ArchivedUntil = next
+		if err = manifest.Save(a.dir); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		day = next
+	}
+}
+
+// archiveLate archives records of archived days that were inserted after the previous run, e.g. when the
+// write-ahead log of the sink was replayed after an outage, into additional files of their day
+func (a *archiver) archiveLate(ctx context.Context, manifest *archive.Manifest, now time.Time) error {
+	filter := bson.M{
+		"timestamp": bson.M{"$lt": manifest.ArchivedUntil},
+		"inserted":  bson.M{"$gte": manifest.CheckedUntil, "$lt": now},
+	}
+	return a.archive(ctx, manifest, filter, "late-"+now.Format("20060102150405"))
+}
+
+// archive writes records matching filter into one file per source and day and adds the files to manifest
+func (a *archiver) archive(ctx context.Context, manifest *archive.Manifest, filter bson.M, part string) error {
+	sources, err := a.repo.DistinctSources(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		var w *archive.Writer
+		var day time.Time
+		closeFile := func() error {
+			f, err := w.Close()
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, f)
+			zap.S().Infof("Archived %d log records of source=%s day=%s into %s", f.Records, source, f.Day, f.Path)
+			return nil
+		}
+		sourceFilter := bson.M{"source": source}
+		for k, v := range filter {
+			sourceFilter[k] = v
+		}
+		err = a.repo.StreamLogs(ctx, sourceFilter, func(e *repo.LogRecordEntity) error {
+			if d := e.Timestamp.UTC().Truncate(24 * time.Hour); w == nil || !d.Equal(day) {
+				if w != nil {
+					err := closeFile()
+					w = nil
+					if err != nil {
+						return err
+					}
+				}
+				var err error
+				if w, err = archive.NewWriter(a.dir, d, source, part, a.compression); err != nil {
+					return err
+				}
+				day = d
+			}
+			return w.Write(mapper.LogRecordEntityToArchive(e))
+		})
+		if err != nil {
+			if w != nil {
+				w.Abort()
+			}
+			return err
+		}
+		if w != nil {
+			if err = closeFile(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// insertedBefore matches records inserted before t, records without insertion time are restored from
+// archive or were stored before it was recorded
+func insertedBefore(t time.Time) bson.A {
+	return bson.A{
+		bson.M{"inserted": bson.M{"$lt": t}},
+		bson.M{"inserted": bson.M{"$exists": false}},
+	}
+}
+
+// RestoreArchive re-imports archived records with timestamp in [from, to) into the collection of mongo sink.
+// Records which are still in the collection are skipped, time-series collections do not reject duplicate
+// ids, so existing ids are looked up before every batch is inserted.
+func RestoreArchive(
+	ctx context.Context,
+	p outport.Persistence,
+	cfg *app.MongoSinkConfig,
+	from time.Time,
+	to time.Time,
+) (int, error) {
+	dir := archiveDir(cfg)
+	manifest, err := archive.LoadManifest(dir)
+	if err != nil {
+		return 0, err
+	}
+	r := repo.NewLogRepo(p.DB(), cfg.Collection)
+	restored := 0
+	batch := make([]*repo.LogRecordEntity, 0, restoreBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		missing, err := missingLogs(ctx, r, batch)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			if err = r.InsertLogs(ctx, missing); err != nil {
+				return err
+			}
+		}
+		restored += len(missing)
+		batch = batch[:0]
+		return nil
+	}
+	for _, f := range manifest.Overlapping(from, to) {
+		app.Logger(ctx).Infof("Restoring log records from %s", f.Path)
+		err = archive.Read(dir, f, func(line *archive.Line) error {
+			if line.Timestamp.Before(from) || !line.Timestamp.Before(to) {
+				return nil
+			}
+			batch = append(batch, mapper.ArchiveToLogRecordEntity(line))
+			if len(batch) >= restoreBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return restored, fmt.Errorf("error restoring %s: %w", f.Path, err)
+		}
+	}
+	err = flush()
+	return restored, err
+}
+
+// missingLogs returns records of batch which are not stored yet, records archived without id are always missing
+func missingLogs(ctx context.Context, r *repo.LogRepo, batch []*repo.LogRecordEntity) ([]*repo.LogRecordEntity, error) {
+	ids := make([]primitive.ObjectID, 0, len(batch))
+	var from, to time.Time
+	for _, e := range batch {
+		if e.ID == primitive.NilObjectID {
+			continue
+		}
+		ids = append(ids, e.ID)
+		if from.IsZero() || e.Timestamp.Before(from) {
+			from = e.Timestamp
+		}
+		if e.Timestamp.After(to) {
+			to = e.Timestamp
+		}
+	}
+	if len(ids) == 0 {
+		return batch, nil
+	}
+	existing, err := r.ExistingLogIDs(ctx, ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]*repo.LogRecordEntity, 0, len(batch))
+	for _, e := range batch {
+		if e.ID == primitive.NilObjectID || !existing[e.ID] {
+			missing = append(missing, e)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,132 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/mapper"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+
+	"github.com/samber/lo"
+)
+
+type dashboardAdapter struct {
+	repo *repo.DashboardRepo
+}
+
+// NewDashboardAdapters returns ports of saved searches and dashboards, both are stored by the same adapter
+func NewDashboardAdapters(p outport.Persistence) (outport.SavedSearches, outport.Dashboards) {
+	a := &dashboardAdapter{
+		repo: repo.NewDashboardRepo(p.DB()),
+	}
+	return a, a
+}
+
+func (a *dashboardAdapter) LoadAllSavedSearches(ctx context.Context, tenant string) ([]*model.SavedSearch, error) {
+	all, err := a.repo.SelectAllSavedSearches(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(all, func(item *repo.SavedSearchEntity, _ int) *model.SavedSearch {
+		return mapper.SavedSearchEntityToModel(item)
+	}), nil
+}
+
+func (a *dashboardAdapter) LoadSavedSearchByID(ctx context.Context, tenant string, ID string) (*model.SavedSearch, error) {
+	repoID, err := mapper.ModelIdToRepoId(ID)
+	if err != nil {
+		app.Logger(ctx).Debugln("error parsing id:", ID)
+		return nil, nil
+	}
+	entity, err := a.repo.SelectSavedSearchByID(ctx, tenant, repoID)
+	if err != nil || entity == nil {
+		return nil, err
+	}
+	return mapper.SavedSearchEntityToModel(entity), nil
+}
+
+func (a *dashboardAdapter) AddSavedSearch(ctx context.Context, s *model.SavedSearch) (*model.SavedSearch, error) {
+	entity, err := a.repo.AddSavedSearch(ctx, mapper.SavedSearchModelToEntity(s))
+	if err != nil {
+		return nil, err
+	}
+	return mapper.SavedSearchEntityToModel(entity), nil
+}
+
+func (a *dashboardAdapter) UpdateSavedSearch(ctx context.Context, ID string, s *model.SavedSearch) (*model.SavedSearch, error) {
+	entity := mapper.SavedSearchModelToEntity(s)
+	var err error
+	if entity.ID, err = mapper.ModelIdToRepoId(ID); err != nil {
+		app.Logger(ctx).Debugln("error parsing id:", ID)
+		return nil, nil
+	}
+	found, err := a.repo.UpdateSavedSearch(ctx, entity)
+	if err != nil || !found {
+		return nil, err
+	}
+	return mapper.SavedSearchEntityToModel(entity), nil
+}
+
+func (a *dashboardAdapter) DeleteSavedSearch(ctx context.Context, tenant string, ID string) (found bool, err error) {
+	repoID, err := mapper.ModelIdToRepoId(ID)
+	if err != nil {
+		app.Logger(ctx).Debugln("error parsing id:", ID)
+		return false, nil
+	}
+	return a.repo.DeleteSavedSearch(ctx, tenant, repoID)
+}
+
+func (a *dashboardAdapter) LoadAllDashboards(ctx context.Context, tenant string) ([]*model.Dashboard, error) {
+	all, err := a.repo.SelectAllDashboards(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(all, func(item *repo.DashboardEntity, _ int) *model.Dashboard {
+		return mapper.DashboardEntityToModel(item)
+	}), nil
+}
+
+func (a *dashboardAdapter) LoadDashboardByID(ctx context.Context, tenant string, ID string) (*model.Dashboard, error) {
+	repoID, err := mapper.ModelIdToRepoId(ID)
+	if err != nil {
+		app.Logger(ctx).Debugln("error parsing id:", ID)
+		return nil, nil
+	}
+	entity, err := a.repo.SelectDashboardByID(ctx, tenant, repoID)
+	if err != nil || entity == nil {
+		return nil, err
+	}
+	return mapper.DashboardEntityToModel(entity), nil
+}
+
+func (a *dashboardAdapter) AddDashboard(ctx context.Context, d *model.Dashboard) (*model.Dashboard, error) {
+	entity, err := a.repo.AddDashboard(ctx, mapper.DashboardModelToEntity(d))
+	if err != nil {
+		return nil, err
+	}
+	return mapper.DashboardEntityToModel(entity), nil
+}
+
+func (a *dashboardAdapter) UpdateDashboard(ctx context.Context, ID string, d *model.Dashboard) (*model.Dashboard, error) {
+	entity := mapper.DashboardModelToEntity(d)
+	var err error
+	if entity.ID, err = mapper.ModelIdToRepoId(ID); err != nil {
+		app.Logger(ctx).Debugln("error parsing id:", ID)
+		return nil, nil
+	}
+	found, err := a.repo.UpdateDashboard(ctx, entity)
+	if err != nil || !found {
+		return nil, err
+	}
+	return mapper.DashboardEntityToModel(entity), nil
+}
+
+func (a *dashboardAdapter) DeleteDashboard(ctx context.Context, tenant string, ID string) (found bool, err error) {
+	repoID, err := mapper.ModelIdToRepoId(ID)
+	if err != nil {
+		app.Logger(ctx).Debugln("error parsing id:", ID)
+		return false, nil
+	}
+	return a.repo.DeleteDashboard(ctx, tenant, repoID)
+}
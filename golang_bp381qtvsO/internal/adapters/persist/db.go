@@ -15,7 +15,7 @@ type dbAdapter struct {
 	db     *mongo.Database
 }
 
-// NewPersistence connects to PostgreSQL database and returns Persistence interface that wraps database reference
+// NewPersistence connects to MongoDB database and returns Persistence interface that wraps database reference
 func NewPersistence(cfg *app.Config) outport.Persistence {
 	dbc := cfg.Database
 	var connStr string
@@ -31,11 +31,14 @@ func NewPersistence(cfg *app.Config) outport.Persistence {
 		zap.S().Fatalln("error connecting to mongodb database:", err)
 	}
 
+	// driver keeps reconnecting in background, so an unavailable database must not stop the service:
+	// log sinks buffer records on their own until it is back
 	err = client.Ping(context.TODO(), nil)
 	if err != nil {
-		zap.S().Fatalln("failed to ping mongodb database:", err)
+		zap.S().Warnln("failed to ping mongodb database, continuing without connection:", err)
+	} else {
+		zap.S().Infoln("database initialization was successfully performed")
 	}
-	zap.S().Infoln("database initialization was successfully performed")
 
 	db := client.Database(dbc.Name)
 
@@ -51,6 +54,6 @@ func (d dbAdapter) DB() *mongo.Database {
 
 func (d dbAdapter) Close() {
 	if err := d.client.Disconnect(context.TODO()); err != nil {
-		zap.S().Fatalln("failed to close mongdb connection: ", err)
+		zap.S().Warnln("failed to close mongodb connection:", err)
 	}
 }
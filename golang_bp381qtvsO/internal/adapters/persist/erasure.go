@@ -0,0 +1,31 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/mapper"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+)
+
+type erasureAdapter struct {
+	repo *repo.ErasureRepo
+}
+
+func NewErasureAdapter(p outport.Persistence) outport.Erasures {
+	return &erasureAdapter{
+		repo: repo.NewErasureRepo(p.DB()),
+	}
+}
+
+func (a *erasureAdapter) SaveErasure(ctx context.Context, e *model.Erasure) error {
+	return a.repo.SaveErasure(ctx, mapper.ErasureModelToEntity(e))
+}
+
+func (a *erasureAdapter) LoadErasure(ctx context.Context, tenant string, customerNumber string) (*model.Erasure, error) {
+	entity, err := a.repo.SelectErasure(ctx, tenant, customerNumber)
+	if err != nil || entity == nil {
+		return nil, err
+	}
+	return mapper.ErasureEntityToModel(entity), nil
+}
@@ -0,0 +1,305 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	CompressionZstd = "zstd"
+	CompressionGzip = "gzip"
+
+	manifestFile = "manifest.json"
+	dayLayout    = "2006-01-02"
+)
+
+// Manifest lists archived segment files of one collection
+type Manifest struct {
+	ArchivedUntil time.Time `json:"archivedUntil"` // all days before this moment are archived
+	// records of archived days inserted before this moment are archived, later ones arrived late
+	CheckedUntil time.Time `json:"checkedUntil,omitempty"`
+	Files        []*File   `json:"files"`
+}
+
+// File describes one compressed NDJSON segment with records of one source and day
+type File struct {
+	Path        string    `json:"path"` // relative to archive directory
+	Day         string    `json:"day"`
+	Source      string    `json:"source"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Records     int       `json:"records"`
+	Compression string    `json:"compression"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Line is the representation of archived log record, one JSON document per line
+type Line struct {
+	ID        string         `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Topic     string         `json:"topic,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Source    string         `json:"source"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive manifest: %w", err)
+	}
+	m := &Manifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error parsing archive manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Save writes manifest into temporary file first, so readers never see half-written manifest
+func (m *Manifest) Save(dir string) error {
+	sort.Slice(m.Files, func(i, j int) bool {
+		if m.Files[i].Day != m.Files[j].Day {
+			return m.Files[i].Day < m.Files[j].Day
+		}
+		if m.Files[i].Source != m.Files[j].Source {
+			return m.Files[i].Source < m.Files[j].Source
+		}
+		return m.Files[i].Path < m.Files[j].Path
+	})
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, manifestFile)
+	if err = os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("error writing archive manifest: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Overlapping returns files that may contain records in [from, to)
+func (m *Manifest) Overlapping(from time.Time, to time.Time) []*File {
+	var files []*File
+	for _, f := range m.Files {
+		if f.From.Before(to) && !f.To.Before(from) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// Writer writes one compressed NDJSON segment file
+type Writer struct {
+	file  *File
+	out   *os.File
+	comp  io.WriteCloser
+	buf   *bufio.Writer
+	enc   *json.Encoder
+	empty bool
+}
+
+// NewWriter creates segment file for records of source on day, partitioned as <day>/<source>.ndjson.<ext>.
+// Records arriving after the day was archived go to further files <day>/<source>.<part>.ndjson.<ext>.
+func NewWriter(dir string, day time.Time, source string, part string, compression string) (*Writer, error) {
+	ext, err := extension(compression)
+	if err != nil {
+		return nil, err
+	}
+	dayName := day.UTC().Format(dayLayout)
+	name := sanitize(source)
+	if part != "" {
+		name += "." + part
+	}
+	rel := filepath.Join(dayName, name+".ndjson."+ext)
+	if err = os.MkdirAll(filepath.Join(dir, dayName), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating archive directory: %w", err)
+	}
+	return createWriter(filepath.Join(dir, rel), &File{
+		Path:        rel,
+		Day:         dayName,
+		Source:      source,
+		Compression: compression,
+	})
+}
+
+func createWriter(path string, file *File) (*Writer, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating archive file: %w", err)
+	}
+	var comp io.WriteCloser
+	switch file.Compression {
+	case CompressionZstd:
+		comp, err = zstd.NewWriter(out)
+	default:
+		comp, err = gzip.NewWriterLevel(out, gzip.BestCompression)
+	}
+	if err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	buf := bufio.NewWriter(comp)
+	return &Writer{
+		file:  file,
+		out:   out,
+		comp:  comp,
+		buf:   buf,
+		enc:   json.NewEncoder(buf),
+		empty: true,
+	}, nil
+}
+
+func (w *Writer) Write(line *Line) error {
+	if w.empty || line.Timestamp.Before(w.file.From) {
+		w.file.From = line.Timestamp
+	}
+	if w.empty || line.Timestamp.After(w.file.To) {
+		w.file.To = line.Timestamp
+	}
+	w.empty = false
+	w.file.Records++
+	return w.enc.Encode(line)
+}
+
+// Close flushes compressed data to disk and returns description of written file for the manifest
+func (w *Writer) Close() (*File, error) {
+	err := w.buf.Flush()
+	if cerr := w.comp.Close(); err == nil {
+		err = cerr
+	}
+	if serr := w.out.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := w.out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error writing archive file %s: %w", w.file.Path, err)
+	}
+	w.file.CreatedAt = time.Now().UTC()
+	return w.file, nil
+}
+
+// Abort closes and deletes partially written file
+func (w *Writer) Abort() {
+	_ = w.comp.Close()
+	_ = w.out.Close()
+	_ = os.Remove(w.out.Name())
+}
+
+// Read calls fn for every record of archived file
+func Read(dir string, f *File, fn func(line *Line) error) error {
+	in, err := os.Open(filepath.Join(dir, f.Path))
+	if err != nil {
+		return fmt.Errorf("error opening archive file: %w", err)
+	}
+	defer in.Close()
+	var r io.Reader
+	switch f.Compression {
+	case CompressionZstd:
+		zr, err := zstd.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		gr, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		line := new(Line)
+		if err = dec.Decode(line); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading archive file %s: %w", f.Path, err)
+		}
+		if err = fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+// Rewrite replaces records of file by the records fn returns for them, records for which fn returns nil are
+// removed. The new file is written next to the old one and renamed over it, the returned description
+// replaces the old one in the manifest.
+func Rewrite(dir string, f *File, fn func(line *Line) *Line) (*File, error) {
+	path := filepath.Join(dir, f.Path)
+	w, err := createWriter(path+".tmp", &File{
+		Path:        f.Path,
+		Day:         f.Day,
+		Source:      f.Source,
+		Compression: f.Compression,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = Read(dir, f, func(line *Line) error {
+		if line = fn(line); line != nil {
+			return w.Write(line)
+		}
+		return nil
+	})
+	if err != nil {
+		w.Abort()
+		return nil, err
+	}
+	rewritten, err := w.Close()
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		_ = os.Remove(path + ".tmp")
+		return nil, fmt.Errorf("error rewriting archive file %s: %w", f.Path, err)
+	}
+	return rewritten, nil
+}
+
+func extension(compression string) (string, error) {
+	switch compression {
+	case CompressionZstd:
+		return "zst", nil
+	case CompressionGzip:
+		return "gz", nil
+	default:
+		return "", fmt.Errorf("unknown archive compression: %s", compression)
+	}
+}
+
+// sanitize makes source usable as file name, sources that had to be changed get a hash suffix so
+// they cannot collide with each other
+func sanitize(source string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, source)
+	if safe == source && source != "" {
+		return safe
+	}
+	return fmt.Sprintf("%s_%08x", safe, crc32.ChecksumIEEE([]byte(source)))
+}
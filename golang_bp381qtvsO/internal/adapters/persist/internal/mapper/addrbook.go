@@ -17,17 +17,19 @@ func ContactEntityToModel(e *repo.ContactWithPhonesEntity) *model.Contact {
 		}
 	}
 	return &model.Contact{
-		ID:        RepoIdToModelId(e.ID),
-		FirstName: e.FirstName,
-		LastName:  e.LastName,
-		Phones:    phones,
+		ID:             RepoIdToModelId(e.ID),
+		CustomerNumber: e.CustomerNumber,
+		FirstName:      e.FirstName,
+		LastName:       e.LastName,
+		Phones:         phones,
 	}
 }
 
 func ContactToSaveModelToEntity(m *model.ContactToSave) *repo.ContactWithPhonesEntity {
 	return &repo.ContactWithPhonesEntity{
-		FirstName: m.FirstName,
-		LastName:  m.LastName,
+		CustomerNumber: m.CustomerNumber,
+		FirstName:      m.FirstName,
+		LastName:       m.LastName,
 		Phones: lo.Map(m.Phones, func(item *model.ContactPhoneToSave, _ int) *repo.PhoneEntity {
 			return &repo.PhoneEntity{
 				PhoneType:   phoneTypeModelToEntity(item.PhoneType),
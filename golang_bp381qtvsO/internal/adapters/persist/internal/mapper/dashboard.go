@@ -0,0 +1,107 @@
+package mapper
+
+import (
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+
+	"github.com/samber/lo"
+)
+
+func SavedSearchModelToEntity(m *model.SavedSearch) *repo.SavedSearchEntity {
+	e := &repo.SavedSearchEntity{
+		Tenant:      m.Tenant,
+		Name:        m.Name,
+		Description: m.Description,
+		Query:       logQueryModelToEntity(&m.Query),
+		TimeRange:   repo.TimeRangeEntity(m.TimeRange),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if ID, err := ModelIdToRepoId(m.ID); err == nil {
+		e.ID = ID
+	}
+	return e
+}
+
+func SavedSearchEntityToModel(e *repo.SavedSearchEntity) *model.SavedSearch {
+	return &model.SavedSearch{
+		ID:          RepoIdToModelId(e.ID),
+		Tenant:      e.Tenant,
+		Name:        e.Name,
+		Description: e.Description,
+		Query:       logQueryEntityToModel(&e.Query),
+		TimeRange:   model.TimeRange(e.TimeRange),
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+func DashboardModelToEntity(m *model.Dashboard) *repo.DashboardEntity {
+	e := &repo.DashboardEntity{
+		Tenant:      m.Tenant,
+		Name:        m.Name,
+		Description: m.Description,
+		Panels: lo.Map(m.Panels, func(item *model.DashboardPanel, _ int) *repo.DashboardPanelEntity {
+			return &repo.DashboardPanelEntity{
+				Title:         item.Title,
+				Visualization: string(item.Visualization),
+				Query:         logQueryModelToEntity(&item.Query),
+				TimeRange:     repo.TimeRangeEntity(item.TimeRange),
+				GroupBy:       item.GroupBy,
+				Interval:      item.Interval,
+			}
+		}),
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if ID, err := ModelIdToRepoId(m.ID); err == nil {
+		e.ID = ID
+	}
+	return e
+}
+
+func DashboardEntityToModel(e *repo.DashboardEntity) *model.Dashboard {
+	return &model.Dashboard{
+		ID:          RepoIdToModelId(e.ID),
+		Tenant:      e.Tenant,
+		Name:        e.Name,
+		Description: e.Description,
+		Panels: lo.Map(e.Panels, func(item *repo.DashboardPanelEntity, _ int) *model.DashboardPanel {
+			return &model.DashboardPanel{
+				Title:         item.Title,
+				Visualization: model.PanelVisualization(item.Visualization),
+				Query:         logQueryEntityToModel(&item.Query),
+				TimeRange:     model.TimeRange(item.TimeRange),
+				GroupBy:       item.GroupBy,
+				Interval:      item.Interval,
+			}
+		}),
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+// logQueryModelToEntity keeps everything but the time bounds, they are resolved from the time range
+func logQueryModelToEntity(m *model.LogQuery) repo.LogQueryEntity {
+	return repo.LogQueryEntity{
+		Store:   m.Store,
+		Text:    m.Text,
+		Sources: m.Sources,
+		Topics:  m.Topics,
+		Levels:  lo.Map(m.Levels, func(item model.LogLevel, _ int) string { return string(item) }),
+		Fields:  m.Fields,
+		Limit:   m.Limit,
+	}
+}
+
+func logQueryEntityToModel(e *repo.LogQueryEntity) model.LogQuery {
+	return model.LogQuery{
+		Store:   e.Store,
+		Text:    e.Text,
+		Sources: e.Sources,
+		Topics:  e.Topics,
+		Levels:  lo.Map(e.Levels, func(item string, _ int) model.LogLevel { return model.LogLevel(item) }),
+		Fields:  e.Fields,
+		Limit:   e.Limit,
+	}
+}
@@ -0,0 +1,50 @@
+package mapper
+
+import (
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+
+	"github.com/samber/lo"
+)
+
+func ErasureModelToEntity(m *model.Erasure) *repo.ErasureEntity {
+	return &repo.ErasureEntity{
+		CustomerNumber:  m.CustomerNumber,
+		Tenant:          m.Tenant,
+		Status:          string(m.Status),
+		Action:          string(m.Action),
+		RequestedAt:     m.RequestedAt,
+		CompletedAt:     m.CompletedAt,
+		ContactsDeleted: m.ContactsDeleted,
+		Logs: lo.Map(m.Logs, func(item *model.ErasureLogResult, _ int) *repo.ErasureLogResultEntity {
+			return &repo.ErasureLogResultEntity{
+				Store:   item.Store,
+				Matched: item.Matched,
+				Erased:  item.Erased,
+			}
+		}),
+		NotCovered: m.NotCovered,
+		Error:      m.Error,
+	}
+}
+
+func ErasureEntityToModel(e *repo.ErasureEntity) *model.Erasure {
+	return &model.Erasure{
+		CustomerNumber:  e.CustomerNumber,
+		Tenant:          e.Tenant,
+		Status:          model.ErasureStatus(e.Status),
+		Action:          model.ErasureAction(e.Action),
+		RequestedAt:     e.RequestedAt,
+		CompletedAt:     e.CompletedAt,
+		ContactsDeleted: e.ContactsDeleted,
+		Logs: lo.Map(e.Logs, func(item *repo.ErasureLogResultEntity, _ int) *model.ErasureLogResult {
+			return &model.ErasureLogResult{
+				Store:   item.Store,
+				Matched: item.Matched,
+				Erased:  item.Erased,
+			}
+		}),
+		NotCovered: e.NotCovered,
+		Error:      e.Error,
+	}
+}
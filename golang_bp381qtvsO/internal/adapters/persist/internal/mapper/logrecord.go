@@ -0,0 +1,66 @@
+package mapper
+
+import (
+	"example_consumer/internal/adapters/persist/internal/archive"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func LogRecordModelToEntity(m *model.LogRecord) *repo.LogRecordEntity {
+	e := &repo.LogRecordEntity{
+		Timestamp: m.Timestamp,
+		Topic:     m.Topic,
+		Tenant:    m.Tenant,
+		Source:    m.Source,
+		Level:     string(m.Level),
+		Message:   m.Message,
+		Fields:    m.Fields,
+	}
+	if ID, err := primitive.ObjectIDFromHex(m.ID); err == nil {
+		e.ID = ID
+	}
+	return e
+}
+
+func LogRecordEntityToModel(e *repo.LogRecordEntity) *model.LogRecord {
+	return &model.LogRecord{
+		ID:        RepoIdToModelId(e.ID),
+		Timestamp: e.Timestamp,
+		Topic:     e.Topic,
+		Tenant:    e.Tenant,
+		Source:    e.Source,
+		Level:     model.LogLevel(e.Level),
+		Message:   e.Message,
+		Fields:    e.Fields,
+	}
+}
+
+func LogRecordEntityToArchive(e *repo.LogRecordEntity) *archive.Line {
+	return &archive.Line{
+		ID:        RepoIdToModelId(e.ID),
+		Timestamp: e.Timestamp,
+		Topic:     e.Topic,
+		Tenant:    e.Tenant,
+		Source:    e.Source,
+		Level:     e.Level,
+		Message:   e.Message,
+		Fields:    e.Fields,
+	}
+}
+
+func ArchiveToLogRecordEntity(l *archive.Line) *repo.LogRecordEntity {
+	e := &repo.LogRecordEntity{
+		Timestamp: l.Timestamp,
+		Topic:     l.Topic,
+		Tenant:    l.Tenant,
+		Source:    l.Source,
+		Level:     l.Level,
+		Message:   l.Message,
+		Fields:    l.Fields,
+	}
+	if ID, err := primitive.ObjectIDFromHex(l.ID); err == nil {
+		e.ID = ID
+	}
+	return e
+}
@@ -24,10 +24,11 @@ func NewAddrBookRepo(db *mongo.Database) *AddrBookRepo {
 }
 
 type ContactWithPhonesEntity struct {
-	ID        primitive.ObjectID `bson:"_id, omitempty"`
-	FirstName string             `bson:"firstName"`
-	LastName  string             `bson:"lastName"`
-	Phones    []*PhoneEntity     `bson:"phones"`
+	ID             primitive.ObjectID `bson:"_id, omitempty"`
+	CustomerNumber string             `bson:"customerNumber,omitempty"`
+	FirstName      string             `bson:"firstName"`
+	LastName       string             `bson:"lastName"`
+	Phones         []*PhoneEntity     `bson:"phones"`
 }
 
 type PhoneEntity struct {
@@ -77,6 +78,19 @@ func (r *AddrBookRepo) SelectContactByID(ctx context.Context, ID primitive.Objec
 	return &c, nil
 }
 
+func (r *AddrBookRepo) SelectContactsByCustomerNumber(ctx context.Context, customerNumber string) ([]*ContactWithPhonesEntity, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{"customerNumber": customerNumber})
+	if err != nil {
+		return nil, err
+	}
+	var results []*ContactWithPhonesEntity
+	if err = cursor.All(ctx, &results); err != nil {
+		app.Logger(ctx).Errorln("failed to decode contact records:", err)
+		return nil, err
+	}
+	return results, nil
+}
+
 func (r *AddrBookRepo) SelectAllContacts(ctx context.Context) ([]*ContactWithPhonesEntity, error) {
 	// Set options for the find operation
 	findOptions := options.Find()
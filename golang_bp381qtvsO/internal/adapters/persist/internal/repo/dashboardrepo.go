@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// DashboardRepo stores saved searches and dashboards, each in its own collection
+type DashboardRepo struct {
+	searches   *mongo.Collection
+	dashboards *mongo.Collection
+}
+
+func NewDashboardRepo(db *mongo.Database) *DashboardRepo {
+	return &DashboardRepo{
+		searches:   db.Collection("savedSearches"),
+		dashboards: db.Collection("dashboards"),
+	}
+}
+
+type LogQueryEntity struct {
+	Store   string            `bson:"store,omitempty"`
+	Text    string            `bson:"text,omitempty"`
+	Sources []string          `bson:"sources,omitempty"`
+	Topics  []string          `bson:"topics,omitempty"`
+	Levels  []string          `bson:"levels,omitempty"`
+	Fields  map[string]string `bson:"fields,omitempty"`
+	Limit   int               `bson:"limit,omitempty"`
+}
+
+type TimeRangeEntity struct {
+	Last time.Duration `bson:"last,omitempty"`
+	From time.Time     `bson:"from,omitempty"`
+	To   time.Time     `bson:"to,omitempty"`
+}
+
+type SavedSearchEntity struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Tenant      string             `bson:"tenant,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Query       LogQueryEntity     `bson:"query"`
+	TimeRange   TimeRangeEntity    `bson:"timeRange"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt"`
+}
+
+type DashboardEntity struct {
+	ID          primitive.ObjectID      `bson:"_id,omitempty"`
+	Tenant      string                  `bson:"tenant,omitempty"`
+	Name        string                  `bson:"name"`
+	Description string                  `bson:"description,omitempty"`
+	Panels      []*DashboardPanelEntity `bson:"panels"`
+	CreatedAt   time.Time               `bson:"createdAt"`
+	UpdatedAt   time.Time               `bson:"updatedAt"`
+}
+
+type DashboardPanelEntity struct {
+	Title         string          `bson:"title"`
+	Visualization string          `bson:"visualization"`
+	Query         LogQueryEntity  `bson:"query"`
+	TimeRange     TimeRangeEntity `bson:"timeRange"`
+	GroupBy       string          `bson:"groupBy,omitempty"`
+	Interval      time.Duration   `bson:"interval,omitempty"`
+}
+
+func (r *DashboardRepo) SelectAllSavedSearches(ctx context.Context, tenant string) ([]*SavedSearchEntity, error) {
+	return selectAllByName[SavedSearchEntity](ctx, r.searches, tenant)
+}
+
+func (r *DashboardRepo) SelectSavedSearchByID(
+	ctx context.Context,
+	tenant string,
+	ID primitive.ObjectID,
+) (*SavedSearchEntity, error) {
+	return selectByID[SavedSearchEntity](ctx, r.searches, tenant, ID)
+}
+
+func (r *DashboardRepo) AddSavedSearch(ctx context.Context, s *SavedSearchEntity) (*SavedSearchEntity, error) {
+	s.ID = primitive.NewObjectID()
+	if _, err := r.searches.InsertOne(ctx, s); err != nil {
+		return nil, fmt.Errorf("error inserting saved search: %w", err)
+	}
+	return s, nil
+}
+
+func (r *DashboardRepo) UpdateSavedSearch(ctx context.Context, s *SavedSearchEntity) (found bool, err error) {
+	return replaceByID(ctx, r.searches, s.Tenant, s.ID, s)
+}
+
+func (r *DashboardRepo) DeleteSavedSearch(ctx context.Context, tenant string, ID primitive.ObjectID) (found bool, err error) {
+	return deleteByID(ctx, r.searches, tenant, ID)
+}
+
+func (r *DashboardRepo) SelectAllDashboards(ctx context.Context, tenant string) ([]*DashboardEntity, error) {
+	return selectAllByName[DashboardEntity](ctx, r.dashboards, tenant)
+}
+
+func (r *DashboardRepo) SelectDashboardByID(
+	ctx context.Context,
+	tenant string,
+	ID primitive.ObjectID,
+) (*DashboardEntity, error) {
+	return selectByID[DashboardEntity](ctx, r.dashboards, tenant, ID)
+}
+
+func (r *DashboardRepo) AddDashboard(ctx context.Context, d *DashboardEntity) (*DashboardEntity, error) {
+	d.ID = primitive.NewObjectID()
+	if _, err := r.dashboards.InsertOne(ctx, d); err != nil {
+		return nil, fmt.Errorf("error inserting dashboard: %w", err)
+	}
+	return d, nil
+}
+
+func (r *DashboardRepo) UpdateDashboard(ctx context.Context, d *DashboardEntity) (found bool, err error) {
+	return replaceByID(ctx, r.dashboards, d.Tenant, d.ID, d)
+}
+
+func (r *DashboardRepo) DeleteDashboard(ctx context.Context, tenant string, ID primitive.ObjectID) (found bool, err error) {
+	return deleteByID(ctx, r.dashboards, tenant, ID)
+}
+
+// tenantFilter selects documents of tenant, documents of all tenants if tenant is empty
+func tenantFilter(tenant string) bson.M {
+	if tenant == "" {
+		return bson.M{}
+	}
+	return bson.M{"tenant": tenant}
+}
+
+func idFilter(tenant string, ID primitive.ObjectID) bson.M {
+	filter := tenantFilter(tenant)
+	filter["_id"] = ID
+	return filter
+}
+
+func selectAllByName[T any](ctx context.Context, coll *mongo.Collection, tenant string) ([]*T, error) {
+	cursor, err := coll.Find(ctx, tenantFilter(tenant), options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error querying collection %s: %w", coll.Name(), err)
+	}
+	var results []*T
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode documents of collection %s: %w", coll.Name(), err)
+	}
+	return results, nil
+}
+
+func selectByID[T any](ctx context.Context, coll *mongo.Collection, tenant string, ID primitive.ObjectID) (*T, error) {
+	var e T
+	err := coll.FindOne(ctx, idFilter(tenant, ID)).Decode(&e)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching document %s of collection %s: %w", ID.Hex(), coll.Name(), err)
+	}
+	return &e, nil
+}
+
+func replaceByID(ctx context.Context, coll *mongo.Collection, tenant string, ID primitive.ObjectID, doc any) (bool, error) {
+	result, err := coll.ReplaceOne(ctx, idFilter(tenant, ID), doc)
+	if err != nil {
+		return false, fmt.Errorf("error replacing document %s of collection %s: %w", ID.Hex(), coll.Name(), err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func deleteByID(ctx context.Context, coll *mongo.Collection, tenant string, ID primitive.ObjectID) (bool, error) {
+	result, err := coll.DeleteOne(ctx, idFilter(tenant, ID))
+	if err != nil {
+		return false, fmt.Errorf("error deleting document %s of collection %s: %w", ID.Hex(), coll.Name(), err)
+	}
+	return result.DeletedCount > 0, nil
+}
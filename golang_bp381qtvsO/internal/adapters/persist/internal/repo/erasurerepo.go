@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type ErasureRepo struct {
+	coll *mongo.Collection
+}
+
+func NewErasureRepo(db *mongo.Database) *ErasureRepo {
+	return &ErasureRepo{
+		coll: db.Collection("erasures"),
+	}
+}
+
+// ErasureEntity is keyed by tenant and customer number, repeated erasure of the same customer replaces previous
+// one. Erasures of all tenants are keyed by customer number only.
+type ErasureEntity struct {
+	ID              string                    `bson:"_id"`
+	CustomerNumber  string                    `bson:"customerNumber"`
+	Tenant          string                    `bson:"tenant,omitempty"`
+	Status          string                    `bson:"status"`
+	Action          string                    `bson:"action"`
+	RequestedAt     time.Time                 `bson:"requestedAt"`
+	CompletedAt     *time.Time                `bson:"completedAt,omitempty"`
+	ContactsDeleted int                       `bson:"contactsDeleted"`
+	Logs            []*ErasureLogResultEntity `bson:"logs"`
+	NotCovered      []string                  `bson:"notCovered,omitempty"`
+	Error           string                    `bson:"error,omitempty"`
+}
+
+type ErasureLogResultEntity struct {
+	Store   string `bson:"store"`
+	Matched int64  `bson:"matched"`
+	Erased  int64  `bson:"erased"`
+}
+
+// ErasureID returns key of erasure of customer of tenant
+func ErasureID(tenant string, customerNumber string) string {
+	if tenant == "" {
+		return customerNumber
+	}
+	return tenant + "/" + customerNumber
+}
+
+func (r *ErasureRepo) SaveErasure(ctx context.Context, e *ErasureEntity) error {
+	e.ID = ErasureID(e.Tenant, e.CustomerNumber)
+	filter := bson.M{"_id": e.ID}
+	_, err := r.coll.ReplaceOne(ctx, filter, e, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving erasure: %w", err)
+	}
+	return nil
+}
+
+func (r *ErasureRepo) SelectErasure(ctx context.Context, tenant string, customerNumber string) (*ErasureEntity, error) {
+	var e ErasureEntity
+	err := r.coll.FindOne(ctx, bson.M{"_id": ErasureID(tenant, customerNumber)}).Decode(&e)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching erasure: %w", err)
+	}
+	// erasures saved before tenants were supported only have the customer number as id
+	if e.CustomerNumber == "" {
+		e.CustomerNumber = e.ID
+	}
+	return &e, nil
+}
@@ -0,0 +1,299 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+const duplicateKeyCode = 11000
+
+type LogRepo struct {
+	coll *mongo.Collection
+}
+
+func NewLogRepo(db *mongo.Database, collection string) *LogRepo {
+	return &LogRepo{
+		coll: db.Collection(collection),
+	}
+}
+
+type LogRecordEntity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Timestamp time.Time          `bson:"timestamp"`
+	Topic     string             `bson:"topic"`
+	Tenant    string             `bson:"tenant,omitempty"`
+	Source    string             `bson:"source"`
+	Level     string             `bson:"level"`
+	Message   string             `bson:"message"`
+	Fields    map[string]any     `bson:"fields,omitempty"`
+	Inserted  time.Time          `bson:"inserted,omitempty"` // set by the sink, missing for restored records
+}
+
+func (r *LogRepo) InsertLogs(ctx context.Context, logs []*LogRecordEntity) error {
+	docs := make([]any, len(logs))
+	for i, l := range logs {
+		if l.ID == primitive.NilObjectID {
+			l.ID = primitive.NewObjectID()
+		}
+		docs[i] = l
+	}
+	// unordered insert keeps going after duplicates, so records already stored by
+	// a previous partially failed attempt do not fail the whole batch again
+	_, err := r.coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeyErrors(err) {
+		return fmt.Errorf("error inserting log records into collection %s: %w", r.coll.Name(), err)
+	}
+	return nil
+}
+
+// onlyDuplicateKeyErrors tells whether every write of a failed bulk write was rejected as duplicate,
+// mongo.IsDuplicateKeyError is also true if other writes failed for a different reason
+func onlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, we := range bulkErr.WriteErrors {
+		if we.Code != duplicateKeyCode {
+			return false
+		}
+	}
+	return true
+}
+
+// ServerMajorVersion returns the major version of the MongoDB server
+func (r *LogRepo) ServerMajorVersion(ctx context.Context) (int, error) {
+	var info struct {
+		VersionArray []int `bson:"versionArray"`
+	}
+	cmd := bson.D{{Key: "buildInfo", Value: 1}}
+	if err := r.coll.Database().RunCommand(ctx, cmd).Decode(&info); err != nil {
+		return 0, fmt.Errorf("error fetching server version: %w", err)
+	}
+	if len(info.VersionArray) == 0 {
+		return 0, errors.New("server did not report its version")
+	}
+	return info.VersionArray[0], nil
+}
+
+// EnsureCollection creates log collection if it does not exist yet, optionally as time-series collection
+// with "timestamp" as time field and "source" as meta field. Returns whether collection is time-series.
+func (r *LogRepo) EnsureCollection(ctx context.Context, timeSeries bool) (bool, error) {
+	db := r.coll.Database()
+	specs, err := db.ListCollectionSpecifications(ctx, bson.M{"name": r.coll.Name()})
+	if err != nil {
+		return false, fmt.Errorf("error listing collections: %w", err)
+	}
+	if len(specs) > 0 {
+		return specs[0].Type == "timeseries", nil
+	}
+	opts := options.CreateCollection()
+	if timeSeries {
+		opts.SetTimeSeriesOptions(options.TimeSeries().
+			SetTimeField("timestamp").
+			SetMetaField("source").
+			SetGranularity("seconds"))
+	}
+	err = db.CreateCollection(ctx, r.coll.Name(), opts)
+	if err != nil && !isNamespaceExistsError(err) {
+		return false, fmt.Errorf("error creating collection %s: %w", r.coll.Name(), err)
+	}
+	return timeSeries, nil
+}
+
+// SetCollectionExpiry changes expireAfterSeconds of time-series collection, zero disables expiry
+func (r *LogRepo) SetCollectionExpiry(ctx context.Context, maxAge time.Duration) error {
+	var expire any = "off"
+	if maxAge > 0 {
+		expire = int64(maxAge.Seconds())
+	}
+	cmd := bson.D{{Key: "collMod", Value: r.coll.Name()}, {Key: "expireAfterSeconds", Value: expire}}
+	if err := r.coll.Database().RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("error changing expiry of collection %s: %w", r.coll.Name(), err)
+	}
+	return nil
+}
+
+// IndexNames returns names of all indexes of the collection that start with prefix
+func (r *LogRepo) IndexNames(ctx context.Context, prefix string) ([]string, error) {
+	specs, err := r.coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing indexes of collection %s: %w", r.coll.Name(), err)
+	}
+	var names []string
+	for _, spec := range specs {
+		if strings.HasPrefix(spec.Name, prefix) {
+			names = append(names, spec.Name)
+		}
+	}
+	return names, nil
+}
+
+// CreateTTLIndex creates index on timestamp that deletes records matching filter once they are older than maxAge
+func (r *LogRepo) CreateTTLIndex(ctx context.Context, name string, filter bson.M, maxAge time.Duration) error {
+	opts := options.Index().
+		SetName(name).
+		SetExpireAfterSeconds(int32(maxAge.Seconds()))
+	if len(filter) > 0 {
+		opts.SetPartialFilterExpression(filter)
+	}
+	_, err := r.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "timestamp", Value: 1}},
+		Options: opts,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating ttl index %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *LogRepo) DropIndex(ctx context.Context, name string) error {
+	if _, err := r.coll.Indexes().DropOne(ctx, name); err != nil {
+		return fmt.Errorf("error dropping index %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *LogRepo) DeleteLogs(ctx context.Context, filter bson.M) (int64, error) {
+	result, err := r.coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting log records from collection %s: %w", r.coll.Name(), err)
+	}
+	return result.DeletedCount, nil
+}
+
+// UpdateLogs applies update (document or pipeline) to records matching filter
+func (r *LogRepo) UpdateLogs(ctx context.Context, filter bson.M, update any) (matched int64, modified int64, err error) {
+	result, err := r.coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error updating log records in collection %s: %w", r.coll.Name(), err)
+	}
+	return result.MatchedCount, result.ModifiedCount, nil
+}
+
+func isNamespaceExistsError(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists"
+}
+
+// OldestLogTimestamp returns timestamp of the oldest record, zero time if collection is empty
+func (r *LogRepo) OldestLogTimestamp(ctx context.Context) (time.Time, error) {
+	var e LogRecordEntity
+	opts := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	err := r.coll.FindOne(ctx, bson.M{}, opts).Decode(&e)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("error fetching oldest log record: %w", err)
+	}
+	return e.Timestamp, nil
+}
+
+// ExistingLogIDs returns which of ids are already stored, the time range [from, to] of the records lets
+// time-series collections, which do not index _id, only scan buckets of that range
+func (r *LogRepo) ExistingLogIDs(
+	ctx context.Context,
+	ids []primitive.ObjectID,
+	from time.Time,
+	to time.Time,
+) (map[primitive.ObjectID]bool, error) {
+	filter := bson.M{
+		"_id":       bson.M{"$in": ids},
+		"timestamp": bson.M{"$gte": from, "$lte": to},
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("error querying log record ids: %w", err)
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode log record ids: %w", err)
+	}
+	existing := make(map[primitive.ObjectID]bool, len(docs))
+	for _, d := range docs {
+		existing[d.ID] = true
+	}
+	return existing, nil
+}
+
+// DistinctSources returns sources of records matching filter
+func (r *LogRepo) DistinctSources(ctx context.Context, filter bson.M) ([]string, error) {
+	values, err := r.coll.Distinct(ctx, "source", filter)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching log sources: %w", err)
+	}
+	sources := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			sources = append(sources, s)
+		}
+	}
+	return sources, nil
+}
+
+// StreamLogs calls fn for every record matching filter in timestamp order without loading them all into memory
+func (r *LogRepo) StreamLogs(ctx context.Context, filter bson.M, fn func(e *LogRecordEntity) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("error querying log records: %w", err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var e LogRecordEntity
+		if err = cursor.Decode(&e); err != nil {
+			return fmt.Errorf("failed to decode log record: %w", err)
+		}
+		if err = fn(&e); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// FindLogs returns at most limit records matching filter, newest first
+func (r *LogRepo) FindLogs(ctx context.Context, filter bson.M, limit int) ([]*LogRecordEntity, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error querying log records: %w", err)
+	}
+	var results []*LogRecordEntity
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode log records: %w", err)
+	}
+	return results, nil
+}
+
+type LogBucketEntity struct {
+	ID struct {
+		Key  string    `bson:"key,omitempty"`
+		Time time.Time `bson:"time,omitempty"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+// AggregateLogs runs aggregation pipeline whose documents are buckets
+func (r *LogRepo) AggregateLogs(ctx context.Context, pipeline bson.A) ([]*LogBucketEntity, error) {
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating log records: %w", err)
+	}
+	var results []*LogBucketEntity
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode log buckets: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LookupRepo reads documents of a lookup table collection
+type LookupRepo struct {
+	coll *mongo.Collection
+}
+
+func NewLookupRepo(db *mongo.Database, collection string) *LookupRepo {
+	return &LookupRepo{
+		coll: db.Collection(collection),
+	}
+}
+
+func (r *LookupRepo) SelectAll(ctx context.Context) ([]bson.M, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.M
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
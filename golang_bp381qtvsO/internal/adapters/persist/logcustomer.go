@@ -0,0 +1,275 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/archive"
+	"example_consumer/internal/adapters/persist/internal/mapper"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/pipeline"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const erasureRedactionRule = "erasure"
+
+// EraseCustomerLogs deletes or redacts records mentioning customer number. Redaction replaces the number in
+// the message, where it is not part of a longer token, and values of fields equal to it, and adds "erasure"
+// to the record's applied redaction rules. Archive files of the sink are rewritten the same way. Time-series collections support this only from MongoDB 7.0 on.
+func (a *logSinkAdapter) EraseCustomerLogs(
+	ctx context.Context,
+	q *model.CustomerLogQuery,
+	action model.ErasureAction,
+) (matched int64, erased int64, err error) {
+	info, err := a.ensureCollection(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	if info.metaFilterOnly {
+		return 0, 0, fmt.Errorf("erasure in time-series collection of log sink %s needs MongoDB 7.0", a.name)
+	}
+	filter := customerLogFilter(q)
+
+	if a.retention != nil && a.retention.archive != nil {
+		// archived records are erased first, retention may delete them from the collection meanwhile
+		matched, erased, err = a.retention.archive.eraseCustomer(q, action)
+		if err != nil {
+			return matched, erased, err
+		}
+	}
+	var m, e int64
+	switch action {
+	case model.ErasureActionDelete:
+		e, err = a.repo.DeleteLogs(ctx, filter)
+		m = e
+	case model.ErasureActionRedact:
+		m, e, err = a.redactCustomerLogs(ctx, q, filter)
+	default:
+		return 0, 0, fmt.Errorf("unknown erasure action: %s", action)
+	}
+	return matched + m, erased + e, err
+}
+
+// redactCustomerLogs redacts matching records one by one, MongoDB has no regular expression replacement
+// to mask the number only where it is not part of a longer token
+func (a *logSinkAdapter) redactCustomerLogs(
+	ctx context.Context,
+	q *model.CustomerLogQuery,
+	filter bson.M,
+) (matched int64, modified int64, err error) {
+	type match struct {
+		filter  bson.M
+		message string
+	}
+	// collected first, records must not be updated while the cursor may still return them
+	var matches []match
+	err = a.repo.StreamLogs(ctx, filter, func(e *repo.LogRecordEntity) error {
+		matches = append(matches, match{
+			filter:  bson.M{"_id": e.ID, "timestamp": e.Timestamp},
+			message: redactCustomerNumber(e.Message, q.CustomerNumber),
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, mt := range matches {
+		set := bson.M{
+			"message": bson.M{"$literal": mt.message},
+			"fields." + pipeline.RedactedField: bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$fields." + pipeline.RedactedField, bson.A{}}},
+				bson.A{erasureRedactionRule},
+			}},
+		}
+		for _, f := range q.Fields {
+			// fields missing in a record stay missing, $cond evaluates to missing value
+			set["fields."+f] = bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$fields." + f, q.CustomerNumber}},
+				pipeline.RedactionMask,
+				"$fields." + f,
+			}}
+		}
+		m, e, err := a.repo.UpdateLogs(ctx, mt.filter, bson.A{bson.M{"$set": set}})
+		if err != nil {
+			return matched, modified, err
+		}
+		matched += m
+		modified += e
+	}
+	return matched, modified, nil
+}
+
+func (a *logSinkAdapter) StreamCustomerLogs(
+	ctx context.Context,
+	q *model.CustomerLogQuery,
+	fn func(rec *model.LogRecord) error,
+) error {
+	return a.repo.StreamLogs(ctx, customerLogFilter(q), func(e *repo.LogRecordEntity) error {
+		return fn(mapper.LogRecordEntityToModel(e))
+	})
+}
+
+// eraseCustomer rewrites archive files containing records mentioning customer number, files without such
+// records are left untouched
+func (a *archiver) eraseCustomer(q *model.CustomerLogQuery, action model.ErasureAction) (int64, int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	manifest, err := archive.LoadManifest(a.dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	match := customerLineMatcher(q)
+	var matched, erased int64
+	for i, f := range manifest.Files {
+		found := false
+		err = archive.Read(a.dir, f, func(line *archive.Line) error {
+			found = found || match(line)
+			return nil
+		})
+		if err != nil {
+			return matched, erased, err
+		}
+		if !found {
+			continue
+		}
+		rewritten, err := archive.Rewrite(a.dir, f, func(line *archive.Line) *archive.Line {
+			if !match(line) {
+				return line
+			}
+			matched++
+			erased++
+			if action == model.ErasureActionDelete {
+				return nil
+			}
+			redactLine(line, q)
+			return line
+		})
+		if err != nil {
+			return matched, erased, err
+		}
+		manifest.Files[i] = rewritten
+		if err = manifest.Save(a.dir); err != nil {
+			return matched, erased, err
+		}
+	}
+	return matched, erased, nil
+}
+
+// customerLineMatcher selects archived records the same way customerLogFilter selects stored ones
+func customerLineMatcher(q *model.CustomerLogQuery) func(line *archive.Line) bool {
+	re := customerNumberRe(q.CustomerNumber)
+	return func(line *archive.Line) bool {
+		if q.Tenant != "" && line.Tenant != q.Tenant {
+			return false
+		}
+		if len(q.Topics) > 0 && lo.Contains(q.Topics, line.Topic) == q.ExcludeTopics {
+			return false
+		}
+		if re.MatchString(line.Message) {
+			return true
+		}
+		for _, f := range q.Fields {
+			if v, ok := lineField(line.Fields, f); ok && v == q.CustomerNumber {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func redactLine(line *archive.Line, q *model.CustomerLogQuery) {
+	line.Message = redactCustomerNumber(line.Message, q.CustomerNumber)
+	if line.Fields == nil {
+		line.Fields = make(map[string]any)
+	}
+	for _, f := range q.Fields {
+		if v, ok := lineField(line.Fields, f); ok && v == q.CustomerNumber {
+			setLineField(line.Fields, f, pipeline.RedactionMask)
+		}
+	}
+	rules, _ := line.Fields[pipeline.RedactedField].([]any)
+	line.Fields[pipeline.RedactedField] = append(rules, erasureRedactionRule)
+}
+
+// lineField returns value of dot separated path of decoded JSON fields
+func lineField(fields map[string]any, path string) (any, bool) {
+	var v any = fields
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func setLineField(fields map[string]any, path string, value any) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		fields = fields[key].(map[string]any) // path was resolved by lineField
+	}
+	fields[keys[len(keys)-1]] = value
+}
+
+// redactCustomerNumber masks the customer number wherever customerNumberRe finds it, so C-1000 stays as is
+// when C-100 is erased. Adjacent occurrences are masked as well, unlike with regular expression replacement
+// where the boundary character between them is consumed by the first match.
+func redactCustomerNumber(s string, customerNumber string) string {
+	if customerNumber == "" {
+		return s
+	}
+	var sb strings.Builder
+	last := 0
+	for pos := 0; pos < len(s); {
+		i := strings.Index(s[pos:], customerNumber)
+		if i < 0 {
+			break
+		}
+		i += pos
+		end := i + len(customerNumber)
+		if (i == 0 || !isAlphanumeric(s[i-1])) && (end == len(s) || !isAlphanumeric(s[end])) {
+			sb.WriteString(s[last:i])
+			sb.WriteString(pipeline.RedactionMask)
+			last, pos = end, end
+		} else {
+			pos = i + 1
+		}
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
+
+func customerNumberRe(customerNumber string) *regexp.Regexp {
+	// number must not be part of a longer token, C-100 must not select records of C-1000
+	return regexp.MustCompile(fmt.Sprintf(`(^|[^A-Za-z0-9])%s($|[^A-Za-z0-9])`, regexp.QuoteMeta(customerNumber)))
+}
+
+func customerLogFilter(q *model.CustomerLogQuery) bson.M {
+	or := bson.A{bson.M{"message": bson.M{"$regex": customerNumberRe(q.CustomerNumber).String()}}}
+	for _, f := range q.Fields {
+		or = append(or, bson.M{"fields." + f: q.CustomerNumber})
+	}
+	filter := bson.M{"$or": or}
+	if q.Tenant != "" {
+		filter["tenant"] = q.Tenant
+	}
+	if len(q.Topics) > 0 {
+		if q.ExcludeTopics {
+			filter["topic"] = bson.M{"$nin": q.Topics}
+		} else {
+			filter["topic"] = bson.M{"$in": q.Topics}
+		}
+	}
+	return filter
+}
@@ -0,0 +1,31 @@
+package persist
+
+import "testing"
+
+func TestRedactCustomerNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "whole message", message: "C-100", want: "***"},
+		{name: "inside text", message: "order of C-100 shipped", want: "order of *** shipped"},
+		{name: "longer number untouched", message: "order of C-1000 shipped", want: "order of C-1000 shipped"},
+		{name: "prefixed number untouched", message: "order of XC-100", want: "order of XC-100"},
+		{name: "punctuation boundaries", message: "customer=C-100,C-1000;(C-100)", want: "customer=***,C-1000;(***)"},
+		{name: "adjacent occurrences", message: "C-100 C-100", want: "*** ***"},
+		{name: "after longer number", message: "C-1000 and C-100", want: "C-1000 and ***"},
+		{name: "not mentioned", message: "nothing here", want: "nothing here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactCustomerNumber(tt.message, "C-100"); got != tt.want {
+				t.Errorf("redactCustomerNumber() = %q, want %q", got, tt.want)
+			}
+			if re := customerNumberRe("C-100"); re.MatchString(tt.message) != (tt.message != tt.want) {
+				t.Errorf("customerNumberRe matches %q: %v, but redaction changed it: %v",
+					tt.message, re.MatchString(tt.message), tt.message != tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/mapper"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+	"regexp"
+
+	"github.com/samber/lo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (a *logSinkAdapter) SearchLogs(ctx context.Context, q *model.LogQuery) ([]*model.LogRecord, error) {
+	entities, err := a.repo.FindLogs(ctx, logQueryFilter(q), q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(entities, func(item *repo.LogRecordEntity, _ int) *model.LogRecord {
+		return mapper.LogRecordEntityToModel(item)
+	}), nil
+}
+
+func (a *logSinkAdapter) AggregateLogs(ctx context.Context, agg *model.LogAggregation) ([]*model.LogBucket, error) {
+	group := bson.M{}
+	sort := bson.D{}
+	if agg.Interval > 0 {
+		// timestamp rounded down to a multiple of the interval
+		ms := agg.Interval.Milliseconds()
+		group["time"] = bson.M{"$toDate": bson.M{"$subtract": bson.A{
+			bson.M{"$toLong": "$timestamp"},
+			bson.M{"$mod": bson.A{bson.M{"$toLong": "$timestamp"}, ms}},
+		}}}
+		sort = append(sort, bson.E{Key: "_id.time", Value: 1})
+	}
+	if agg.GroupBy != "" {
+		group["key"] = bson.M{"$convert": bson.M{
+			"input":   "$" + logAttributePath(agg.GroupBy),
+			"to":      "string",
+			"onError": "",
+			"onNull":  "",
+		}}
+	}
+	sort = append(sort, bson.E{Key: "count", Value: -1}, bson.E{Key: "_id.key", Value: 1})
+	pipeline := bson.A{
+		bson.M{"$match": logQueryFilter(&agg.Query)},
+		bson.M{"$group": bson.M{"_id": group, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": sort},
+		bson.M{"$limit": agg.Query.Limit},
+	}
+	entities, err := a.repo.AggregateLogs(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(entities, func(item *repo.LogBucketEntity, _ int) *model.LogBucket {
+		return &model.LogBucket{
+			Key:   item.ID.Key,
+			Time:  item.ID.Time,
+			Count: item.Count,
+		}
+	}), nil
+}
+
+func logQueryFilter(q *model.LogQuery) bson.M {
+	filter := bson.M{}
+	if q.Text != "" {
+		filter["message"] = bson.M{"$regex": regexp.QuoteMeta(q.Text), "$options": "i"}
+	}
+	if len(q.Sources) > 0 {
+		filter["source"] = bson.M{"$in": q.Sources}
+	}
+	if len(q.Topics) > 0 {
+		filter["topic"] = bson.M{"$in": q.Topics}
+	}
+	if q.Tenant != "" {
+		filter["tenant"] = q.Tenant
+	}
+	if len(q.Levels) > 0 {
+		filter["level"] = bson.M{"$in": q.Levels}
+	}
+	for path, value := range q.Fields {
+		filter["fields."+path] = value
+	}
+	ts := bson.M{}
+	if !q.From.IsZero() {
+		ts["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		ts["$lt"] = q.To
+	}
+	if len(ts) > 0 {
+		filter["timestamp"] = ts
+	}
+	return filter
+}
+
+// logAttributePath returns document path of record attribute, anything else than source, topic and level
+// is a path within record fields
+func logAttributePath(attribute string) string {
+	switch attribute {
+	case "source", "topic", "level":
+		return attribute
+	default:
+		return "fields." + attribute
+	}
+}
@@ -0,0 +1,113 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/mapper"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+type logSinkAdapter struct {
+	name       string
+	repo       *repo.LogRepo
+	timeSeries bool
+	retention  *retentionJob
+
+	mu    sync.Mutex
+	ready bool
+	info  collectionInfo
+}
+
+// collectionInfo describes the log collection once it exists
+type collectionInfo struct {
+	timeSeries bool
+	// metaFilterOnly is set for time-series collections before MongoDB 7.0, their deletes and updates may
+	// only filter on the meta field (source)
+	metaFilterOnly bool
+}
+
+// NewLogSinkAdapter returns sink that stores log records in the configured mongodb collection.
+// Collection is created on first use (as time-series collection if configured) and its records
+// are expired according to retention rules.
+func NewLogSinkAdapter(
+	p outport.Persistence,
+	name string,
+	cfg *app.MongoSinkConfig,
+) (outport.LogSink, error) {
+	r := repo.NewLogRepo(p.DB(), cfg.Collection)
+	a := &logSinkAdapter{
+		name:       name,
+		repo:       r,
+		timeSeries: cfg.TimeSeries,
+	}
+	job, err := newRetentionJob(r, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if job.enabled() {
+		a.retention = job
+		job.start(a.ensureCollection)
+	}
+	return a, nil
+}
+
+func (a *logSinkAdapter) Name() string {
+	return a.name
+}
+
+func (a *logSinkAdapter) Write(ctx context.Context, records []*model.LogRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	// inserting into missing collection would implicitly create a regular one
+	if _, err := a.ensureCollection(ctx); err != nil {
+		return err
+	}
+	// insertion time tells the archive which records arrived after their day was archived
+	now := time.Now().UTC()
+	return a.repo.InsertLogs(ctx, lo.Map(records, func(item *model.LogRecord, _ int) *repo.LogRecordEntity {
+		e := mapper.LogRecordModelToEntity(item)
+		e.Inserted = now
+		return e
+	}))
+}
+
+func (a *logSinkAdapter) Close() {
+	if a.retention != nil {
+		a.retention.close()
+	}
+}
+
+func (a *logSinkAdapter) ensureCollection(ctx context.Context) (collectionInfo, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ready {
+		return a.info, nil
+	}
+	timeSeries, err := a.repo.EnsureCollection(ctx, a.timeSeries)
+	if err != nil {
+		return collectionInfo{}, err
+	}
+	if a.timeSeries && !timeSeries {
+		zap.S().Warnf("Collection of log sink %s already exists as regular collection, it is not converted to time-series", a.name)
+	}
+	info := collectionInfo{timeSeries: timeSeries}
+	if timeSeries {
+		major, err := a.repo.ServerMajorVersion(ctx)
+		if err != nil {
+			return collectionInfo{}, err
+		}
+		info.metaFilterOnly = major < 7
+	}
+	a.timeSeries = timeSeries
+	a.info = info
+	a.ready = true
+	return info, nil
+}
@@ -0,0 +1,44 @@
+package persist
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+)
+
+type lookupSourceAdapter struct {
+	repo     *repo.LookupRepo
+	keyField string
+}
+
+// NewLookupSourceAdapter reads lookup table from documents of collection, top level fields are the columns
+func NewLookupSourceAdapter(p outport.Persistence, collection, keyField string) outport.LookupSource {
+	return &lookupSourceAdapter{
+		repo:     repo.NewLookupRepo(p.DB(), collection),
+		keyField: keyField,
+	}
+}
+
+func (a *lookupSourceAdapter) LoadLookupTable(ctx context.Context) (model.LookupTable, error) {
+	docs, err := a.repo.SelectAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := make(model.LookupTable, len(docs))
+	for _, doc := range docs {
+		key, ok := doc[a.keyField]
+		if !ok || key == nil {
+			continue
+		}
+		values := make(map[string]string, len(doc))
+		for k, v := range doc {
+			if k != "_id" && v != nil {
+				values[k] = fmt.Sprint(v)
+			}
+		}
+		table[fmt.Sprint(key)] = values
+	}
+	return table, nil
+}
@@ -0,0 +1,304 @@
+package persist
+
+import (
+	"context"
+	"errors"
+	"example_consumer/internal/adapters/persist/internal/repo"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const (
+	retentionIndexPrefix     = "retention_"
+	defaultPurgeInterval     = time.Hour
+	retentionSetupRetryDelay = 30 * time.Second
+)
+
+// retentionRule applies maxAge to records matching filter and not matching any rule before it
+type retentionRule struct {
+	filter bson.M
+	maxAge time.Duration
+	ttl    bool   // enforced by TTL index instead of purge job
+	index  string // name of TTL index
+}
+
+// retentionJob keeps TTL indexes of log collection in sync with configured retention rules and
+// periodically deletes old records of the rules that cannot be expressed as TTL index. If archive
+// is enabled, no TTL index is used at all: completed days are archived first and the purge job only
+// deletes records of days that were archived successfully.
+type retentionJob struct {
+	repo       *repo.LogRepo
+	archive    *archiver
+	collection string
+	rules      []*retentionRule
+	defaultAge time.Duration
+	interval   time.Duration
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+func newRetentionJob(r *repo.LogRepo, cfg *app.MongoSinkConfig) (*retentionJob, error) {
+	rc := cfg.Retention
+	job := &retentionJob{
+		repo:       r,
+		archive:    newArchiver(r, cfg),
+		collection: cfg.Collection,
+		defaultAge: rc.Default,
+		interval:   rc.PurgeInterval,
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	if job.interval <= 0 {
+		job.interval = defaultPurgeInterval
+	}
+	for _, rule := range rc.Rules {
+		filter := bson.M{}
+		if rule.Source != "" {
+			filter["source"] = rule.Source
+		}
+		if rule.Level != "" {
+			filter["level"] = string(model.ParseLogLevel(rule.Level))
+		}
+		if len(filter) == 0 || rule.MaxAge <= 0 {
+			return nil, fmt.Errorf("retention rule of collection %s needs source or level and maxAge", cfg.Collection)
+		}
+		job.rules = append(job.rules, &retentionRule{filter: filter, maxAge: rule.MaxAge})
+	}
+	return job, nil
+}
+
+// errUnsupportedRetention is returned by setup if the collection cannot enforce the configured rules
+var errUnsupportedRetention = errors.New("retention is not supported by the collection")
+
+func (j *retentionJob) enabled() bool {
+	return j.defaultAge > 0 || len(j.rules) > 0 || j.archive != nil
+}
+
+// start waits for setup of the collection (which may fail while database is unavailable) and then runs purge
+// loop. Rules the collection cannot enforce stop the service, like other configuration errors.
+func (j *retentionJob) start(setup func(ctx context.Context) (collectionInfo, error)) {
+	go func() {
+		defer close(j.stopped)
+		ctx := app.BackgroundContextWithDefaultLogger()
+		for {
+			info, err := setup(ctx)
+			if err == nil {
+				err = j.syncIndexes(ctx, info)
+			}
+			if err == nil {
+				break
+			}
+			if errors.Is(err, errUnsupportedRetention) {
+				zap.S().Fatalf("invalid retention configuration of collection %s: %v", j.collection, err)
+			}
+			zap.S().Warnf("Retention setup of collection %s failed, retrying in %s: %v",
+				j.collection, retentionSetupRetryDelay, err)
+			select {
+			case <-j.stop:
+				return
+			case <-time.After(retentionSetupRetryDelay):
+			}
+		}
+		if !j.needsPurge() && j.archive == nil {
+			return
+		}
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			j.runOnce(ctx)
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *retentionJob) close() {
+	close(j.stop)
+	<-j.stopped
+}
+
+// syncIndexes decides which rules are enforced by TTL indexes, creates missing indexes and drops outdated ones.
+// A rule can only use TTL index if no rule before it may match the same records (TTL index does not know
+// about rule order), time-series collections additionally allow partial TTL indexes on meta field only.
+// Before MongoDB 7.0 time-series collections cannot be purged at all, as deletes may only filter on the
+// meta field, so only rules by source enforced by TTL index and the default expiry are possible there.
+func (j *retentionJob) syncIndexes(ctx context.Context, info collectionInfo) error {
+	timeSeries := info.timeSeries
+	if info.metaFilterOnly {
+		if j.archive != nil {
+			return fmt.Errorf("%w: archiving time-series collection needs MongoDB 7.0", errUnsupportedRetention)
+		}
+		for _, rule := range j.rules {
+			if !onlySourceFilter(rule.filter) {
+				return fmt.Errorf("%w: rules by level on time-series collection need MongoDB 7.0", errUnsupportedRetention)
+			}
+		}
+	}
+	wanted := make(map[string]*retentionRule)
+	for i, rule := range j.rules {
+		// TTL index would delete records whether or not they were archived
+		rule.ttl = j.archive == nil &&
+			(!timeSeries || onlySourceFilter(rule.filter)) && disjointWithAll(rule, j.rules[:i])
+		if rule.ttl {
+			rule.index = ttlIndexName(rule.filter, rule.maxAge)
+			wanted[rule.index] = rule
+		}
+	}
+	useDefaultTTL := j.useDefaultTTL()
+	if timeSeries {
+		// time-series collections have expiry on collection level instead of index on time field
+		expiry := time.Duration(0)
+		if useDefaultTTL {
+			expiry = j.defaultAge
+		}
+		if err := j.repo.SetCollectionExpiry(ctx, expiry); err != nil {
+			return err
+		}
+	} else if useDefaultTTL {
+		name := ttlIndexName(nil, j.defaultAge)
+		wanted[name] = &retentionRule{maxAge: j.defaultAge, ttl: true, index: name}
+	}
+
+	existing, err := j.repo.IndexNames(ctx, retentionIndexPrefix)
+	if err != nil {
+		return err
+	}
+	for _, name := range existing {
+		if _, ok := wanted[name]; ok {
+			delete(wanted, name)
+			continue
+		}
+		zap.S().Infof("Drop outdated retention index %s of collection %s", name, j.collection)
+		if err = j.repo.DropIndex(ctx, name); err != nil {
+			return err
+		}
+	}
+	for name, rule := range wanted {
+		zap.S().Infof("Create retention index %s of collection %s", name, j.collection)
+		if err = j.repo.CreateTTLIndex(ctx, name, rule.filter, rule.maxAge); err != nil {
+			// e.g. partial TTL indexes on time-series need MongoDB 6.3, purge job takes over then
+			zap.S().Warnf("Retention rule of collection %s is enforced by purge job instead: %v", j.collection, err)
+			rule.ttl = false
+		}
+	}
+	if info.metaFilterOnly && j.needsPurge() {
+		return fmt.Errorf("%w: rules that are not enforced by TTL index on time-series collection need MongoDB 7.0",
+			errUnsupportedRetention)
+	}
+	return nil
+}
+
+// useDefaultTTL tells whether default retention is enforced by collection expiry or TTL index
+func (j *retentionJob) useDefaultTTL() bool {
+	return j.defaultAge > 0 && len(j.rules) == 0 && j.archive == nil
+}
+
+func (j *retentionJob) needsPurge() bool {
+	if j.defaultAge > 0 && !j.useDefaultTTL() {
+		return true
+	}
+	for _, rule := range j.rules {
+		if !rule.ttl {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *retentionJob) runOnce(ctx context.Context) {
+	// without archive, every record may be purged once it is old enough
+	var archived bson.M
+	if j.archive != nil {
+		archivedUntil, checkedUntil, err := j.archive.run(ctx)
+		if err != nil {
+			zap.S().Errorf("Archiving log records of collection %s failed, skipping purge: %v", j.collection, err)
+			return
+		}
+		if archivedUntil.IsZero() {
+			return
+		}
+		archived = bson.M{"timestamp": bson.M{"$lt": archivedUntil}, "$or": insertedBefore(checkedUntil)}
+	}
+	if j.needsPurge() {
+		j.purge(ctx, archived)
+	}
+}
+
+// purge deletes records older than retention of the first rule they match, but only those matching
+// archived unless it is nil
+func (j *retentionJob) purge(ctx context.Context, archived bson.M) {
+	now := time.Now().UTC()
+	previous := make([]bson.M, 0, len(j.rules))
+	for _, rule := range j.rules {
+		if !rule.ttl {
+			j.deleteOlderThan(ctx, rule.filter, previous, now.Add(-rule.maxAge), archived)
+		}
+		previous = append(previous, rule.filter)
+	}
+	if j.defaultAge > 0 && !j.useDefaultTTL() {
+		j.deleteOlderThan(ctx, bson.M{}, previous, now.Add(-j.defaultAge), archived)
+	}
+}
+
+func (j *retentionJob) deleteOlderThan(ctx context.Context, filter bson.M, exclude []bson.M, cutoff time.Time, archived bson.M) {
+	conditions := []bson.M{filter, {"timestamp": bson.M{"$lt": cutoff}}}
+	if len(exclude) > 0 {
+		conditions = append(conditions, bson.M{"$nor": exclude})
+	}
+	if archived != nil {
+		// records arriving late for an archived day are kept until the next archive run got them
+		conditions = append(conditions, archived)
+	}
+	deleted, err := j.repo.DeleteLogs(ctx, bson.M{"$and": conditions})
+	if err != nil {
+		zap.S().Errorf("Purging old log records of collection %s failed: %v", j.collection, err)
+		return
+	}
+	if deleted > 0 {
+		zap.S().Infof("Purged %d log records older than %s from collection %s",
+			deleted, cutoff.Format(time.RFC3339), j.collection)
+	}
+}
+
+func onlySourceFilter(filter bson.M) bool {
+	_, ok := filter["source"]
+	return ok && len(filter) == 1
+}
+
+// disjointWithAll checks that every other rule requires different value of some field than rule does
+func disjointWithAll(rule *retentionRule, others []*retentionRule) bool {
+	for _, other := range others {
+		disjoint := false
+		for field, value := range rule.filter {
+			if otherValue, ok := other.filter[field]; ok && otherValue != value {
+				disjoint = true
+				break
+			}
+		}
+		if !disjoint {
+			return false
+		}
+	}
+	return true
+}
+
+func ttlIndexName(filter bson.M, maxAge time.Duration) string {
+	name := retentionIndexPrefix
+	for _, field := range []string{"source", "level"} {
+		if v, ok := filter[field]; ok {
+			name += fmt.Sprintf("%s_%v_", field, v)
+		}
+	}
+	if len(filter) == 0 {
+		name += "default_"
+	}
+	return fmt.Sprintf("%s%ds", name, int64(maxAge.Seconds()))
+}
@@ -0,0 +1,169 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 500
+	defaultFlushInterval = time.Second
+	defaultMaxRetries    = 5
+	maxRetryBackoff      = 30 * time.Second
+)
+
+type bufferedSink struct {
+	sink          outport.LogSink
+	queue         chan *model.LogRecord
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+
+	mu      sync.RWMutex
+	closed  bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewBufferedSink decorates sink with its own queue and goroutine, so Write never blocks the caller.
+// Records are written in batches, failed records of a batch are retried with backoff. When the queue is full
+// new records are dropped and Write returns error.
+func NewBufferedSink(s outport.LogSink, cfg *app.SinkBufferConfig) outport.LogSink {
+	b := &bufferedSink{
+		sink:          s,
+		queue:         make(chan *model.LogRecord, positiveOr(cfg.Size, defaultBufferSize)),
+		batchSize:     positiveOr(cfg.BatchSize, defaultBatchSize),
+		flushInterval: cfg.FlushInterval,
+		maxRetries:    positiveOr(cfg.MaxRetries, defaultMaxRetries),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	if b.flushInterval <= 0 {
+		b.flushInterval = defaultFlushInterval
+	}
+	go b.run()
+	return b
+}
+
+func (b *bufferedSink) Name() string {
+	return b.sink.Name()
+}
+
+func (b *bufferedSink) Write(_ context.Context, records []*model.LogRecord) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return fmt.Errorf("sink %s is closed", b.Name())
+	}
+	for i, rec := range records {
+		select {
+		case b.queue <- rec:
+		default:
+			return fmt.Errorf("buffer of sink %s is full, dropped %d records", b.Name(), len(records)-i)
+		}
+	}
+	return nil
+}
+
+// Close stops accepting new records, writes everything that is still queued and closes decorated sink
+func (b *bufferedSink) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.stop)
+	}
+	b.mu.Unlock()
+	<-b.stopped
+	b.sink.Close()
+}
+
+func (b *bufferedSink) run() {
+	defer close(b.stopped)
+	ctx := app.BackgroundContextWithDefaultLogger()
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+	batch := make([]*model.LogRecord, 0, b.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			b.writeWithRetries(ctx, batch)
+			batch = make([]*model.LogRecord, 0, b.batchSize)
+		}
+	}
+	for {
+		select {
+		case rec := <-b.queue:
+			batch = append(batch, rec)
+			if len(batch) >= b.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-b.stop:
+			for {
+				select {
+				case rec := <-b.queue:
+					batch = append(batch, rec)
+					if len(batch) >= b.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *bufferedSink) writeWithRetries(ctx context.Context, batch []*model.LogRecord) {
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := b.sink.Write(ctx, batch)
+		if err == nil {
+			return
+		}
+		batch = failedRecords(err, batch)
+		if attempt >= b.maxRetries {
+			zap.S().Errorf("Dropping %d log records after %d failed attempts to write to sink %s: %v",
+				len(batch), attempt+1, b.Name(), err)
+			return
+		}
+		zap.S().Warnf("Write to sink %s failed, retrying in %s: %v", b.Name(), backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-b.stop:
+			// still retry while shutting down, but without waiting
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// failedRecords returns records that still have to be written after err, all of them unless the sink
+// reported a partial write
+func failedRecords(err error, records []*model.LogRecord) []*model.LogRecord {
+	var partial *outport.PartialWriteError
+	if errors.As(err, &partial) {
+		return partial.Failed
+	}
+	return records
+}
+
+// positiveOr returns value if it is positive or def otherwise
+func positiveOr(value int, def int) int {
+	if value > 0 {
+		return value
+	}
+	return def
+}
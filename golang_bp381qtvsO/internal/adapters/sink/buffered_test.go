@@ -0,0 +1,92 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSink fails the first writes as configured and keeps messages of records written successfully
+type fakeSink struct {
+	mu       sync.Mutex
+	failures []func(records []*model.LogRecord) error
+	written  []string
+	closed   bool
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Write(_ context.Context, records []*model.LogRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if len(s.failures) > 0 {
+		err = s.failures[0](records)
+		s.failures = s.failures[1:]
+	}
+	failed := failedRecords(err, records)
+	if err == nil {
+		failed = nil
+	}
+	for _, rec := range records[:len(records)-len(failed)] {
+		s.written = append(s.written, rec.Message)
+	}
+	return err
+}
+
+func (s *fakeSink) Close() {
+	s.closed = true
+}
+
+func failAll(records []*model.LogRecord) error {
+	return errors.New("unavailable")
+}
+
+func failFrom(i int) func(records []*model.LogRecord) error {
+	return func(records []*model.LogRecord) error {
+		return &outport.PartialWriteError{Failed: records[i:], Err: errors.New("unavailable")}
+	}
+}
+
+func TestBufferedSinkRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   []func(records []*model.LogRecord) error
+		maxRetries int
+		want       []string
+	}{
+		{name: "no failure", want: []string{"a", "b", "c", "d"}},
+		{name: "whole batch retried", failures: []func([]*model.LogRecord) error{failAll, failAll}, want: []string{"a", "b", "c", "d"}},
+		{name: "only failed records retried", failures: []func([]*model.LogRecord) error{failFrom(2), failFrom(1)}, want: []string{"a", "b", "c", "d"}},
+		{name: "dropped after max retries", failures: []func([]*model.LogRecord) error{failFrom(1), failAll, failAll}, maxRetries: 2, want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSink{failures: tt.failures}
+			b := NewBufferedSink(fake, &app.SinkBufferConfig{BatchSize: 10, FlushInterval: time.Hour, MaxRetries: tt.maxRetries})
+			var records []*model.LogRecord
+			for _, msg := range []string{"a", "b", "c", "d"} {
+				records = append(records, &model.LogRecord{Message: msg})
+			}
+			if err := b.Write(context.Background(), records); err != nil {
+				t.Fatal(err)
+			}
+			// close writes queued records and retries without waiting
+			b.Close()
+			if !reflect.DeepEqual(fake.written, tt.want) {
+				t.Errorf("written = %v, want %v", fake.written, tt.want)
+			}
+			if !fake.closed {
+				t.Error("decorated sink was not closed")
+			}
+		})
+	}
+}
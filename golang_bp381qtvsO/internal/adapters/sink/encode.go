@@ -0,0 +1,36 @@
+package sink
+
+import (
+	"encoding/json"
+	"example_consumer/internal/core/model"
+	"time"
+)
+
+// logRecordJson is the representation of a log record used by all sinks writing JSON (files, stdout, kafka, webhook)
+type logRecordJson struct {
+	ID        string         `json:"id,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	Topic     string         `json:"topic,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Source    string         `json:"source"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+func logRecordModelToJson(m *model.LogRecord) *logRecordJson {
+	return &logRecordJson{
+		ID:        m.ID,
+		Timestamp: m.Timestamp,
+		Topic:     m.Topic,
+		Tenant:    m.Tenant,
+		Source:    m.Source,
+		Level:     string(m.Level),
+		Message:   m.Message,
+		Fields:    m.Fields,
+	}
+}
+
+func marshalLogRecord(m *model.LogRecord) ([]byte, error) {
+	return json.Marshal(logRecordModelToJson(m))
+}
@@ -0,0 +1,157 @@
+package sink
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultFileMaxSizeMB = 100
+	defaultFileMaxFiles  = 10
+)
+
+type fileSink struct {
+	name     string
+	dir      string
+	prefix   string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink returns sink that writes log records as JSON lines into a file. Once the file grows past
+// MaxSizeMB it is renamed with a timestamp suffix and a new file is started, only MaxFiles rotated
+// files are kept.
+func NewFileSink(name string, cfg *app.FileSinkConfig) (outport.LogSink, error) {
+	s := &fileSink{
+		name:     name,
+		dir:      cfg.Dir,
+		prefix:   cfg.Name,
+		maxSize:  int64(cfg.MaxSizeMB) * 1024 * 1024,
+		maxFiles: cfg.MaxFiles,
+	}
+	if s.dir == "" {
+		s.dir = "."
+	}
+	if s.prefix == "" {
+		s.prefix = name
+	}
+	if s.maxSize <= 0 {
+		s.maxSize = defaultFileMaxSizeMB * 1024 * 1024
+	}
+	if s.maxFiles <= 0 {
+		s.maxFiles = defaultFileMaxFiles
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating log directory %s: %w", s.dir, err)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) Name() string {
+	return s.name
+}
+
+func (s *fileSink) Write(_ context.Context, records []*model.LogRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("file sink %s is closed", s.name)
+	}
+	var buf []byte
+	for _, rec := range records {
+		data, err := marshalLogRecord(rec)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, data...)
+		buf = append(buf, '\n')
+	}
+	n, err := s.file.Write(buf)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("error writing to %s: %w", s.file.Name(), err)
+	}
+	if s.size >= s.maxSize {
+		return s.rotate()
+	}
+	return nil
+}
+
+func (s *fileSink) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			zap.S().Warnf("Failed to close log file %s: %v", s.file.Name(), err)
+		}
+		s.file = nil
+	}
+}
+
+func (s *fileSink) currentPath() string {
+	return filepath.Join(s.dir, s.prefix+".log")
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.currentPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error reading log file size: %w", err)
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		zap.S().Warnf("Failed to close log file %s: %v", s.file.Name(), err)
+	}
+	s.file = nil
+	rotated := filepath.Join(s.dir, fmt.Sprintf("%s-%s.log", s.prefix, time.Now().UTC().Format("20060102T150405.000")))
+	if err := os.Rename(s.currentPath(), rotated); err != nil {
+		return fmt.Errorf("error rotating log file: %w", err)
+	}
+	s.removeOldFiles()
+	return s.open()
+}
+
+// removeOldFiles deletes the oldest rotated files so only maxFiles of them are kept
+func (s *fileSink) removeOldFiles() {
+	rotated, err := filepath.Glob(filepath.Join(s.dir, s.prefix+"-*.log"))
+	if err != nil {
+		zap.S().Warnf("Failed to list rotated log files: %v", err)
+		return
+	}
+	if len(rotated) <= s.maxFiles {
+		return
+	}
+	// timestamp suffix makes lexical order equal to rotation order
+	sort.Strings(rotated)
+	for _, path := range rotated[:len(rotated)-s.maxFiles] {
+		if err := os.Remove(path); err != nil {
+			zap.S().Warnf("Failed to remove rotated log file %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DropPolicy decides what happens when WAL reaches its max size
+type DropPolicy string
+
+const (
+	DropOldest DropPolicy = "oldest" // delete oldest segments to make room for new records
+	DropNewest DropPolicy = "newest" // reject new records
+)
+
+const (
+	segmentExt     = ".wal"
+	checkpointFile = "checkpoint"
+	headerSize     = 8 // uint32 payload length + uint32 crc32 of payload
+)
+
+var ErrFull = errors.New("write-ahead log is full")
+
+type Options struct {
+	Dir         string
+	SegmentSize int64
+	MaxSize     int64
+	DropPolicy  DropPolicy
+	OnDrop      func(records int) // called when unread records are dropped because of size cap
+}
+
+// Position identifies how far records returned by Read reach, pass it to Commit once they are processed
+type Position struct {
+	seq    uint64
+	offset int64
+	count  int
+}
+
+// WAL is a segmented append-only log on disk. Records are appended to the newest segment and read
+// in the same order from the oldest one. Fully read segments are deleted, read position is kept in
+// checkpoint file so records are not replayed again after restart.
+type WAL struct {
+	mu         sync.Mutex
+	opts       Options
+	segments   []*segment // oldest first, the last one is open for writing
+	active     *os.File
+	readOffset int64 // position in segments[0]
+}
+
+type segment struct {
+	seq     uint64
+	size    int64
+	records int // records not read yet
+}
+
+func Open(opts Options) (*WAL, error) {
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating wal directory %s: %w", opts.Dir, err)
+	}
+	if opts.DropPolicy == "" {
+		opts.DropPolicy = DropOldest
+	}
+	if opts.DropPolicy != DropOldest && opts.DropPolicy != DropNewest {
+		return nil, fmt.Errorf("unknown wal drop policy: %s", opts.DropPolicy)
+	}
+	w := &WAL{opts: opts}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	if err := w.openActive(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Append writes records to the log, records are written either all or none
+func (w *WAL) Append(records [][]byte) error {
+	var buf []byte
+	for _, rec := range records {
+		var header [headerSize]byte
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(rec)))
+		binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(rec))
+		buf = append(buf, header[:]...)
+		buf = append(buf, rec...)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.active == nil {
+		return errors.New("write-ahead log is closed")
+	}
+	if err := w.makeRoom(int64(len(buf)), len(records)); err != nil {
+		return err
+	}
+	cur := w.segments[len(w.segments)-1]
+	if cur.size > 0 && cur.size+int64(len(buf)) > w.opts.SegmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+		cur = w.segments[len(w.segments)-1]
+	}
+	n, err := w.active.Write(buf)
+	if err != nil {
+		// cut partially written data so the segment stays readable
+		_ = w.active.Truncate(cur.size)
+		return fmt.Errorf("error writing to wal segment: %w", err)
+	}
+	cur.size += int64(n)
+	cur.records += len(records)
+	return nil
+}
+
+// Read returns up to max oldest records that were not committed yet
+func (w *WAL) Read(max int) ([][]byte, Position, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(w.segments) > 1 && w.segments[0].records == 0 {
+		w.removeOldest()
+	}
+	seg := w.segments[0]
+	if seg.records == 0 {
+		return nil, Position{}, nil
+	}
+	f, err := os.Open(w.segmentPath(seg.seq))
+	if err != nil {
+		return nil, Position{}, err
+	}
+	defer f.Close()
+	if _, err = f.Seek(w.readOffset, io.SeekStart); err != nil {
+		return nil, Position{}, err
+	}
+	r := bufio.NewReader(io.LimitReader(f, seg.size-w.readOffset))
+	var out [][]byte
+	offset := w.readOffset
+	for len(out) < max && len(out) < seg.records {
+		rec, n, err := readRecord(r)
+		if err != nil {
+			if len(out) > 0 {
+				break
+			}
+			// rest of the segment cannot be trusted, skip it so replay does not get stuck
+			w.dropped(seg.records)
+			seg.records = 0
+			w.readOffset = seg.size
+			return nil, Position{}, fmt.Errorf("error reading wal segment %d: %w", seg.seq, err)
+		}
+		out = append(out, rec)
+		offset += n
+	}
+	return out, Position{seq: seg.seq, offset: offset, count: len(out)}, nil
+}
+
+// Commit marks records returned by Read as processed
+func (w *WAL) Commit(pos Position) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	seg := w.segments[0]
+	if seg.seq != pos.seq {
+		// segment was dropped meanwhile to make room for new records
+		return nil
+	}
+	w.readOffset = pos.offset
+	seg.records -= pos.count
+	if seg.records == 0 && len(w.segments) > 1 {
+		w.removeOldest()
+	}
+	return w.writeCheckpoint()
+}
+
+// Len returns number of records that were not committed yet
+func (w *WAL) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, seg := range w.segments {
+		n += seg.records
+	}
+	return n
+}
+
+// Size returns number of bytes used by segments on disk
+func (w *WAL) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.totalSize()
+}
+
+// Segments returns number of segment files
+func (w *WAL) Segments() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.segments)
+}
+
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.active == nil {
+		return nil
+	}
+	err := w.active.Close()
+	w.active = nil
+	return err
+}
+
+func (w *WAL) totalSize() int64 {
+	var size int64
+	for _, seg := range w.segments {
+		size += seg.size
+	}
+	return size
+}
+
+func (w *WAL) makeRoom(size int64, records int) error {
+	if w.opts.MaxSize <= 0 || w.totalSize()+size <= w.opts.MaxSize {
+		return nil
+	}
+	if w.opts.DropPolicy == DropNewest {
+		w.dropped(records)
+		return ErrFull
+	}
+	for w.totalSize()+size > w.opts.MaxSize {
+		if len(w.segments) == 1 {
+			if w.segments[0].size == 0 {
+				// records bigger than the whole log would not fit anyway
+				w.dropped(records)
+				return ErrFull
+			}
+			if err := w.rotate(); err != nil {
+				return err
+			}
+			continue
+		}
+		w.dropped(w.segments[0].records)
+		w.removeOldest()
+	}
+	return w.writeCheckpoint()
+}
+
+func (w *WAL) dropped(records int) {
+	if records > 0 && w.opts.OnDrop != nil {
+		w.opts.OnDrop(records)
+	}
+}
+
+func (w *WAL) removeOldest() {
+	seg := w.segments[0]
+	_ = os.Remove(w.segmentPath(seg.seq))
+	w.segments = w.segments[1:]
+	w.readOffset = 0
+}
+
+func (w *WAL) rotate() error {
+	if err := w.active.Close(); err != nil {
+		return fmt.Errorf("error closing wal segment: %w", err)
+	}
+	last := w.segments[len(w.segments)-1]
+	w.segments = append(w.segments, &segment{seq: last.seq + 1})
+	for len(w.segments) > 1 && w.segments[0].records == 0 {
+		w.removeOldest()
+	}
+	return w.openActive()
+}
+
+func (w *WAL) openActive() error {
+	seg := w.segments[len(w.segments)-1]
+	f, err := os.OpenFile(w.segmentPath(seg.seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening wal segment: %w", err)
+	}
+	w.active = f
+	return nil
+}
+
+func (w *WAL) segmentPath(seq uint64) string {
+	return filepath.Join(w.opts.Dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// load restores segments and read position from disk
+func (w *WAL) load() error {
+	cpSeq, cpOffset := w.readCheckpoint()
+	entries, err := os.ReadDir(w.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("error reading wal directory: %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for _, seq := range seqs {
+		if seq < cpSeq {
+			_ = os.Remove(w.segmentPath(seq))
+			continue
+		}
+		var from int64
+		if seq == cpSeq {
+			from = cpOffset
+		}
+		seg, err := w.scanSegment(seq, from)
+		if err != nil {
+			return err
+		}
+		if len(w.segments) == 0 {
+			w.readOffset = from
+		}
+		w.segments = append(w.segments, seg)
+	}
+	if len(w.segments) == 0 {
+		w.segments = []*segment{{seq: cpSeq}}
+		w.readOffset = 0
+	}
+	return nil
+}
+
+// scanSegment counts records after offset from and cuts off incomplete record at the end, which
+// is left behind if the process died in the middle of a write
+func (w *WAL) scanSegment(seq uint64, from int64) (*segment, error) {
+	path := w.segmentPath(seq)
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening wal segment: %w", err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	seg := &segment{seq: seq}
+	for {
+		_, n, err := readRecord(r)
+		if err != nil {
+			if err != io.EOF {
+				if err = f.Truncate(seg.size); err != nil {
+					return nil, fmt.Errorf("error truncating wal segment: %w", err)
+				}
+			}
+			break
+		}
+		if seg.size >= from {
+			seg.records++
+		}
+		seg.size += n
+	}
+	return seg, nil
+}
+
+func readRecord(r io.Reader) ([]byte, int64, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, errors.New("truncated record header")
+		}
+		return nil, 0, err
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	rec := make([]byte, size)
+	if _, err := io.ReadFull(r, rec); err != nil {
+		return nil, 0, errors.New("truncated record")
+	}
+	if crc32.ChecksumIEEE(rec) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, errors.New("record checksum mismatch")
+	}
+	return rec, int64(headerSize) + int64(size), nil
+}
+
+func (w *WAL) readCheckpoint() (uint64, int64) {
+	data, err := os.ReadFile(filepath.Join(w.opts.Dir, checkpointFile))
+	if err != nil {
+		return 0, 0
+	}
+	var seq uint64
+	var offset int64
+	if _, err = fmt.Sscanf(string(data), "%d %d", &seq, &offset); err != nil {
+		return 0, 0
+	}
+	return seq, offset
+}
+
+func (w *WAL) writeCheckpoint() error {
+	path := filepath.Join(w.opts.Dir, checkpointFile)
+	tmp := path + ".tmp"
+	data := fmt.Sprintf("%d %d", w.segments[0].seq, w.readOffset)
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		return fmt.Errorf("error writing wal checkpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,261 @@
+package wal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func records(from, to int) [][]byte {
+	var out [][]byte
+	for i := from; i < to; i++ {
+		out = append(out, []byte(fmt.Sprintf("record-%d", i)))
+	}
+	return out
+}
+
+func readAll(t *testing.T, w *WAL) []string {
+	t.Helper()
+	var out []string
+	for {
+		recs, pos, err := w.Read(3)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(recs) == 0 {
+			return out
+		}
+		for _, r := range recs {
+			out = append(out, string(r))
+		}
+		if err = w.Commit(pos); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+}
+
+func expected(from, to int) []string {
+	var out []string
+	for _, r := range records(from, to) {
+		out = append(out, string(r))
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		appended  int
+		committed int // records read and committed before restart
+		read      int // records read but not committed before restart
+		want      []string
+	}{
+		{name: "nothing committed", appended: 10, want: expected(0, 10)},
+		{name: "partly committed", appended: 10, committed: 4, want: expected(4, 10)},
+		{name: "read but not committed is replayed", appended: 10, committed: 3, read: 3, want: expected(3, 10)},
+		{name: "all committed", appended: 10, committed: 10, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Dir: t.TempDir(), SegmentSize: 64}
+			w, err := Open(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range records(0, tt.appended) {
+				if err = w.Append([][]byte{r}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for done := 0; done < tt.committed; {
+				recs, pos, err := w.Read(tt.committed - done)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = w.Commit(pos); err != nil {
+					t.Fatal(err)
+				}
+				done += len(recs)
+			}
+			if tt.read > 0 {
+				if _, _, err = w.Read(tt.read); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			w, err = Open(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			if got := w.Len(); got != len(tt.want) {
+				t.Errorf("Len() = %d, want %d", got, len(tt.want))
+			}
+			if got := readAll(t, w); !equal(got, tt.want) {
+				t.Errorf("records after restart = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncatedSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte // bytes left behind by a write interrupted by a crash
+	}{
+		{name: "partial header", tail: []byte{0, 0, 0}},
+		{name: "partial payload", tail: []byte{0, 0, 0, 20, 1, 2, 3, 4, 'a', 'b'}},
+		{name: "checksum mismatch", tail: []byte{0, 0, 0, 1, 0, 0, 0, 0, 'x'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Dir: t.TempDir(), SegmentSize: 1 << 20}
+			w, err := Open(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Append(records(0, 3)); err != nil {
+				t.Fatal(err)
+			}
+			size := w.Size()
+			_ = w.Close()
+			path := w.segmentPath(0)
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = f.Write(tt.tail)
+			_ = f.Close()
+
+			w, err = Open(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			if got := w.Size(); got != size {
+				t.Errorf("Size() = %d, want %d after cutting incomplete record", got, size)
+			}
+			if err = w.Append(records(3, 4)); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := readAll(t, w), expected(0, 4); !equal(got, want) {
+				t.Errorf("records = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		segmentSize  int64
+		committed    int
+		wantSegments int
+	}{
+		{name: "single segment keeps read offset", segmentSize: 1 << 20, committed: 5, wantSegments: 1},
+		{name: "fully read segments are deleted", segmentSize: 40, committed: 8, wantSegments: 1},
+		{name: "unread segments are kept", segmentSize: 40, committed: 0, wantSegments: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Dir: t.TempDir(), SegmentSize: tt.segmentSize}
+			w, err := Open(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range records(0, 10) {
+				if err = w.Append([][]byte{r}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for done := 0; done < tt.committed; {
+				recs, pos, err := w.Read(tt.committed - done)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = w.Commit(pos); err != nil {
+					t.Fatal(err)
+				}
+				done += len(recs)
+			}
+			_ = w.Close()
+
+			files, _ := filepath.Glob(filepath.Join(opts.Dir, "*"+segmentExt))
+			if len(files) != tt.wantSegments {
+				t.Errorf("segment files = %d, want %d", len(files), tt.wantSegments)
+			}
+			w, err = Open(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			if got, want := readAll(t, w), expected(tt.committed, 10); !equal(got, want) {
+				t.Errorf("records after restart = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    DropPolicy
+		wantErr   error
+		wantFirst string
+	}{
+		{name: "drop oldest", policy: DropOldest, wantFirst: "record-4"},
+		{name: "drop newest", policy: DropNewest, wantErr: ErrFull, wantFirst: "record-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dropped := 0
+			// every record takes 16 bytes, two of them per segment
+			w, err := Open(Options{
+				Dir:         t.TempDir(),
+				SegmentSize: 32,
+				MaxSize:     64,
+				DropPolicy:  tt.policy,
+				OnDrop:      func(records int) { dropped += records },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			var lastErr error
+			for _, r := range records(0, 8) {
+				if err = w.Append([][]byte{r}); err != nil {
+					lastErr = err
+				}
+			}
+			if lastErr != tt.wantErr {
+				t.Errorf("Append() error = %v, want %v", lastErr, tt.wantErr)
+			}
+			if w.Size() > 64 {
+				t.Errorf("Size() = %d exceeds max size", w.Size())
+			}
+			got := readAll(t, w)
+			if len(got) == 0 || got[0] != tt.wantFirst {
+				t.Errorf("records = %v, want first %s", got, tt.wantFirst)
+			}
+			if dropped != 8-len(got) {
+				t.Errorf("dropped = %d, want %d", dropped, 8-len(got))
+			}
+		})
+	}
+}
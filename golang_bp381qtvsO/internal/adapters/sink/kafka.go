@@ -0,0 +1,52 @@
+package sink
+
+import (
+	"context"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+
+	"github.com/c0olix/goChan"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+type kafkaSink struct {
+	name    string
+	channel goChan.ChannelInterface
+}
+
+// NewKafkaSink returns sink that produces log records as JSON messages into goChan channel, record source is used
+// as message key so records of the same source stay in order
+func NewKafkaSink(name string, channel goChan.ChannelInterface) outport.LogSink {
+	return &kafkaSink{
+		name:    name,
+		channel: channel,
+	}
+}
+
+func (s *kafkaSink) Name() string {
+	return s.name
+}
+
+// Write produces records in order and stops at the first failure, records produced before it are not
+// reported as failed, so they are not produced again on retry
+func (s *kafkaSink) Write(ctx context.Context, records []*model.LogRecord) error {
+	for i, rec := range records {
+		data, err := marshalLogRecord(rec)
+		if err != nil {
+			return &outport.PartialWriteError{Failed: records[i:], Err: err}
+		}
+		msg := kafkaGo.Message{
+			Key:   []byte(rec.Source),
+			Value: data,
+		}
+		if err = s.channel.Produce(ctx, msg); err != nil {
+			return &outport.PartialWriteError{Failed: records[i:], Err: fmt.Errorf("error producing log record to kafka: %w", err)}
+		}
+	}
+	return nil
+}
+
+func (s *kafkaSink) Close() {
+	// Nothing to do, channel is owned by goChan manager
+}
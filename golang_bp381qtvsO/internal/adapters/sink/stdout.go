@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"io"
+	"os"
+	"sync"
+)
+
+type streamSink struct {
+	name string
+	mu   sync.Mutex
+	out  io.Writer
+}
+
+// NewStdoutSink returns sink that prints log records to stdout, one JSON document per line
+func NewStdoutSink(name string) outport.LogSink {
+	return &streamSink{
+		name: name,
+		out:  os.Stdout,
+	}
+}
+
+func (s *streamSink) Name() string {
+	return s.name
+}
+
+func (s *streamSink) Write(_ context.Context, records []*model.LogRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := bufio.NewWriter(s.out)
+	for _, rec := range records {
+		data, err := marshalLogRecord(rec)
+		if err != nil {
+			return err
+		}
+		_, _ = w.Write(data)
+		_ = w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+func (s *streamSink) Close() {
+	// Nothing to do
+}
@@ -0,0 +1,193 @@
+package sink
+
+import (
+	"context"
+	"example_consumer/internal/adapters/sink/internal/wal"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/metrics"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWALDir           = "./wal"
+	defaultWALSegmentSizeMB = 64
+	defaultWALMaxSizeMB     = 1024
+	defaultWALRetryInterval = 5 * time.Second
+	walReplayBatchSize      = 500
+)
+
+var (
+	walRecords = metrics.NewGauge("logservice_wal_records",
+		"Number of log records waiting in write-ahead log to be replayed into the sink", "sink")
+	walBytes = metrics.NewGauge("logservice_wal_bytes",
+		"Size of write-ahead log segments on disk", "sink")
+	walSegments = metrics.NewGauge("logservice_wal_segments",
+		"Number of write-ahead log segment files", "sink")
+	walDropped = metrics.NewCounter("logservice_wal_dropped_records_total",
+		"Number of log records dropped because write-ahead log reached its size cap", "sink")
+	sinkHealthy = metrics.NewGauge("logservice_sink_healthy",
+		"Whether the last write to the sink succeeded (1) or failed (0)", "sink")
+)
+
+type walSink struct {
+	sink          outport.LogSink
+	wal           *wal.WAL
+	retryInterval time.Duration
+	stop          chan struct{}
+	stopped       chan struct{}
+}
+
+// NewWALSink decorates sink with a write-ahead log on disk. Once a write to the sink fails all
+// records go to the log, which is replayed in order in background as soon as the sink accepts
+// writes again. Write only fails if the log itself is full (with "newest" drop policy) or broken.
+func NewWALSink(s outport.LogSink, cfg *app.SinkWALConfig) (outport.LogSink, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = defaultWALDir
+	}
+	name := s.Name()
+	w, err := wal.Open(wal.Options{
+		Dir:         filepath.Join(dir, name),
+		SegmentSize: int64(positiveOr(cfg.SegmentSizeMB, defaultWALSegmentSizeMB)) * 1024 * 1024,
+		MaxSize:     int64(positiveOr(cfg.MaxSizeMB, defaultWALMaxSizeMB)) * 1024 * 1024,
+		DropPolicy:  wal.DropPolicy(cfg.DropPolicy),
+		OnDrop: func(records int) {
+			zap.S().Warnf("Write-ahead log of sink %s is full, dropped %d log records", name, records)
+			walDropped.Add(float64(records), name)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening write-ahead log of sink %s: %w", name, err)
+	}
+	ws := &walSink{
+		sink:          s,
+		wal:           w,
+		retryInterval: cfg.RetryInterval,
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	if ws.retryInterval <= 0 {
+		ws.retryInterval = defaultWALRetryInterval
+	}
+	if n := w.Len(); n > 0 {
+		zap.S().Infof("Write-ahead log of sink %s contains %d log records to replay", name, n)
+	}
+	ws.updateMetrics()
+	if w.Len() == 0 {
+		sinkHealthy.Set(1, name)
+	} else {
+		sinkHealthy.Set(0, name)
+	}
+	go ws.run()
+	return ws, nil
+}
+
+func (s *walSink) Name() string {
+	return s.sink.Name()
+}
+
+func (s *walSink) Write(ctx context.Context, records []*model.LogRecord) error {
+	if s.wal.Len() == 0 {
+		err := s.sink.Write(ctx, records)
+		if err == nil {
+			return nil
+		}
+		sinkHealthy.Set(0, s.Name())
+		app.Logger(ctx).Warnf("Write to sink %s failed, buffering log records in write-ahead log: %v", s.Name(), err)
+		records = failedRecords(err, records)
+	}
+	// while there is something to replay new records go to the log as well to keep the order
+	payloads := make([][]byte, len(records))
+	for i, rec := range records {
+		data, err := msgpack.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("error serializing log record: %w", err)
+		}
+		payloads[i] = data
+	}
+	err := s.wal.Append(payloads)
+	s.updateMetrics()
+	return err
+}
+
+// Close stops replay and closes the log and decorated sink, records that were not replayed yet
+// stay on disk until the next start
+func (s *walSink) Close() {
+	close(s.stop)
+	<-s.stopped
+	if err := s.wal.Close(); err != nil {
+		zap.S().Warnf("Failed to close write-ahead log of sink %s: %v", s.Name(), err)
+	}
+	s.sink.Close()
+}
+
+func (s *walSink) run() {
+	defer close(s.stopped)
+	ctx := app.BackgroundContextWithDefaultLogger()
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.replay(ctx)
+		}
+	}
+}
+
+// replay writes records from the log into the sink until the log is empty or the sink fails again
+func (s *walSink) replay(ctx context.Context) {
+	for s.wal.Len() > 0 {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		payloads, pos, err := s.wal.Read(walReplayBatchSize)
+		if err != nil {
+			app.Logger(ctx).Errorf("Reading write-ahead log of sink %s failed: %v", s.Name(), err)
+			s.updateMetrics()
+			continue
+		}
+		if len(payloads) == 0 {
+			return
+		}
+		records := make([]*model.LogRecord, 0, len(payloads))
+		for _, data := range payloads {
+			rec := new(model.LogRecord)
+			if err = msgpack.Unmarshal(data, rec); err != nil {
+				app.Logger(ctx).Errorf("Skipping unreadable log record in write-ahead log of sink %s: %v", s.Name(), err)
+				continue
+			}
+			rec.Timestamp = rec.Timestamp.UTC()
+			records = append(records, rec)
+		}
+		if err = s.sink.Write(ctx, records); err != nil {
+			app.Logger(ctx).Debugf("Sink %s is still unavailable: %v", s.Name(), err)
+			return
+		}
+		if err = s.wal.Commit(pos); err != nil {
+			app.Logger(ctx).Errorf("Committing write-ahead log of sink %s failed: %v", s.Name(), err)
+		}
+		s.updateMetrics()
+		if s.wal.Len() == 0 {
+			sinkHealthy.Set(1, s.Name())
+			app.Logger(ctx).Infof("Write-ahead log of sink %s is replayed, writing directly again", s.Name())
+		}
+	}
+}
+
+func (s *walSink) updateMetrics() {
+	name := s.Name()
+	walRecords.Set(float64(s.wal.Len()), name)
+	walBytes.Set(float64(s.wal.Size()), name)
+	walSegments.Set(float64(s.wal.Segments()), name)
+}
@@ -0,0 +1,77 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const defaultWebhookTimeout = 10 * time.Second
+
+type webhookSink struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink returns sink that posts batches of log records as JSON array to configured URL
+func NewWebhookSink(name string, cfg *app.WebhookSinkConfig) (outport.LogSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook sink %s has no url configured", name)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &webhookSink{
+		name:    name,
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *webhookSink) Name() string {
+	return s.name
+}
+
+func (s *webhookSink) Write(ctx context.Context, records []*model.LogRecord) error {
+	body, err := json.Marshal(lo.Map(records, func(item *model.LogRecord, _ int) *logRecordJson {
+		return logRecordModelToJson(item)
+	}))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting log records to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() {
+	s.client.CloseIdleConnections()
+}
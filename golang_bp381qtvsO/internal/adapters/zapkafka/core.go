@@ -0,0 +1,226 @@
+package zapkafka
+
+import (
+	"context"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/metrics"
+	"sync"
+	"time"
+
+	"github.com/c0olix/goChan"
+	kafkaGo "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultSource        = "logservice"
+	syncTimeout          = 2 * time.Second
+)
+
+var errSyncTimeout = errors.New("shipping own log entries to kafka timed out")
+
+var droppedEntries = metrics.NewCounter("logservice_selflog_dropped_entries_total",
+	"Number of own log entries not shipped to kafka because the buffer was full or producing failed")
+
+// Shipper produces encoded log entries to kafka in the background, entries are dropped instead of blocking
+// the logging goroutine when the buffer is full
+type Shipper struct {
+	channel       goChan.ChannelInterface
+	fallback      *zap.SugaredLogger
+	entries       chan []byte
+	batchSize     int
+	flushInterval time.Duration
+	flush         chan chan struct{}
+	stop          chan struct{}
+	stopped       chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewShipper starts shipping to channel, problems of shipping itself are logged to fallback only
+func NewShipper(channel goChan.ChannelInterface, cfg *app.KafkaLoggingConfig, fallback *zap.SugaredLogger) *Shipper {
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	s := &Shipper{
+		channel:       channel,
+		fallback:      fallback,
+		entries:       make(chan []byte, bufferSize),
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		flush:         make(chan chan struct{}),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultBatchSize
+	}
+	if s.flushInterval <= 0 {
+		s.flushInterval = defaultFlushInterval
+	}
+	go s.run()
+	return s
+}
+
+// NewCore returns core encoding entries of level or above as JSON understood by the log pipeline
+func (s *Shipper) NewCore(level zapcore.LevelEnabler, source string) zapcore.Core {
+	if source == "" {
+		source = defaultSource
+	}
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "message",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     "",
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+	enc.AddString("source", source)
+	return &core{LevelEnabler: level, enc: enc, shipper: s}
+}
+
+func (s *Shipper) offer(data []byte) {
+	select {
+	case s.entries <- data:
+	default:
+		droppedEntries.Inc()
+	}
+}
+
+func (s *Shipper) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	batch := make([][]byte, 0, s.batchSize)
+	for {
+		select {
+		case data := <-s.entries:
+			if batch = append(batch, data); len(batch) >= s.batchSize {
+				batch = s.produce(batch)
+			}
+		case <-ticker.C:
+			batch = s.produce(batch)
+		case done := <-s.flush:
+			batch = s.produceBuffered(batch)
+			close(done)
+		case <-s.stop:
+			for {
+				select {
+				case data := <-s.entries:
+					batch = append(batch, data)
+				default:
+					s.produce(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// produceBuffered produces batch and all entries buffered so far, entries arriving meanwhile are left for
+// the next batch
+func (s *Shipper) produceBuffered(batch [][]byte) [][]byte {
+	for n := len(s.entries); n > 0; n-- {
+		if batch = append(batch, <-s.entries); len(batch) >= s.batchSize {
+			batch = s.produce(batch)
+		}
+	}
+	return s.produce(batch)
+}
+
+// produce writes batch to kafka and returns it emptied
+func (s *Shipper) produce(batch [][]byte) [][]byte {
+	if len(batch) == 0 {
+		return batch
+	}
+	ctx := app.ContextWithLogger(context.Background(), s.fallback)
+	for i, data := range batch {
+		if err := s.channel.Produce(ctx, kafkaGo.Message{Value: data}); err != nil {
+			droppedEntries.Add(float64(len(batch) - i))
+			s.fallback.Warnf("Shipping %d own log entries to kafka failed: %v", len(batch)-i, err)
+			break
+		}
+	}
+	return batch[:0]
+}
+
+// Sync produces entries buffered so far, it gives up after a short timeout so that logging of fatal
+// entries does not hang while kafka is unavailable
+func (s *Shipper) Sync() error {
+	done := make(chan struct{})
+	timer := time.NewTimer(syncTimeout)
+	defer timer.Stop()
+	select {
+	case s.flush <- done:
+	case <-s.stopped:
+		return nil
+	case <-timer.C:
+		return errSyncTimeout
+	}
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return errSyncTimeout
+	}
+}
+
+// Close produces buffered entries, entries logged afterwards are dropped
+func (s *Shipper) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.stopped
+	})
+}
+
+type core struct {
+	zapcore.LevelEnabler
+	enc     zapcore.Encoder
+	shipper *Shipper
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &core{LevelEnabler: c.LevelEnabler, enc: enc, shipper: c.shipper}
+}
+
+func (c *core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	// buffer is reused by zap, entry has to be copied before it is handed over
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	buf.Free()
+	c.shipper.offer(data)
+	// process may exit right after entries above error level, like zap's own cores they are synced at once
+	if entry.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+	return nil
+}
+
+func (c *core) Sync() error {
+	return c.shipper.Sync()
+}
@@ -1,5 +1,7 @@
 package app
 
+import "time"
+
 type DatabaseConfig struct {
 	Password string
 	Host     string
@@ -24,6 +26,32 @@ type Config struct {
 	Server      ServerConfig
 	Database    DatabaseConfig
 	Cache       CacheConfig
+	Kafka       KafkaConfig
+	Sinks       []SinkConfig
+	Topics      []TopicConfig
+	Routes      []RouteConfig
+	Pipeline    PipelineConfig
+	Erasure     ErasureConfig
+	Export      ExportConfig
+	Ingest      IngestConfig
+	Tenants     TenantsConfig
+	Logging     LoggingConfig
+}
+
+// LoggingConfig configures logs of the service itself, they are always written to stderr
+type LoggingConfig struct {
+	Kafka KafkaLoggingConfig
+}
+
+// KafkaLoggingConfig ships own logs as JSON to kafka topic as well, so they can be ingested like any other logs
+type KafkaLoggingConfig struct {
+	Enabled       bool
+	Topic         string
+	Level         string        // minimum level of shipped entries, info by default
+	Source        string        // source of shipped entries, logservice by default
+	BufferSize    int           // entries waiting to be produced, new entries are dropped when full, 10000 by default
+	BatchSize     int           // entries produced at once, 100 by default
+	FlushInterval time.Duration // maximum time entries wait in the buffer, 1s by default
 }
 
 type CredentialsConfig struct {
@@ -35,3 +63,349 @@ type ServerConfig struct {
 	Port int
 	Addr string
 }
+
+type KafkaConfig struct {
+	Brokers string
+	Group   string
+	Offset  string
+	// TopicPattern is a regular expression starting with "^" of the topics consumed for topic "*", the
+	// consumer does not know topic names otherwise
+	TopicPattern string
+	Security     KafkaSecurityConfig
+	// StatsInterval of librdkafka statistics exported as lag and throughput metrics, 0 disables them
+	StatsInterval time.Duration
+}
+
+// KafkaSecurityConfig applies to the confluent consumer and to goChan producers and consumers
+type KafkaSecurityConfig struct {
+	Protocol      string // PLAINTEXT (default), SSL, SASL_PLAINTEXT or SASL_SSL
+	SaslMechanism string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+	Username      string
+	Password      string
+	CaFile        string // PEM file of CA verifying brokers, system roots are used when empty
+	CertFile      string // PEM client certificate and key, only needed when brokers verify clients
+	KeyFile       string
+}
+
+// ErasureConfig configures erasure of customer data when CUSTOMER_DELETION event is consumed
+type ErasureConfig struct {
+	Enabled bool
+	Action  string   // delete (default) | redact
+	Fields  []string // dot separated paths of record fields holding customer number, message is always searched
+}
+
+// ExportConfig configures data subject access exports, records are selected by erasure fields
+type ExportConfig struct {
+	EventTopics []string // topics of customer events, their records are exported as events instead of logs
+}
+
+// TenantsConfig isolates teams sharing the service, records are stored with id of their tenant
+// and queries only return records of the caller's tenant
+type TenantsConfig struct {
+	Enabled     bool
+	Header      string // HTTP header holding API key, X-API-Key by default
+	KafkaHeader string // kafka message header holding tenant id, "tenant" by default
+	DailyQuota  int64  // records a tenant may ingest per day (UTC), 0 is unlimited
+	Tenants     []TenantConfig
+	// AdminAPIKeys are keys of HTTP requests to endpoints covering all tenants (pipeline sampling and routes,
+	// /metrics), tenant keys are rejected there
+	AdminAPIKeys []string
+}
+
+type TenantConfig struct {
+	ID          string
+	APIKeys     []string // keys of HTTP requests of the tenant
+	TopicPrefix string   // kafka topics with this prefix belong to the tenant if message has no tenant header
+	DailyQuota  int64    // overrides default quota if set
+}
+
+// HeaderOr returns configured HTTP header or header if there is none
+func (c *TenantsConfig) HeaderOr(header string) string {
+	if c.Header == "" {
+		return header
+	}
+	return c.Header
+}
+
+// KafkaHeaderOr returns configured kafka header or header if there is none
+func (c *TenantsConfig) KafkaHeaderOr(header string) string {
+	if c.KafkaHeader == "" {
+		return header
+	}
+	return c.KafkaHeader
+}
+
+// IngestConfig configures inputs besides kafka, records of an input are routed by its topic
+type IngestConfig struct {
+	Loki          IngestInputConfig   // Loki compatible push endpoint POST /loki/api/v1/push
+	Elasticsearch ElasticIngestConfig // Elasticsearch compatible bulk endpoints POST /_bulk and /:index/_bulk
+	Otlp          IngestInputConfig   // OTLP/HTTP logs endpoint POST /v1/logs
+	Forward       ListenIngestConfig  // Fluentd forward protocol TCP listener
+	Gelf          GelfIngestConfig    // GELF UDP and TCP listeners
+}
+
+type GelfIngestConfig struct {
+	ListenIngestConfig `mapstructure:",squash"`
+	ChunkTimeout       time.Duration // time to wait for missing chunks of a UDP message, 5s by default
+}
+
+// ListenIngestConfig configures inputs that have their own listener instead of API server routes
+type ListenIngestConfig struct {
+	IngestInputConfig `mapstructure:",squash"`
+	Addr              string // listen address, default port of the protocol on all interfaces if empty
+	Tenant            string // tenant of all records received by the listener, required if tenants are enabled
+}
+
+// AddrOr returns configured listen address or addr if there is none
+func (c *ListenIngestConfig) AddrOr(addr string) string {
+	if c.Addr == "" {
+		return addr
+	}
+	return c.Addr
+}
+
+type ElasticIngestConfig struct {
+	IngestInputConfig `mapstructure:",squash"`
+	Version           string // version reported to shippers by GET /, 8.11.0 by default
+}
+
+// IngestInputConfig is common to all inputs
+type IngestInputConfig struct {
+	Enabled bool
+	Topic   string // topic records are routed by, name of the input by default
+}
+
+// TopicOr returns configured topic or name of the input if there is none
+func (c *IngestInputConfig) TopicOr(name string) string {
+	if c.Topic == "" {
+		return name
+	}
+	return c.Topic
+}
+
+// Topics returns topics of enabled inputs, they must not be consumed from kafka
+func (c *IngestConfig) Topics() []string {
+	var topics []string
+	for name, input := range map[string]*IngestInputConfig{
+		"loki":          &c.Loki,
+		"elasticsearch": &c.Elasticsearch.IngestInputConfig,
+		"otlp":          &c.Otlp,
+		"forward":       &c.Forward.IngestInputConfig,
+		"gelf":          &c.Gelf.IngestInputConfig,
+	} {
+		if input.Enabled {
+			topics = append(topics, input.TopicOr(name))
+		}
+	}
+	return topics
+}
+
+// TopicConfig defines to which sinks records consumed from a topic are written.
+// Topic with name "*" is used for all topics that are not listed explicitly.
+type TopicConfig struct {
+	Name  string
+	Sinks []string
+}
+
+// RouteConfig sends records matching all conditions to sinks, routes are evaluated in order before topics
+type RouteConfig struct {
+	Name     string
+	Topic    string            // topic of records, all topics if empty
+	Source   string            // source of records, all sources if empty or "*"
+	Levels   []string          // levels of records, all levels if empty
+	Match    map[string]string // record attribute (message, source, topic, level or field path) -> regexp
+	Sinks    []string          // names of sinks, empty list drops matching records
+	Continue bool              // copy to sinks and route record further by following routes or its topic
+}
+
+// PipelineConfig configures stages every log record passes before it is written to the sinks
+type PipelineConfig struct {
+	Multiline []MultilineConfig
+	Grok      GrokConfig
+	Sampling  SamplingConfig
+	Redaction []RedactionRuleConfig
+	Dedupe    DedupeConfig
+	Metrics   []MetricRuleConfig
+	Enrich    EnrichConfig
+	Transform []TransformConfig
+}
+
+// TransformConfig drops and rewrites records of a topic by expressions such as
+// `drop if level == "debug" && source == "health"` or `set env = "prod"`, applied in order
+type TransformConfig struct {
+	Topic       string // topic of records, "*" or empty for all topics
+	Expressions []string
+}
+
+// EnrichConfig adds deployment, hostname, static fields and fields joined from lookup tables to records,
+// fields already set in a record are kept
+type EnrichConfig struct {
+	Enabled bool
+	Fields  map[string]string // static fields besides deployment and hostname
+	Lookups []LookupConfig
+}
+
+// LookupConfig joins records with rows of a CSV file or mongo collection
+type LookupConfig struct {
+	Name            string            // unique name, tables are cached by name
+	Key             string            // record attribute (source, topic, level or field path) looked up
+	File            string            // CSV file with header row
+	Collection      string            // mongo collection, used if file is not set
+	KeyColumn       string            // column or document field holding the key
+	Fields          map[string]string // record field -> column, all other columns under their name if empty
+	RefreshInterval time.Duration     // 5m by default
+}
+
+// MetricRuleConfig derives a counter or histogram from records matching all conditions
+type MetricRuleConfig struct {
+	Name      string            // name of the metric, must be unique
+	Help      string            // description shown on /metrics
+	Type      string            // counter (default) or histogram
+	Source    string            // source of records, all sources if empty or "*"
+	Topic     string            // topic of records, all topics if empty
+	Levels    []string          // levels of records, all levels if empty
+	Match     map[string]string // record attribute (message, source, topic, level or field path) -> regexp
+	Field     string            // histogram: attribute holding observed number or duration (in seconds)
+	Buckets   []float64         // histogram: bucket upper bounds, metrics.DefaultBuckets if empty
+	Labels    map[string]string // label name -> record attribute providing its value
+	MaxSeries int               // label combinations kept, further ones are counted with label values "__overflow__", 1000 if 0
+}
+
+// DedupeConfig defines dropping of repeated records, they are counted in one record per window. Fields and ids
+// of dropped repetitions are lost, ids reported to clients of the Elasticsearch bulk API included.
+type DedupeConfig struct {
+	Enabled     bool
+	Window      time.Duration // repetitions of a record with same fingerprint within window are dropped, 10s by default
+	MaxPatterns int           // patterns tracked for statistics, 10000 by default
+}
+
+// SamplingConfig defines rate limits and sampling of noisy sources, rules can be changed at runtime via REST API
+type SamplingConfig struct {
+	SummaryInterval time.Duration // how often a summary record of dropped records is written, 1m by default
+	Rules           []SamplingRuleConfig
+}
+
+// SamplingRuleConfig limits records of a source, records of error and fatal level are never dropped
+type SamplingRuleConfig struct {
+	Source     string   // source name or "*" for all sources without own rule, each source of every tenant gets own bucket
+	Rate       float64  // records per second (token bucket), 0 is unlimited
+	Burst      int      // bucket size, defaults to Rate
+	Levels     []string // levels subject to sampling, debug and info if empty
+	SampleRate float64  // share of records kept (0..1), 0 disables sampling
+	SampleBy   string   // field whose value decides (by hash) whether record is kept, random if empty
+}
+
+// RedactionRuleConfig defines personal data removed from records before they are stored. Without detector
+// or pattern whole values at Fields are replaced, otherwise matches are replaced in Fields, or in message
+// and all fields if no Fields are listed. Rules are applied in order.
+type RedactionRuleConfig struct {
+	Name     string
+	Source   string   // source name, empty or "*" for all sources
+	Fields   []string // dot separated paths into record fields, "message" is the record message
+	Detector string   // email | phone | phone_national | iban, phone_national needs fields
+	Pattern  string   // custom regular expression instead of detector
+	Action   string   // mask (default) | hash, hash is HMAC-SHA256 keyed by credentials secret
+}
+
+// GrokConfig defines grok patterns that extract structured fields out of free text messages
+type GrokConfig struct {
+	Patterns []GrokPatternConfig // custom named patterns, usable as %{NAME} in rules
+	Rules    []GrokRuleConfig
+}
+
+type GrokPatternConfig struct {
+	Name    string
+	Pattern string
+}
+
+// GrokRuleConfig lists patterns tried in order on messages of a source, the first match wins
+type GrokRuleConfig struct {
+	Source   string // source name or "*" for all sources without own rule
+	Patterns []string
+}
+
+// MultilineConfig defines how consecutive records of a source are joined into one record. A record continues
+// the previous one if it matches Continuation or if it does not match Start.
+type MultilineConfig struct {
+	Source       string // source name or "*" for all sources without own rule
+	Preset       string // java | go, provides default patterns
+	Start        string
+	Continuation string
+	FlushTimeout time.Duration // Pending record is passed on after this time without continuation
+	MaxLines     int
+}
+
+type SinkConfig struct {
+	Name    string
+	Type    string // mongo | file | stdout | kafka | webhook
+	Buffer  SinkBufferConfig
+	WAL     SinkWALConfig
+	Mongo   MongoSinkConfig
+	File    FileSinkConfig
+	Kafka   KafkaSinkConfig
+	Webhook WebhookSinkConfig
+}
+
+type SinkBufferConfig struct {
+	Size          int           // Max number of records waiting to be written, newer records are dropped when full
+	BatchSize     int           // Max number of records per write
+	FlushInterval time.Duration // Max time a record waits before it is written
+	MaxRetries    int           // Number of retries of failed write before batch is dropped
+}
+
+// SinkWALConfig configures write-ahead log on disk that keeps records while the sink is unavailable
+type SinkWALConfig struct {
+	Enabled       bool
+	Dir           string        // Base directory, every sink gets own subdirectory
+	SegmentSizeMB int           // Size of one segment file
+	MaxSizeMB     int           // Max size of all segments of the sink
+	DropPolicy    string        // oldest | newest, which records are dropped when MaxSizeMB is reached
+	RetryInterval time.Duration // How often replay into unavailable sink is attempted
+}
+
+type MongoSinkConfig struct {
+	Collection string
+	TimeSeries bool // create collection as time-series collection with "timestamp" time field and "source" meta field
+	Retention  RetentionConfig
+	Archive    ArchiveConfig
+}
+
+// ArchiveConfig enables export of records into compressed NDJSON files (one per day and source)
+// before retention deletes them from the collection
+type ArchiveConfig struct {
+	Enabled     bool
+	Dir         string        // Base directory, every collection gets own subdirectory
+	Compression string        // zstd | gzip
+	Delay       time.Duration // How long after the end of a day it is archived, leaves room for late records
+}
+
+// RetentionConfig defines how long log records are kept. Record gets maxAge of the first rule it matches
+// or Default if it does not match any rule, zero means records are kept forever.
+type RetentionConfig struct {
+	Default       time.Duration
+	PurgeInterval time.Duration // How often records of rules without TTL index are purged
+	Rules         []RetentionRuleConfig
+}
+
+type RetentionRuleConfig struct {
+	Source string
+	Level  string
+	MaxAge time.Duration
+}
+
+type FileSinkConfig struct {
+	Dir       string
+	Name      string
+	MaxSizeMB int
+	MaxFiles  int
+}
+
+type KafkaSinkConfig struct {
+	Topic string
+}
+
+type WebhookSinkConfig struct {
+	URL     string
+	Timeout time.Duration
+	Headers map[string]string
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/spf13/viper"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // This prefix is used for environment variables to override config file values
@@ -15,19 +17,26 @@ const envVarPrefix = "APISERVER"
 const baseDir = "./configs"
 
 func LoadConfig(deployment string) *Config {
-	var cfg *Config = nil
+	cfg, err := ReadConfig(deployment)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load configuration file: %v", err))
+	}
+	return cfg
+}
+
+// ReadConfig reads configuration of deployment, it is used to reload parts of configuration at runtime
+func ReadConfig(deployment string) (*Config, error) {
 	opt := loadOptions{
 		EnvVarPrefix: envVarPrefix,
 		Deployment:   deployment,
 		BaseDir:      baseDir,
 	}
-	cfg = &Config{}
-	err := loadFromYaml(opt, cfg)
-	if err != nil {
-		panic(fmt.Sprintf("failed to load configuration file: %v", err))
+	cfg := &Config{}
+	if err := loadFromYaml(opt, cfg); err != nil {
+		return nil, err
 	}
 	cfg.Deployment = deployment
-	return cfg
+	return cfg, nil
 }
 
 type loadOptions struct {
@@ -61,6 +70,7 @@ func loadFromYaml(opt loadOptions, cfg *Config) error {
 func decoderWithEnvVariablesSupport() func(c *mapstructure.DecoderConfig) {
 	return func(c *mapstructure.DecoderConfig) {
 		c.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+			stringToDurationWithDaysHookFunc,
 			c.DecodeHook,
 			mapstructure.StringToSliceHookFunc(","),
 			replaceEnvVarsHookFunc,
@@ -83,3 +93,33 @@ func replaceEnvVarsHookFunc(
 
 	return replaceEnvVars(data.(string)), nil
 }
+
+// stringToDurationWithDaysHookFunc extends time.ParseDuration with "d" unit for days, which is handy for
+// retention settings, e.g. "90d" or "1d12h"
+func stringToDurationWithDaysHookFunc(
+	f reflect.Type,
+	t reflect.Type,
+	data interface{},
+) (interface{}, error) {
+	if f.Kind() != reflect.String || t != reflect.TypeOf(time.Duration(0)) {
+		return data, nil
+	}
+	value := strings.TrimSpace(data.(string))
+	idx := strings.Index(value, "d")
+	if idx <= 0 {
+		return data, nil
+	}
+	days, err := strconv.Atoi(value[:idx])
+	if err != nil {
+		return data, nil
+	}
+	d := time.Duration(days) * 24 * time.Hour
+	if rest := value[idx+1:]; rest != "" {
+		restDuration, err := time.ParseDuration(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		d += restDuration
+	}
+	return d, nil
+}
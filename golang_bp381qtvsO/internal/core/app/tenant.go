@@ -0,0 +1,16 @@
+package app
+
+import "context"
+
+type tenantContextKey struct{}
+
+// ContextWithTenant marks context of a request or message as belonging to tenant
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// Tenant returns id of the tenant of context, empty string if context belongs to none
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
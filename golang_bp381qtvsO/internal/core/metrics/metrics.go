@@ -0,0 +1,294 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry keeps track of all metrics of the service and renders them in Prometheus text format
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// Default registry is used by package-level constructors and exposed by API server
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]*metric),
+	}
+}
+
+type metric struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64 // upper bounds of histogram buckets, ascending
+	maxSeries  int       // label combinations kept, further ones are counted as OverflowLabelValue, unlimited if 0
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // value of counter and gauge, sum of observations of histogram
+	counts      []uint64 // observations per histogram bucket, not cumulative
+	count       uint64   // number of histogram observations
+}
+
+// Counter is a monotonically increasing value, optionally split by labels
+type Counter struct {
+	m *metric
+}
+
+// Gauge is a value that can go up and down, optionally split by labels
+type Gauge struct {
+	m *metric
+}
+
+// Histogram counts observed values in buckets, optionally split by labels
+type Histogram struct {
+	m *metric
+}
+
+// OverflowLabelValue replaces all label values of series exceeding the series limit of a metric
+const OverflowLabelValue = "__overflow__"
+
+// DefaultBuckets suit durations in seconds of typical requests
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	return Default.NewCounter(name, help, labelNames...)
+}
+
+func NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return Default.NewGauge(name, help, labelNames...)
+}
+
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labelNames...)
+}
+
+func (r *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{m: r.register(name, help, "counter", labelNames)}
+}
+
+func (r *Registry) NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{m: r.register(name, help, "gauge", labelNames)}
+}
+
+// NewHistogram registers histogram with buckets (DefaultBuckets if empty), +Inf bucket is added implicitly
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	m := r.register(name, help, "histogram", labelNames)
+	m.buckets = buckets
+	return &Histogram{m: m}
+}
+
+func (r *Registry) register(name string, help string, kind string, labelNames []string) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		panic(fmt.Sprintf("metric with name=%s was already registered", name))
+	}
+	m := &metric{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// LimitSeries limits the number of label combinations of the counter to n
+func (c *Counter) LimitSeries(n int) *Counter {
+	c.m.maxSeries = n
+	return c
+}
+
+// LimitSeries limits the number of label combinations of the histogram to n
+func (h *Histogram) LimitSeries(n int) *Histogram {
+	h.m.maxSeries = n
+	return h
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("counter cannot decrease")
+	}
+	c.m.update(labelValues, func(s *series) { s.value += v })
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.m.update(labelValues, func(s *series) { s.value = v })
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.m.update(labelValues, func(s *series) { s.value += v })
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.m.update(labelValues, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.m.buckets))
+		}
+		// values above the highest bucket are only counted by +Inf
+		if i := sort.SearchFloat64s(h.m.buckets, v); i < len(s.counts) {
+			s.counts[i]++
+		}
+		s.count++
+		s.value += v
+	})
+}
+
+func (m *metric) update(labelValues []string, fn func(s *series)) {
+	if len(labelValues) != len(m.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", m.name, len(m.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.series[key]
+	if !ok && m.maxSeries > 0 && len(m.series) >= m.maxSeries {
+		labelValues = make([]string, len(m.labelNames))
+		for i := range labelValues {
+			labelValues[i] = OverflowLabelValue
+		}
+		key = strings.Join(labelValues, "\xff")
+		s, ok = m.series[key]
+	}
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		m.series[key] = s
+	}
+	fn(s)
+}
+
+// WriteText renders all metrics in Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	all := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		all = append(all, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+
+	var sb strings.Builder
+	for _, m := range all {
+		m.writeText(&sb)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (m *metric) writeText(sb *strings.Builder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(sb, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(sb, "# TYPE %s %s\n", m.name, m.kind)
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind == "histogram" {
+			m.writeHistogram(sb, s)
+			continue
+		}
+		sb.WriteString(m.name)
+		writeLabels(sb, m.labelNames, s.labelValues)
+		sb.WriteByte(' ')
+		sb.WriteString(formatValue(s.value))
+		sb.WriteByte('\n')
+	}
+}
+
+// writeHistogram renders cumulative buckets, sum and count of series
+func (m *metric) writeHistogram(sb *strings.Builder, s *series) {
+	names := append(append([]string(nil), m.labelNames...), "le")
+	values := append(append([]string(nil), s.labelValues...), "")
+	var cumulative uint64
+	for i, bound := range m.buckets {
+		if s.counts != nil {
+			cumulative += s.counts[i]
+		}
+		values[len(values)-1] = formatValue(bound)
+		sb.WriteString(m.name + "_bucket")
+		writeLabels(sb, names, values)
+		fmt.Fprintf(sb, " %d\n", cumulative)
+	}
+	values[len(values)-1] = "+Inf"
+	sb.WriteString(m.name + "_bucket")
+	writeLabels(sb, names, values)
+	fmt.Fprintf(sb, " %d\n", s.count)
+	sb.WriteString(m.name + "_sum")
+	writeLabels(sb, m.labelNames, s.labelValues)
+	sb.WriteString(" " + formatValue(s.value) + "\n")
+	sb.WriteString(m.name + "_count")
+	writeLabels(sb, m.labelNames, s.labelValues)
+	fmt.Fprintf(sb, " %d\n", s.count)
+}
+
+func writeLabels(sb *strings.Builder, names []string, values []string) {
+	if len(names) == 0 {
+		return
+	}
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(values[i]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(r *Registry)
+		want   string
+	}{
+		{
+			name: "counter with labels",
+			record: func(r *Registry) {
+				c := r.NewCounter("requests_total", "Handled requests.", "method", "code")
+				c.Inc("GET", "200")
+				c.Add(2, "GET", "200")
+				c.Inc("POST", "500")
+			},
+			want: `# HELP requests_total Handled requests.
+# TYPE requests_total counter
+requests_total{method="GET",code="200"} 3
+requests_total{method="POST",code="500"} 1
+`,
+		},
+		{
+			name: "gauge with escaped help and label value",
+			record: func(r *Registry) {
+				r.NewGauge("queue_length", "Queued\nrecords \\ topic.", "topic").Set(0.5, `a"b`)
+			},
+			want: `# HELP queue_length Queued\nrecords \\ topic.
+# TYPE queue_length gauge
+queue_length{topic="a\"b"} 0.5
+`,
+		},
+		{
+			name: "histogram",
+			record: func(r *Registry) {
+				h := r.NewHistogram("duration_seconds", "Durations.", []float64{1, 0.1}, "stage")
+				for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+					h.Observe(v, "grok")
+				}
+			},
+			want: `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{stage="grok",le="0.1"} 2
+duration_seconds_bucket{stage="grok",le="1"} 3
+duration_seconds_bucket{stage="grok",le="+Inf"} 4
+duration_seconds_sum{stage="grok"} 3.65
+duration_seconds_count{stage="grok"} 4
+`,
+		},
+		{
+			name: "histogram without labels",
+			record: func(r *Registry) {
+				r.NewHistogram("size_bytes", "Sizes.", []float64{10}).Observe(20)
+			},
+			want: `# HELP size_bytes Sizes.
+# TYPE size_bytes histogram
+size_bytes_bucket{le="10"} 0
+size_bytes_bucket{le="+Inf"} 1
+size_bytes_sum 20
+size_bytes_count 1
+`,
+		},
+		{
+			name: "series limit",
+			record: func(r *Registry) {
+				c := r.NewCounter("records_total", "Records.", "source").LimitSeries(2)
+				for _, source := range []string{"a", "b", "c", "a", "d"} {
+					c.Inc(source)
+				}
+			},
+			want: `# HELP records_total Records.
+# TYPE records_total counter
+records_total{source="__overflow__"} 2
+records_total{source="a"} 2
+records_total{source="b"} 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tt.record(r)
+			var sb strings.Builder
+			if err := r.WriteText(&sb); err != nil {
+				t.Fatal(err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("WriteText() =\n%s\nwant\n%s", sb.String(), tt.want)
+			}
+		})
+	}
+}
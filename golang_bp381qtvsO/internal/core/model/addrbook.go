@@ -9,10 +9,11 @@ const (
 )
 
 type Contact struct {
-	ID        string
-	FirstName string
-	LastName  string
-	Phones    []*ContactPhone
+	ID             string
+	CustomerNumber string // optional, links contact to customer of CUSTOMER_* events
+	FirstName      string
+	LastName       string
+	Phones         []*ContactPhone
 }
 
 type ContactPhone struct {
@@ -21,9 +22,10 @@ type ContactPhone struct {
 }
 
 type ContactToSave struct {
-	FirstName string
-	LastName  string
-	Phones    []*ContactPhoneToSave
+	CustomerNumber string
+	FirstName      string
+	LastName       string
+	Phones         []*ContactPhoneToSave
 }
 
 type ContactPhoneToSave struct {
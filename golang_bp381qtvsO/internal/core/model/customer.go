@@ -0,0 +1,10 @@
+package model
+
+// CustomerLogQuery selects stored log records mentioning a customer number
+type CustomerLogQuery struct {
+	CustomerNumber string
+	Tenant         string   // restricts records to the tenant, records of all tenants if empty
+	Fields         []string // dot separated paths of record fields holding customer number, message is always searched
+	Topics         []string // restricts records to these topics, unless ExcludeTopics is set
+	ExcludeTopics  bool     // selects records of all topics except Topics
+}
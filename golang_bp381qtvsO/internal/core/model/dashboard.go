@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// SavedSearch is a named log query shared between users, its time range is resolved when it is run
+type SavedSearch struct {
+	ID          string
+	Tenant      string // only the tenant can see and change the search, empty if tenants are disabled
+	Name        string
+	Description string
+	Query       LogQuery // From and To are taken from TimeRange
+	TimeRange   TimeRange
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type PanelVisualization string
+
+const (
+	PanelVisualizationLogs       PanelVisualization = "logs"       // matching records
+	PanelVisualizationCount      PanelVisualization = "count"      // number of matching records
+	PanelVisualizationBar        PanelVisualization = "bar"        // number of records per group
+	PanelVisualizationTimeSeries PanelVisualization = "timeseries" // number of records per time bucket (and group)
+)
+
+// Dashboard is an ordered list of panels
+type Dashboard struct {
+	ID          string
+	Tenant      string // only the tenant can see and change the dashboard, empty if tenants are disabled
+	Name        string
+	Description string
+	Panels      []*DashboardPanel
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type DashboardPanel struct {
+	Title         string
+	Visualization PanelVisualization
+	Query         LogQuery // From and To are taken from TimeRange
+	TimeRange     TimeRange
+	GroupBy       string        // required by bar, optional for timeseries
+	Interval      time.Duration // timeseries only, derived from time range if zero
+}
+
+// PanelResult holds records or buckets of one panel depending on its visualization
+type PanelResult struct {
+	Title         string
+	Visualization PanelVisualization
+	From          time.Time
+	To            time.Time
+	Records       []*LogRecord
+	Buckets       []*LogBucket
+	Error         string // panel failed, results of other panels are still returned
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+type ErasureStatus string
+
+const (
+	ErasureStatusRunning   ErasureStatus = "RUNNING"
+	ErasureStatusCompleted ErasureStatus = "COMPLETED"
+	ErasureStatusPartial   ErasureStatus = "PARTIAL" // completed, but copies listed as not covered were not erased
+	ErasureStatusFailed    ErasureStatus = "FAILED"
+)
+
+type ErasureAction string
+
+const (
+	ErasureActionDelete ErasureAction = "delete" // matching log records are deleted
+	ErasureActionRedact ErasureAction = "redact" // customer number is masked in matching log records
+)
+
+// Erasure is status of erasing customer data, once completed it serves as erasure certificate
+type Erasure struct {
+	CustomerNumber  string
+	Tenant          string // only logs of the tenant were erased, logs of all tenants if empty
+	Status          ErasureStatus
+	Action          ErasureAction
+	RequestedAt     time.Time
+	CompletedAt     *time.Time
+	ContactsDeleted int
+	Logs            []*ErasureLogResult
+	NotCovered      []string // copies of log records erasure could not change, e.g. file and kafka sinks
+	Error           string
+}
+
+// ErasureLogResult counts log records erased in one log store
+type ErasureLogResult struct {
+	Store   string
+	Matched int64
+	Erased  int64
+}
@@ -0,0 +1,8 @@
+package model
+
+// GrokMatch is result of applying grok pattern on single sample line
+type GrokMatch struct {
+	Line    string
+	Matched bool
+	Fields  map[string]any
+}
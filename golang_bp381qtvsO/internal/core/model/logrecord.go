@@ -0,0 +1,61 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+type LogLevel string
+
+const (
+	LogLevelDebug LogLevel = "debug"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+	LogLevelFatal LogLevel = "fatal"
+)
+
+type LogRecord struct {
+	ID        string
+	Timestamp time.Time
+	Topic     string
+	Tenant    string // empty if tenants are disabled or record belongs to none
+	Source    string
+	Level     LogLevel
+	Message   string
+	Fields    map[string]any
+	Raw       bool // message is a plain text payload line, not taken from JSON or another structured format
+}
+
+// ParseLogLevel maps the different spellings used by logging libraries (WARNING, err, E, ...) onto LogLevel.
+// Unknown or empty values are treated as info.
+func ParseLogLevel(level string) LogLevel {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace", "debug", "dbg", "d", "verbose":
+		return LogLevelDebug
+	case "warn", "warning", "w":
+		return LogLevelWarn
+	case "error", "err", "e", "severe":
+		return LogLevelError
+	case "fatal", "panic", "dpanic", "critical", "crit", "emerg", "alert":
+		return LogLevelFatal
+	default:
+		return LogLevelInfo
+	}
+}
+
+// Rank orders levels by severity, debug is lowest
+func (l LogLevel) Rank() int {
+	switch l {
+	case LogLevelDebug:
+		return 0
+	case LogLevelWarn:
+		return 2
+	case LogLevelError:
+		return 3
+	case LogLevelFatal:
+		return 4
+	default:
+		return 1
+	}
+}
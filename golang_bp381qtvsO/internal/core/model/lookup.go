@@ -0,0 +1,4 @@
+package model
+
+// LookupTable maps key onto row of column values
+type LookupTable map[string]map[string]string
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// LogPattern counts records of a source whose messages differ only in numbers and ids
+type LogPattern struct {
+	Fingerprint string
+	Tenant      string
+	Source      string
+	Pattern     string // message with numbers and ids replaced by placeholders
+	Count       int64
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
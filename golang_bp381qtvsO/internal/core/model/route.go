@@ -0,0 +1,12 @@
+package model
+
+// Route sends matching records to its sinks instead of the sinks configured for their topic
+type Route struct {
+	Name     string
+	Topic    string            // topic of records, all topics if empty
+	Source   string            // source of records, all sources if empty or "*"
+	Levels   []LogLevel        // levels of records, all levels if empty
+	Match    map[string]string // record attribute (message, source, topic, level or field path) -> regexp
+	Sinks    []string          // names of sinks, empty list drops matching records
+	Continue bool              // copy to sinks and route record further by following routes or its topic
+}
@@ -0,0 +1,11 @@
+package model
+
+// SamplingRule limits records of a source, records of error and fatal level are never dropped
+type SamplingRule struct {
+	Source     string     // source name or "*" for all sources without own rule, each source of every tenant gets own bucket
+	Rate       float64    // records per second (token bucket), 0 is unlimited
+	Burst      int        // bucket size, defaults to Rate
+	Levels     []LogLevel // levels subject to sampling, debug and info if empty
+	SampleRate float64    // share of records kept (0..1), 0 disables sampling
+	SampleBy   string     // field whose value decides (by hash) whether record is kept, random if empty
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// LogQuery selects stored log records, all conditions have to match
+type LogQuery struct {
+	Store   string            // name of the log store, first store if empty
+	Text    string            // case-insensitive text contained in the message
+	Sources []string          // any of the sources
+	Topics  []string          // any of the topics
+	Tenant  string            // records of this tenant only, set from context of the query
+	Levels  []LogLevel        // any of the levels
+	Fields  map[string]string // dot separated paths of record fields and values they must be equal to
+	From    time.Time         // inclusive, unbounded if zero
+	To      time.Time         // exclusive, unbounded if zero
+	Limit   int               // maximum number of records or buckets
+}
+
+// TimeRange is either relative to the time it is resolved at (Last) or absolute (From, To)
+type TimeRange struct {
+	Last time.Duration
+	From time.Time
+	To   time.Time
+}
+
+// Resolve returns absolute bounds of the range
+func (r TimeRange) Resolve(now time.Time) (from time.Time, to time.Time) {
+	if r.Last > 0 {
+		return now.Add(-r.Last), now
+	}
+	return r.From, r.To
+}
+
+// LogAggregation counts records matching query, grouped by a record attribute and/or time buckets
+type LogAggregation struct {
+	Query    LogQuery
+	GroupBy  string        // source, topic, level or dot separated path of a record field, no grouping if empty
+	Interval time.Duration // width of time buckets, no time buckets if zero
+}
+
+// LogBucket is the number of records of one group and time bucket of an aggregation
+type LogBucket struct {
+	Key   string    // value of the group by attribute, empty without grouping
+	Time  time.Time // start of the time bucket, zero without interval
+	Count int64
+}
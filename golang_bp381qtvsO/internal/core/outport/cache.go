@@ -13,6 +13,9 @@ type Cache interface {
 	Set(ctx context.Context, key CacheKey, value any)
 	Get(ctx context.Context, key CacheKey, value any) bool
 	Del(ctx context.Context, key CacheKey)
+	// Incr adds delta to counter and returns its new value, counter expires Ttl after it was created.
+	// False is returned if the cache cannot keep counters.
+	Incr(ctx context.Context, key CacheKey, delta int64) (int64, bool)
 }
 
 type CachePartition struct {
@@ -0,0 +1,25 @@
+package outport
+
+import (
+	"context"
+	"example_consumer/internal/core/model"
+)
+
+// SavedSearches only finds searches of tenant, searches of all tenants if tenant is empty. Updated search
+// keeps its tenant.
+type SavedSearches interface {
+	LoadAllSavedSearches(ctx context.Context, tenant string) ([]*model.SavedSearch, error)
+	LoadSavedSearchByID(ctx context.Context, tenant string, ID string) (*model.SavedSearch, error)
+	AddSavedSearch(ctx context.Context, s *model.SavedSearch) (*model.SavedSearch, error)
+	UpdateSavedSearch(ctx context.Context, ID string, s *model.SavedSearch) (*model.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, tenant string, ID string) (found bool, err error)
+}
+
+// Dashboards only finds dashboards of tenant like SavedSearches
+type Dashboards interface {
+	LoadAllDashboards(ctx context.Context, tenant string) ([]*model.Dashboard, error)
+	LoadDashboardByID(ctx context.Context, tenant string, ID string) (*model.Dashboard, error)
+	AddDashboard(ctx context.Context, d *model.Dashboard) (*model.Dashboard, error)
+	UpdateDashboard(ctx context.Context, ID string, d *model.Dashboard) (*model.Dashboard, error)
+	DeleteDashboard(ctx context.Context, tenant string, ID string) (found bool, err error)
+}
@@ -0,0 +1,12 @@
+package outport
+
+import (
+	"context"
+	"example_consumer/internal/core/model"
+)
+
+// Erasures keeps status and certificates of customer data erasures
+type Erasures interface {
+	SaveErasure(ctx context.Context, e *model.Erasure) error
+	LoadErasure(ctx context.Context, tenant string, customerNumber string) (*model.Erasure, error)
+}
@@ -0,0 +1,28 @@
+package outport
+
+import (
+	"context"
+	"example_consumer/internal/core/model"
+	"fmt"
+)
+
+// LogSink declares a destination for consumed log records (database collection, files, another topic, etc.)
+type LogSink interface {
+	Name() string
+	Write(ctx context.Context, records []*model.LogRecord) error
+	Close()
+}
+
+// PartialWriteError is returned by sinks that wrote some of the records, only Failed records need to be written again
+type PartialWriteError struct {
+	Failed []*model.LogRecord
+	Err    error
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("%d log records were not written: %v", len(e.Failed), e.Err)
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,23 @@
+package outport
+
+import (
+	"context"
+	"example_consumer/internal/core/model"
+)
+
+// LogStore is implemented by log sinks whose records can be queried and changed after they were written
+type LogStore interface {
+	Name() string
+	// EraseCustomerLogs deletes or redacts records matching query
+	EraseCustomerLogs(
+		ctx context.Context,
+		q *model.CustomerLogQuery,
+		action model.ErasureAction,
+	) (matched int64, erased int64, err error)
+	// StreamCustomerLogs calls fn for every record matching query in timestamp order
+	StreamCustomerLogs(ctx context.Context, q *model.CustomerLogQuery, fn func(rec *model.LogRecord) error) error
+	// SearchLogs returns newest records matching query
+	SearchLogs(ctx context.Context, q *model.LogQuery) ([]*model.LogRecord, error)
+	// AggregateLogs counts matching records per group and time bucket, buckets are ordered by time and count
+	AggregateLogs(ctx context.Context, a *model.LogAggregation) ([]*model.LogBucket, error)
+}
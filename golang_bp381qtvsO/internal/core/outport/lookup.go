@@ -0,0 +1,11 @@
+package outport
+
+import (
+	"context"
+	"example_consumer/internal/core/model"
+)
+
+// LookupSource loads all rows of a lookup table
+type LookupSource interface {
+	LoadLookupTable(ctx context.Context) (model.LookupTable, error)
+}
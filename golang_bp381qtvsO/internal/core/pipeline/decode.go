@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	timestampKeys = []string{"timestamp", "@timestamp", "time", "ts"}
+	levelKeys     = []string{"level", "severity", "lvl"}
+	messageKeys   = []string{"message", "msg"}
+	sourceKeys    = []string{"source", "service", "app"}
+)
+
+// DecodeRecord builds log record from raw payload. JSON objects are split into well-known attributes
+// (timestamp, level, message, source) and remaining fields, anything else is stored as plain message.
+// topic, source and ts are used when payload does not specify its own values.
+func DecodeRecord(topic string, source string, ts time.Time, payload []byte) *model.LogRecord {
+	rec := &model.LogRecord{
+		Timestamp: ts,
+		Topic:     topic,
+		Source:    source,
+		Level:     model.LogLevelInfo,
+	}
+	ApplyPayload(rec, payload)
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+	if rec.Source == "" {
+		rec.Source = topic
+	}
+	return rec
+}
+
+// ApplyPayload sets message of record from payload and marks record as raw, JSON objects are applied as
+// fields instead
+func ApplyPayload(rec *model.LogRecord, payload []byte) {
+	var fields map[string]any
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		rec.Message = strings.TrimRight(string(payload), "\r\n")
+		rec.Raw = true
+	} else {
+		ApplyFields(rec, fields)
+	}
+}
+
+// ApplyFields takes well-known attributes out of fields into record and keeps the rest as record fields
+func ApplyFields(rec *model.LogRecord, fields map[string]any) {
+	if v, ok := takeString(fields, messageKeys); ok {
+		rec.Message = v
+	}
+	if v, ok := takeString(fields, levelKeys); ok {
+		rec.Level = model.ParseLogLevel(v)
+	}
+	if v, ok := takeString(fields, sourceKeys); ok && v != "" {
+		rec.Source = v
+	}
+	for _, key := range timestampKeys {
+		if v, ok := fields[key]; ok {
+			if ts, ok := parseTimestamp(v); ok {
+				rec.Timestamp = ts
+				delete(fields, key)
+				break
+			}
+		}
+	}
+	if len(fields) > 0 {
+		if rec.Fields == nil {
+			rec.Fields = fields
+		} else {
+			for k, v := range fields {
+				rec.Fields[k] = v
+			}
+		}
+	}
+}
+
+func takeString(fields map[string]any, keys []string) (string, bool) {
+	for _, key := range keys {
+		if v, ok := fields[key]; ok {
+			delete(fields, key)
+			if s, ok := v.(string); ok {
+				return s, true
+			}
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+// timestampLayouts are tried in order on textual timestamps, layouts without zone are taken as UTC
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parseTimestamp accepts strings of timestampLayouts and unix epoch numbers in seconds, milliseconds or
+// nanoseconds
+func parseTimestamp(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		for _, layout := range timestampLayouts {
+			if ts, err := time.Parse(layout, strings.Replace(t, ",", ".", 1)); err == nil {
+				return ts.UTC(), true
+			}
+		}
+	case int64:
+		return parseTimestamp(float64(t))
+	case float64:
+		switch {
+		case t > 1e17:
+			return time.Unix(0, int64(t)).UTC(), true
+		case t > 1e11:
+			return time.UnixMilli(int64(t)).UTC(), true
+		case t > 0:
+			sec := int64(t)
+			return time.Unix(sec, int64((t-float64(sec))*1e9)).UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
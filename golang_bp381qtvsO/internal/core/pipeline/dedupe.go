@@ -0,0 +1,244 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDedupeWindow      = 10 * time.Second
+	defaultDedupeMaxPatterns = 10000
+
+	// FingerprintField holds fingerprint of record message
+	FingerprintField = "fingerprint"
+	// DedupeField holds repeats, firstSeen, lastSeen and first (id) of records dropped as repetitions
+	DedupeField = "dedupe"
+)
+
+var (
+	uuidRe   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexIdRe  = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8,}\b`)
+	numberRe = regexp.MustCompile(`[-+]?\d+(?:\.\d+)?`)
+	digitsRe = regexp.MustCompile(`^\d+$`)
+)
+
+// MessagePattern returns message with UUIDs, hex ids (0x prefixed or at least 8 hex digits) and numbers
+// replaced by placeholders
+func MessagePattern(message string) string {
+	message = uuidRe.ReplaceAllString(message, "<uuid>")
+	message = hexIdRe.ReplaceAllStringFunc(message, func(id string) string {
+		if digitsRe.MatchString(id) {
+			return id // left for number placeholder
+		}
+		return "<hex>"
+	})
+	return numberRe.ReplaceAllString(message, "<num>")
+}
+
+type dedupeGroup struct {
+	first    model.LogRecord // first record of the group without fields, it was passed on already
+	repeats  int
+	lastSeen time.Time
+	arrived  time.Time
+}
+
+// DedupeStage passes on the first of the records of a source with same level and message pattern arriving within
+// window and drops the repetitions. When the window ends, a record with field dedupe holding number of repeats,
+// firstSeen, lastSeen and id of the first record is passed on instead of them. Fields and ids of the repetitions
+// are not kept. The stage keeps statistics of the most frequent patterns as well.
+type DedupeStage struct {
+	window      time.Duration
+	maxPatterns int
+
+	mu       sync.Mutex
+	pending  map[string]*dedupeGroup
+	patterns map[string]*model.LogPattern
+	next     Handler
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func NewDedupeStage(cfg *app.DedupeConfig) *DedupeStage {
+	s := &DedupeStage{
+		window:      cfg.Window,
+		maxPatterns: cfg.MaxPatterns,
+		pending:     make(map[string]*dedupeGroup),
+		patterns:    make(map[string]*model.LogPattern),
+		stop:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	if s.window <= 0 {
+		s.window = defaultDedupeWindow
+	}
+	if s.maxPatterns <= 0 {
+		s.maxPatterns = defaultDedupeMaxPatterns
+	}
+	return s
+}
+
+func (s *DedupeStage) Wrap(next Handler) Handler {
+	s.next = next
+	go s.flushLoop()
+	return s.handle
+}
+
+func (s *DedupeStage) handle(ctx context.Context, rec *model.LogRecord) {
+	pattern := MessagePattern(rec.Message)
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(pattern))
+	fingerprint := fmt.Sprintf("%016x", h.Sum64())
+	if rec.Fields == nil {
+		rec.Fields = make(map[string]any)
+	}
+	rec.Fields[FingerprintField] = fingerprint
+
+	key := rec.Tenant + "\x00" + rec.Topic + "\x00" + rec.Source + "\x00" + string(rec.Level) + "\x00" + fingerprint
+	s.mu.Lock()
+	s.countPattern(fingerprint, rec, pattern)
+	if g, ok := s.pending[key]; ok {
+		g.repeats++
+		if rec.Timestamp.After(g.lastSeen) {
+			g.lastSeen = rec.Timestamp
+		}
+		s.mu.Unlock()
+		return
+	}
+	if rec.ID == "" {
+		// repeats record refers to the first one
+		rec.ID = NewRecordID()
+	}
+	g := &dedupeGroup{first: *rec, lastSeen: rec.Timestamp, arrived: time.Now()}
+	g.first.Fields = map[string]any{FingerprintField: fingerprint}
+	s.pending[key] = g
+	s.mu.Unlock()
+	s.next(ctx, rec)
+}
+
+func (s *DedupeStage) countPattern(fingerprint string, rec *model.LogRecord, pattern string) {
+	key := rec.Tenant + "\x00" + rec.Source + "\x00" + fingerprint
+	p, ok := s.patterns[key]
+	if !ok {
+		if len(s.patterns) >= s.maxPatterns {
+			s.evictPatterns()
+		}
+		p = &model.LogPattern{
+			Fingerprint: fingerprint,
+			Tenant:      rec.Tenant,
+			Source:      rec.Source,
+			Pattern:     pattern,
+			FirstSeen:   rec.Timestamp,
+		}
+		s.patterns[key] = p
+	}
+	p.Count++
+	if rec.Timestamp.After(p.LastSeen) {
+		p.LastSeen = rec.Timestamp
+	}
+}
+
+// evictPatterns removes the less frequent half of patterns, the rest keeps counting
+func (s *DedupeStage) evictPatterns() {
+	all := make([]string, 0, len(s.patterns))
+	for key := range s.patterns {
+		all = append(all, key)
+	}
+	sort.Slice(all, func(i, j int) bool { return s.patterns[all[i]].Count < s.patterns[all[j]].Count })
+	for _, key := range all[:len(all)/2+1] {
+		delete(s.patterns, key)
+	}
+}
+
+// Patterns returns the most frequent patterns, of all tenants if tenant is empty and of all sources if source is
+// empty
+func (s *DedupeStage) Patterns(tenant string, source string, limit int) []*model.LogPattern {
+	s.mu.Lock()
+	patterns := make([]*model.LogPattern, 0, len(s.patterns))
+	for _, p := range s.patterns {
+		if (tenant == "" || p.Tenant == tenant) && (source == "" || p.Source == source) {
+			c := *p
+			patterns = append(patterns, &c)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
+		}
+		return patterns[i].Fingerprint < patterns[j].Fingerprint
+	})
+	if limit > 0 && len(patterns) > limit {
+		patterns = patterns[:limit]
+	}
+	return patterns
+}
+
+// Close passes on repeats records of all pending groups
+func (s *DedupeStage) Close() {
+	if s.next == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.flush(func(*dedupeGroup) bool { return true })
+}
+
+func (s *DedupeStage) flushLoop() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.flush(func(g *dedupeGroup) bool {
+				return now.Sub(g.arrived) >= s.window
+			})
+		}
+	}
+}
+
+func (s *DedupeStage) flush(expired func(g *dedupeGroup) bool) {
+	var ready []*model.LogRecord
+	s.mu.Lock()
+	for key, g := range s.pending {
+		if expired(g) {
+			if g.repeats > 0 {
+				ready = append(ready, g.repeatsRecord())
+			}
+			delete(s.pending, key)
+		}
+	}
+	s.mu.Unlock()
+	if len(ready) == 0 {
+		return
+	}
+	ctx := app.BackgroundContextWithDefaultLogger()
+	for _, r := range ready {
+		s.next(ctx, r)
+	}
+}
+
+// repeatsRecord returns record like the first one of the group with number of repeats and their time range
+func (g *dedupeGroup) repeatsRecord() *model.LogRecord {
+	rec := g.first
+	rec.ID = ""
+	rec.Timestamp = g.lastSeen
+	rec.Fields = map[string]any{
+		FingerprintField: g.first.Fields[FingerprintField],
+		DedupeField: map[string]any{
+			"repeats":   g.repeats,
+			"firstSeen": g.first.Timestamp,
+			"lastSeen":  g.lastSeen,
+			"first":     g.first.ID,
+		},
+	}
+	return &rec
+}
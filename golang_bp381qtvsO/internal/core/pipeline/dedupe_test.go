@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"testing"
+	"time"
+)
+
+func TestMessagePattern(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"request 42 took 3.5ms", "request <num> took <num>ms"},
+		{"user 3f2b8c1e-9a4d-4e2f-8b1a-0c9d8e7f6a5b logged in", "user <uuid> logged in"},
+		{"pointer 0x1f at deadbeef01", "pointer <hex> at <hex>"},
+		{"order 12345678 failed", "order <num> failed"},
+	}
+	for _, tt := range tests {
+		if got := MessagePattern(tt.message); got != tt.want {
+			t.Errorf("MessagePattern(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeStage(t *testing.T) {
+	s := NewDedupeStage(&app.DedupeConfig{Window: time.Hour})
+	out := &recordCollector{}
+	handle := s.Wrap(out.handle)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rec := func(level model.LogLevel, message string, second int) *model.LogRecord {
+		return &model.LogRecord{
+			Timestamp: start.Add(time.Duration(second) * time.Second),
+			Source:    "billing",
+			Level:     level,
+			Message:   message,
+			Fields:    map[string]any{"traceId": message},
+		}
+	}
+	handle(ctx, rec(model.LogLevelInfo, "request 1 took 3ms", 0))
+	handle(ctx, rec(model.LogLevelInfo, "request 2 took 5ms", 1))
+	handle(ctx, rec(model.LogLevelWarn, "request 3 took 9ms", 2))
+	handle(ctx, rec(model.LogLevelInfo, "request 4 took 4ms", 3))
+
+	if len(out.records) != 2 {
+		t.Fatalf("%d records passed on before window ended, want first of each level", len(out.records))
+	}
+	first := out.records[0]
+	if first.Message != "request 1 took 3ms" || first.ID == "" || first.Fields["traceId"] != "request 1 took 3ms" {
+		t.Errorf("first record changed: %+v", first)
+	}
+
+	s.Close()
+	if len(out.records) != 3 {
+		t.Fatalf("%d records after close, want one repeats record more", len(out.records))
+	}
+	repeats := out.records[2]
+	dedupe, ok := repeats.Fields[DedupeField].(map[string]any)
+	if !ok {
+		t.Fatalf("repeats record without dedupe field: %+v", repeats)
+	}
+	if dedupe["repeats"] != 2 || dedupe["first"] != first.ID || !dedupe["firstSeen"].(time.Time).Equal(start) ||
+		!dedupe["lastSeen"].(time.Time).Equal(start.Add(3*time.Second)) {
+		t.Errorf("dedupe field = %v", dedupe)
+	}
+	if repeats.Message != first.Message || repeats.Level != model.LogLevelInfo || repeats.ID == first.ID ||
+		repeats.Fields[FingerprintField] != first.Fields[FingerprintField] {
+		t.Errorf("repeats record = %+v", repeats)
+	}
+
+	patterns := s.Patterns("", "billing", 0)
+	if len(patterns) != 1 || patterns[0].Count != 4 || patterns[0].Pattern != "request <num> took <num>ms" {
+		t.Errorf("patterns = %+v", patterns)
+	}
+}
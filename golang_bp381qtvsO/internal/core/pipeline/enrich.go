@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultLookupRefreshInterval = 5 * time.Minute
+
+type lookupTable struct {
+	name      string
+	key       string
+	keyColumn string
+	fields    map[string]string
+	source    outport.LookupSource
+	cacheKey  outport.CacheKey
+	interval  time.Duration
+	rows      atomic.Pointer[model.LookupTable]
+}
+
+// EnrichStage adds static fields and fields of lookup table rows to records. Tables are kept in memory and
+// reloaded every refresh interval, loaded tables are shared through the cache so that instances using
+// the same cache do not all read the source.
+type EnrichStage struct {
+	static  map[string]any
+	lookups []*lookupTable
+	cache   outport.Cache
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewEnrichStage registers cache partition of every lookup table and loads the tables,
+// sources are lookup sources by table name
+func NewEnrichStage(
+	cfg *app.EnrichConfig,
+	deployment string,
+	sources map[string]outport.LookupSource,
+	cache outport.Cache,
+) (*EnrichStage, error) {
+	s := &EnrichStage{
+		static: map[string]any{"deployment": deployment},
+		cache:  cache,
+		stop:   make(chan struct{}),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		s.static["hostname"] = hostname
+	}
+	for k, v := range cfg.Fields {
+		s.static[k] = v
+	}
+	for _, lc := range cfg.Lookups {
+		source, ok := sources[lc.Name]
+		if !ok {
+			return nil, fmt.Errorf("lookup table %q has no source", lc.Name)
+		}
+		if lc.Key == "" || lc.KeyColumn == "" {
+			return nil, fmt.Errorf("lookup table %s needs key and key column", lc.Name)
+		}
+		t := &lookupTable{
+			name:      lc.Name,
+			key:       lc.Key,
+			keyColumn: lc.KeyColumn,
+			fields:    lc.Fields,
+			source:    source,
+			interval:  lc.RefreshInterval,
+		}
+		if t.interval <= 0 {
+			t.interval = defaultLookupRefreshInterval
+		}
+		ns := "Lookup:" + lc.Name
+		cache.Register(&outport.CachePartition{Namespace: ns, Ttl: t.interval, LocalMaxItems: 10})
+		t.cacheKey = outport.CacheKey{Namespace: ns, EncodedKey: ns + ":rows"}
+		t.rows.Store(&model.LookupTable{})
+		s.lookups = append(s.lookups, t)
+	}
+	ctx := app.BackgroundContextWithDefaultLogger()
+	for _, t := range s.lookups {
+		s.load(ctx, t)
+		s.stopped.Add(1)
+		go s.refreshLoop(t)
+	}
+	return s, nil
+}
+
+// load replaces rows of table by cached rows or rows read from its source, current rows are kept on error
+func (s *EnrichStage) load(ctx context.Context, t *lookupTable) {
+	var rows model.LookupTable
+	if !s.cache.Get(ctx, t.cacheKey, &rows) {
+		var err error
+		if rows, err = t.source.LoadLookupTable(ctx); err != nil {
+			app.Logger(ctx).Errorf("Loading lookup table %s failed: %v", t.name, err)
+			return
+		}
+		s.cache.Set(ctx, t.cacheKey, rows)
+	}
+	t.rows.Store(&rows)
+	app.Logger(ctx).Debugf("Loaded lookup table %s with %d rows", t.name, len(rows))
+}
+
+func (s *EnrichStage) refreshLoop(t *lookupTable) {
+	defer s.stopped.Done()
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	ctx := app.BackgroundContextWithDefaultLogger()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.load(ctx, t)
+		}
+	}
+}
+
+func (s *EnrichStage) Wrap(next Handler) Handler {
+	return func(ctx context.Context, rec *model.LogRecord) {
+		if rec.Fields == nil {
+			rec.Fields = make(map[string]any)
+		}
+		for k, v := range s.static {
+			setField(rec, k, v)
+		}
+		for _, t := range s.lookups {
+			t.apply(rec)
+		}
+		next(ctx, rec)
+	}
+}
+
+func (s *EnrichStage) Close() {
+	close(s.stop)
+	s.stopped.Wait()
+}
+
+func (t *lookupTable) apply(rec *model.LogRecord) {
+	key, ok := RecordAttribute(rec, t.key)
+	if !ok || key == nil {
+		return
+	}
+	row, ok := (*t.rows.Load())[fmt.Sprint(key)]
+	if !ok {
+		return
+	}
+	if len(t.fields) == 0 {
+		for column, v := range row {
+			if column != t.keyColumn {
+				setField(rec, column, v)
+			}
+		}
+		return
+	}
+	for field, column := range t.fields {
+		if v, ok := row[column]; ok {
+			setField(rec, field, v)
+		}
+	}
+}
+
+// setField sets field unless record has it already
+func setField(rec *model.LogRecord, name string, value any) {
+	if _, ok := rec.Fields[name]; !ok {
+		rec.Fields[name] = value
+	}
+}
@@ -0,0 +1,443 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Statements of transform expressions:
+//
+//	drop [if <cond>]
+//	set <attribute> = <expr> [if <cond>]
+//	delete <field> [if <cond>]
+//
+// Expressions combine string, number, true, false and null literals and record attributes (message, source,
+// topic, level, tenant or dot separated field path) with + and the operators == != < <= > >= =~ !~ ! && ||.
+// Right side of =~ and !~ is a regular expression given as string literal. Unary - negates numbers, e.g.
+// -1 or -duration, and gives null for anything else.
+
+type exprFunc func(rec *model.LogRecord) any
+
+type statementKind int
+
+const (
+	statementDrop statementKind = iota
+	statementSet
+	statementDelete
+)
+
+// statement is a compiled transform expression
+type statement struct {
+	kind  statementKind
+	path  string
+	value exprFunc
+	cond  exprFunc // nil if unconditional
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var exprOperators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "=", "+", "-", "(", ")"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			text, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", i+1, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end + 1
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(src) && (src[end] >= '0' && src[end] <= '9' || src[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:end], pos: i})
+			i = end
+		case c == '_' || c == '@' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := i
+			for end < len(src) && isIdentChar(src[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range exprOperators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i+1)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '@' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+// compileStatement parses transform expression, errors name the position of the problem
+func compileStatement(src string) (*statement, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	st := &statement{}
+	switch keyword := p.next(); {
+	case keyword.kind == tokenIdent && keyword.text == "drop":
+		st.kind = statementDrop
+	case keyword.kind == tokenIdent && keyword.text == "set":
+		st.kind = statementSet
+		if st.path, err = p.attribute(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("="); err != nil {
+			return nil, err
+		}
+		if st.value, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	case keyword.kind == tokenIdent && keyword.text == "delete":
+		st.kind = statementDelete
+		if st.path, err = p.attribute(); err != nil {
+			return nil, err
+		}
+		switch st.path {
+		case "message", "source", "topic", "level", "tenant":
+			return nil, fmt.Errorf("%s can not be deleted, only fields can", st.path)
+		}
+	default:
+		return nil, fmt.Errorf("expression must start with drop, set or delete")
+	}
+	if t := p.peek(); t.kind == tokenIdent && t.text == "if" {
+		p.next()
+		if st.cond, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos+1)
+	}
+	return st, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) expect(op string) error {
+	if t := p.next(); t.kind != tokenOp || t.text != op {
+		return unexpected(t, op)
+	}
+	return nil
+}
+
+func (p *exprParser) attribute() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", unexpected(t, "attribute")
+	}
+	return t.text, nil
+}
+
+func unexpected(t token, expected string) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("expected %s at end of expression", expected)
+	}
+	return fmt.Errorf("expected %s at %d but found %q", expected, t.pos+1, t.text)
+}
+
+func (p *exprParser) parseOr() (exprFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(rec *model.LogRecord) any { return truthy(l(rec)) || truthy(right(rec)) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprFunc, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(rec *model.LogRecord) any { return truthy(l(rec)) && truthy(right(rec)) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprFunc, error) {
+	if t := p.peek(); t.kind == tokenOp && t.text == "!" {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(rec *model.LogRecord) any { return !truthy(operand(rec)) }, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprFunc, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokenOp {
+		return left, nil
+	}
+	switch t.text {
+	case "=~", "!~":
+		p.next()
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return nil, unexpected(pattern, "regular expression as string literal")
+		}
+		re, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at %d: %w", pattern.pos+1, err)
+		}
+		negate := t.text == "!~"
+		return func(rec *model.LogRecord) any {
+			v := left(rec)
+			return v != nil && re.MatchString(fmt.Sprint(v)) != negate
+		}, nil
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		op := t.text
+		return func(rec *model.LogRecord) any { return compareValues(op, left(rec), right(rec)) }, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseSum() (exprFunc, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "+" {
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(rec *model.LogRecord) any { return addValues(l(rec), right(rec)) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return func(*model.LogRecord) any { return t.text }, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos+1)
+		}
+		return func(*model.LogRecord) any { return n }, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			b := t.text == "true"
+			return func(*model.LogRecord) any { return b }, nil
+		case "null":
+			return func(*model.LogRecord) any { return nil }, nil
+		}
+		name := t.text
+		return func(rec *model.LogRecord) any {
+			v, _ := RecordAttribute(rec, name)
+			return v
+		}, nil
+	case tokenOp:
+		if t.text == "-" {
+			operand, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return func(rec *model.LogRecord) any {
+				if f, ok := toNumber(operand(rec)); ok {
+					return -f
+				}
+				return nil
+			}, nil
+		}
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	return nil, unexpected(t, "value")
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	}
+	if f, ok := toNumber(v); ok {
+		return f != 0
+	}
+	return true
+}
+
+func toNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// compareValues compares numbers numerically and everything else by its text, null only equals null
+func compareValues(op string, a, b any) bool {
+	if a == nil || b == nil {
+		switch op {
+		case "==":
+			return a == nil && b == nil
+		case "!=":
+			return (a == nil) != (b == nil)
+		}
+		return false
+	}
+	var cmp int
+	fa, okA := toNumber(a)
+	fb, okB := toNumber(b)
+	if okA && okB {
+		switch {
+		case fa < fb:
+			cmp = -1
+		case fa > fb:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// addValues adds numbers and concatenates anything else as text, null is treated as empty text
+func addValues(a, b any) any {
+	fa, okA := toNumber(a)
+	fb, okB := toNumber(b)
+	if okA && okB {
+		return fa + fb
+	}
+	text := func(v any) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+	return text(a) + text(b)
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"example_consumer/internal/core/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func exprTestRecord() *model.LogRecord {
+	return &model.LogRecord{
+		Source:  "billing",
+		Topic:   "payments",
+		Level:   model.LogLevelWarn,
+		Message: "payment failed: timeout",
+		Fields: map[string]any{
+			"status":   502.0,
+			"duration": 0.75,
+			"offset":   3,
+			"http":     map[string]any{"method": "POST"},
+		},
+	}
+}
+
+func TestCompileStatementValues(t *testing.T) {
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{expr: `set x = "text"`, want: "text"},
+		{expr: `set x = 1.5`, want: 1.5},
+		{expr: `set x = -1`, want: -1.0},
+		{expr: `set x = -offset`, want: -3.0},
+		{expr: `set x = -source`, want: nil},
+		{expr: `set x = null`, want: nil},
+		{expr: `set x = true`, want: true},
+		{expr: `set x = source + "/" + topic`, want: "billing/payments"},
+		{expr: `set x = status + 1`, want: 503.0},
+		{expr: `set x = status + -2`, want: 500.0},
+		{expr: `set x = missing + "!"`, want: "!"},
+		{expr: `set x = http.method`, want: "POST"},
+		{expr: `set x = (status >= 500)`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			st, err := compileStatement(tt.expr)
+			if err != nil {
+				t.Fatalf("compileStatement() error = %v", err)
+			}
+			if st.kind != statementSet || st.path != "x" {
+				t.Fatalf("statement = %+v, want set of x", st)
+			}
+			if got := st.value(exprTestRecord()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("value = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileStatementConditions(t *testing.T) {
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{cond: `level == "warn"`, want: true},
+		{cond: `level != "warn"`, want: false},
+		{cond: `status >= 500 && status < 600`, want: true},
+		{cond: `duration > -0.5`, want: true},
+		{cond: `duration < -0.5`, want: false},
+		{cond: `message =~ "(?i)TIMEOUT"`, want: true},
+		{cond: `message !~ "timeout"`, want: false},
+		{cond: `missing =~ ".*"`, want: false},
+		{cond: `missing == null`, want: true},
+		{cond: `source == null`, want: false},
+		{cond: `!(source == "billing") || topic == "payments"`, want: true},
+		{cond: `!missing`, want: true},
+		{cond: `source < "c"`, want: true},
+		{cond: `level == "warn" && source == "x" || topic == "payments"`, want: true},
+		{cond: `level == "warn" && (source == "x" || topic == "other")`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			st, err := compileStatement("drop if " + tt.cond)
+			if err != nil {
+				t.Fatalf("compileStatement() error = %v", err)
+			}
+			if got := truthy(st.cond(exprTestRecord())); got != tt.want {
+				t.Errorf("condition = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileStatementErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: `keep`, wantErr: "must start with drop, set or delete"},
+		{expr: `set = 1`, wantErr: "expected attribute at 5"},
+		{expr: `set x 1`, wantErr: "expected = at 7"},
+		{expr: `set x =`, wantErr: "expected value at end of expression"},
+		{expr: `set x = "open`, wantErr: "unterminated string at 9"},
+		{expr: `set x = 1.2.3`, wantErr: `invalid number "1.2.3" at 9`},
+		{expr: `set x = 1 - 2`, wantErr: `unexpected "-" at 11`},
+		{expr: `drop if message =~ source`, wantErr: "regular expression as string literal"},
+		{expr: `drop if message =~ "("`, wantErr: "invalid regular expression at 20"},
+		{expr: `drop if (level == "warn"`, wantErr: "expected ) at end of expression"},
+		{expr: `drop if level # 1`, wantErr: "unexpected character '#' at 15"},
+		{expr: `delete message`, wantErr: "message can not be deleted"},
+		{expr: `drop if`, wantErr: "expected value at end of expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := compileStatement(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compileStatement() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
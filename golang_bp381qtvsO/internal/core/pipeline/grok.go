@@ -0,0 +1,206 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// grokBasePatterns are the building blocks available in every grok pattern, modeled after Logstash grok
+var grokBasePatterns = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"INT":               `(?:[+-]?(?:[0-9]+))`,
+	"BASE10NUM":         `(?:[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+))`,
+	"NUMBER":            `(?:%{BASE10NUM})`,
+	"POSINT":            `\b(?:[1-9][0-9]*)\b`,
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`,
+	"IP":                `(?:%{IPV6}|%{IPV4})`,
+	"HOSTNAME":          `\b(?:[0-9A-Za-z][0-9A-Za-z-]{0,62})(?:\.(?:[0-9A-Za-z][0-9A-Za-z-]{0,62}))*\.?\b`,
+	"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"URIPATH":           `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":          `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"HTTPMETHOD":        `\b(?:GET|POST|PUT|DELETE|PATCH|HEAD|OPTIONS|CONNECT|TRACE)\b`,
+	"LOGLEVEL":          `(?:[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo|INFO|[Ww]arn(?:ing)?|WARN(?:ING)?|[Ee]rr(?:or)?|ERR(?:OR)?|[Cc]rit(?:ical)?|CRIT(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|[Pp]anic|PANIC)`,
+	"YEAR":              `(?:\d\d){1,2}`,
+	"MONTHNUM":          `(?:0?[1-9]|1[0-2])`,
+	"MONTHDAY":          `(?:(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9])`,
+	"HOUR":              `(?:2[0123]|[01]?[0-9])`,
+	"MINUTE":            `(?:[0-5][0-9])`,
+	"SECOND":            `(?:(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?)`,
+	"ISO8601_TIMEZONE":  `(?:Z|[+-]%{HOUR}(?::?%{MINUTE}))`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+}
+
+// %{NAME}, %{NAME:field} or %{NAME:field:int|float}
+var grokReferenceRe = regexp.MustCompile(`%\{(\w+)(?::([\w.@\-]+))?(?::(int|float))?\}`)
+
+// Grok is a library of named patterns that grok expressions can refer to
+type Grok struct {
+	patterns map[string]string
+}
+
+// GrokPattern is a compiled grok expression
+type GrokPattern struct {
+	re       *regexp.Regexp
+	captures map[string]grokCapture // by regexp group name
+}
+
+type grokCapture struct {
+	field string
+	kind  string // "", int or float
+}
+
+// NewGrok creates library of base patterns extended by custom ones, custom patterns may refer to each
+// other and override base patterns
+func NewGrok(custom []app.GrokPatternConfig) (*Grok, error) {
+	g := &Grok{patterns: make(map[string]string, len(grokBasePatterns)+len(custom))}
+	for name, p := range grokBasePatterns {
+		g.patterns[name] = p
+	}
+	for _, c := range custom {
+		g.patterns[c.Name] = c.Pattern
+	}
+	for _, c := range custom {
+		if _, err := g.Compile(fmt.Sprintf("%%{%s}", c.Name)); err != nil {
+			return nil, fmt.Errorf("invalid grok pattern %s: %w", c.Name, err)
+		}
+	}
+	return g, nil
+}
+
+// Compile expands pattern references and compiles the result into regular expression
+func (g *Grok) Compile(pattern string) (*GrokPattern, error) {
+	p := &GrokPattern{captures: make(map[string]grokCapture)}
+	expr, err := g.expand(pattern, p, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	p.re, err = regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// expand replaces pattern references recursively, expanding tracks references being expanded to detect cycles
+func (g *Grok) expand(pattern string, p *GrokPattern, expanding map[string]bool) (string, error) {
+	var err error
+	expr := grokReferenceRe.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		m := grokReferenceRe.FindStringSubmatch(ref)
+		name, field, kind := m[1], m[2], m[3]
+		sub, ok := g.patterns[name]
+		if !ok {
+			err = fmt.Errorf("unknown grok pattern %s", name)
+			return ""
+		}
+		if expanding[name] {
+			err = fmt.Errorf("grok pattern %s refers to itself", name)
+			return ""
+		}
+		expanding[name] = true
+		var expanded string
+		expanded, err = g.expand(sub, p, expanding)
+		delete(expanding, name)
+		if field == "" {
+			return "(?:" + expanded + ")"
+		}
+		group := fmt.Sprintf("g%d", len(p.captures))
+		p.captures[group] = grokCapture{field: field, kind: kind}
+		return fmt.Sprintf("(?P<%s>%s)", group, expanded)
+	})
+	return expr, err
+}
+
+// Match extracts fields of the first match in line, typed fields are converted to int64/float64
+func (p *GrokPattern) Match(line string) (map[string]any, bool) {
+	m := p.re.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	fields := make(map[string]any, len(p.captures))
+	for i, group := range p.re.SubexpNames() {
+		c, ok := p.captures[group]
+		if !ok || m[i] == "" {
+			continue
+		}
+		fields[c.field] = convertGrokValue(m[i], c.kind)
+	}
+	return fields, true
+}
+
+func convertGrokValue(value string, kind string) any {
+	switch kind {
+	case "int":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "float":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// grokStage extracts fields from messages of configured sources with the first grok pattern that matches
+type grokStage struct {
+	rules    map[string][]*GrokPattern
+	fallback []*GrokPattern
+}
+
+func NewGrokStage(g *Grok, cfg []app.GrokRuleConfig) (Stage, error) {
+	s := &grokStage{rules: make(map[string][]*GrokPattern)}
+	for _, rule := range cfg {
+		patterns := make([]*GrokPattern, 0, len(rule.Patterns))
+		for _, expr := range rule.Patterns {
+			p, err := g.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid grok pattern of source %s: %w", rule.Source, err)
+			}
+			patterns = append(patterns, p)
+		}
+		if rule.Source == "*" {
+			s.fallback = append(s.fallback, patterns...)
+		} else {
+			s.rules[rule.Source] = append(s.rules[rule.Source], patterns...)
+		}
+	}
+	return s, nil
+}
+
+func (s *grokStage) Wrap(next Handler) Handler {
+	return func(ctx context.Context, rec *model.LogRecord) {
+		patterns, ok := s.rules[rec.Source]
+		if !ok {
+			patterns = s.fallback
+		}
+		for _, p := range patterns {
+			if fields, ok := p.Match(rec.Message); ok {
+				ApplyFields(rec, fields)
+				break
+			}
+		}
+		next(ctx, rec)
+	}
+}
+
+func (s *grokStage) Close() {
+	// Nothing to do
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"example_consumer/internal/core/app"
+	"reflect"
+	"testing"
+)
+
+func TestGrokCompile(t *testing.T) {
+	tests := []struct {
+		name        string
+		custom      []app.GrokPatternConfig
+		pattern     string
+		line        string
+		want        map[string]any
+		wantErr     bool
+		wantNoMatch bool
+	}{
+		{
+			name:    "named and typed captures",
+			pattern: `%{IPV4:client} %{HTTPMETHOD:method} %{URIPATHPARAM:path} %{INT:status:int} %{NUMBER:duration:float}`,
+			line:    `10.0.0.1 GET /api/logs?limit=10 200 0.25`,
+			want: map[string]any{
+				"client":   "10.0.0.1",
+				"method":   "GET",
+				"path":     "/api/logs?limit=10",
+				"status":   int64(200),
+				"duration": 0.25,
+			},
+		},
+		{
+			name:    "dotted field names",
+			pattern: `%{TIMESTAMP_ISO8601:event.time} %{LOGLEVEL:event.level}`,
+			line:    `2024-05-01T10:00:00Z WARN disk almost full`,
+			want:    map[string]any{"event.time": "2024-05-01T10:00:00Z", "event.level": "WARN"},
+		},
+		{
+			name:    "unnamed references are not captured",
+			pattern: `%{WORD} %{WORD:second}`,
+			line:    `first second`,
+			want:    map[string]any{"second": "second"},
+		},
+		{
+			name:    "custom patterns refer to each other",
+			custom:  []app.GrokPatternConfig{{Name: "ORDER", Pattern: `ORD-%{POSINT}`}, {Name: "ORDERREF", Pattern: `order %{ORDER}`}},
+			pattern: `%{ORDERREF:order}`,
+			line:    `shipped order ORD-42 today`,
+			want:    map[string]any{"order": "order ORD-42"},
+		},
+		{
+			name:    "custom pattern overrides base pattern",
+			custom:  []app.GrokPatternConfig{{Name: "WORD", Pattern: `[a-z]+`}},
+			pattern: `%{WORD:word}`,
+			line:    `ABC def`,
+			want:    map[string]any{"word": "def"},
+		},
+		{
+			name:    "typed value that does not convert stays text",
+			pattern: `%{NOTSPACE:n:int}`,
+			line:    `12x`,
+			want:    map[string]any{"n": "12x"},
+		},
+		{
+			name:        "no match",
+			pattern:     `%{IPV4:client}`,
+			line:        `no address here`,
+			wantNoMatch: true,
+		},
+		{
+			name:    "unknown pattern",
+			pattern: `%{NOPE:x}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid regular expression",
+			pattern: `%{WORD:x}(`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGrok(tt.custom)
+			if err != nil {
+				t.Fatalf("NewGrok() error = %v", err)
+			}
+			p, err := g.Compile(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, ok := p.Match(tt.line)
+			if ok == tt.wantNoMatch {
+				t.Fatalf("Match() matched = %v, want %v", ok, !tt.wantNoMatch)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGrokRejectsInvalidCustomPatterns(t *testing.T) {
+	tests := []struct {
+		name   string
+		custom []app.GrokPatternConfig
+	}{
+		{name: "self reference", custom: []app.GrokPatternConfig{{Name: "LOOP", Pattern: `a%{LOOP}`}}},
+		{name: "reference cycle", custom: []app.GrokPatternConfig{{Name: "A", Pattern: `%{B}`}, {Name: "B", Pattern: `%{A}`}}},
+		{name: "unknown reference", custom: []app.GrokPatternConfig{{Name: "A", Pattern: `%{MISSING}`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewGrok(tt.custom); err == nil {
+				t.Error("NewGrok() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/metrics"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const defaultMetricMaxSeries = 1000
+
+var (
+	metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRe  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type metricRule struct {
+	*recordMatcher
+	name      string
+	field     string
+	labels    []string // attributes providing values of the labels, in order of label names
+	counter   *metrics.Counter
+	histogram *metrics.Histogram
+}
+
+// metricsStage updates counters and histograms derived from records, records are passed on unchanged
+type metricsStage struct {
+	rules []*metricRule
+}
+
+// NewMetricsStage registers metrics of rules in registry
+func NewMetricsStage(cfg []app.MetricRuleConfig, registry *metrics.Registry) (Stage, error) {
+	s := &metricsStage{}
+	for _, c := range cfg {
+		rule, err := newMetricRule(c, registry)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+func newMetricRule(c app.MetricRuleConfig, registry *metrics.Registry) (*metricRule, error) {
+	if !metricNameRe.MatchString(c.Name) {
+		return nil, fmt.Errorf("invalid metric name %q", c.Name)
+	}
+	matcher, err := newRecordMatcher(c.Source, c.Topic, c.Levels, c.Match)
+	if err != nil {
+		return nil, fmt.Errorf("metric %s: %w", c.Name, err)
+	}
+	rule := &metricRule{recordMatcher: matcher, name: c.Name, field: c.Field}
+	labelNames := lo.Keys(c.Labels)
+	sort.Strings(labelNames)
+	for _, name := range labelNames {
+		if !labelNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid label name %q in metric %s", name, c.Name)
+		}
+		rule.labels = append(rule.labels, c.Labels[name])
+	}
+	help := c.Help
+	if help == "" {
+		help = "Derived from log records"
+	}
+	maxSeries := c.MaxSeries
+	if maxSeries <= 0 {
+		maxSeries = defaultMetricMaxSeries
+	}
+	switch c.Type {
+	case "", "counter":
+		rule.counter = registry.NewCounter(c.Name, help, labelNames...).LimitSeries(maxSeries)
+	case "histogram":
+		if c.Field == "" {
+			return nil, fmt.Errorf("histogram %s needs field holding observed values", c.Name)
+		}
+		rule.histogram = registry.NewHistogram(c.Name, help, c.Buckets, labelNames...).LimitSeries(maxSeries)
+	default:
+		return nil, fmt.Errorf("unknown type %q of metric %s", c.Type, c.Name)
+	}
+	return rule, nil
+}
+
+func (s *metricsStage) Wrap(next Handler) Handler {
+	return func(ctx context.Context, rec *model.LogRecord) {
+		for _, rule := range s.rules {
+			rule.apply(rec)
+		}
+		next(ctx, rec)
+	}
+}
+
+func (s *metricsStage) Close() {
+	// Nothing to do
+}
+
+func (r *metricRule) apply(rec *model.LogRecord) {
+	if !r.matches(rec) {
+		return
+	}
+	labelValues := make([]string, len(r.labels))
+	for i, attribute := range r.labels {
+		if v, ok := RecordAttribute(rec, attribute); ok && v != nil {
+			labelValues[i] = fmt.Sprint(v)
+		}
+	}
+	if r.counter != nil {
+		r.counter.Inc(labelValues...)
+		return
+	}
+	v, ok := RecordAttribute(rec, r.field)
+	if !ok {
+		return
+	}
+	if value, ok := observedValue(v); ok {
+		r.histogram.Observe(value, labelValues...)
+	}
+}
+
+// observedValue converts numbers and numeric strings, duration strings like "1.5ms" are converted to seconds
+func observedValue(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return f, true
+		}
+		if d, err := time.ParseDuration(t); err == nil {
+			return d.Seconds(), true
+		}
+	}
+	return 0, false
+}
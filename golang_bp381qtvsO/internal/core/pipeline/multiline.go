@@ -0,0 +1,287 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMultilineFlushTimeout = 2 * time.Second
+	defaultMultilineMaxLines     = 500
+)
+
+// multilinePresets are start/continuation patterns for well-known multi-line formats
+var multilinePresets = map[string]app.MultilineConfig{
+	// Exception: message
+	//     at com.example.Foo.bar(Foo.java:42)
+	//     ... 12 more
+	// Caused by: ...
+	"java": {
+		Continuation: `^(\s+at\s|\s+\.\.\.\s+\d+\s+more|\s*Caused by:|\s*Suppressed:)`,
+	},
+	// panic: message
+	//
+	// goroutine 1 [running]:
+	// main.main()
+	//         /src/main.go:5 +0x1d
+	"go": {
+		Start: `^(panic:|fatal error:|\d{4}[-/]\d{2}[-/]\d{2}|\{|\[?(DEBUG|INFO|WARN|WARNING|ERROR|FATAL)\b)`,
+	},
+}
+
+type multilineRule struct {
+	source       string
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	flushTimeout time.Duration
+	maxLines     int
+}
+
+// continues returns whether line belongs to the record before it
+func (r *multilineRule) continues(line string) bool {
+	if r.continuation != nil && r.continuation.MatchString(line) {
+		return true
+	}
+	return r.start != nil && !r.start.MatchString(line)
+}
+
+// stream holds the pending record of one stream, its lock is held while records are passed on, so they
+// leave the stage in the order of the stream
+type stream struct {
+	mu      sync.Mutex
+	pending *pendingRecord
+	users   int // handlers using the stream, guarded by lock of the stage
+}
+
+type pendingRecord struct {
+	rule    *multilineRule
+	rec     *model.LogRecord
+	lines   []string
+	updated time.Time
+}
+
+// multilineStage joins consecutive raw records of the same stream (topic and source) that continue each other,
+// e.g. stack traces delivered line by line, into one record. Structured records are never joined, they only
+// end the pending record of their stream.
+type multilineStage struct {
+	rules    map[string]*multilineRule
+	fallback *multilineRule
+
+	mu      sync.Mutex
+	streams map[string]*stream
+	next    Handler
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewMultilineStage creates stage from per-source rules, source "*" applies to all other sources
+func NewMultilineStage(cfg []app.MultilineConfig) (Stage, error) {
+	s := &multilineStage{
+		rules:   make(map[string]*multilineRule),
+		streams: make(map[string]*stream),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, c := range cfg {
+		rule, err := compileMultilineRule(c)
+		if err != nil {
+			return nil, err
+		}
+		if c.Source == "*" {
+			s.fallback = rule
+		} else {
+			s.rules[c.Source] = rule
+		}
+	}
+	return s, nil
+}
+
+func compileMultilineRule(c app.MultilineConfig) (*multilineRule, error) {
+	if c.Preset != "" {
+		preset, ok := multilinePresets[c.Preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown multiline preset %q of source %s", c.Preset, c.Source)
+		}
+		if c.Start == "" {
+			c.Start = preset.Start
+		}
+		if c.Continuation == "" {
+			c.Continuation = preset.Continuation
+		}
+	}
+	if c.Start == "" && c.Continuation == "" {
+		return nil, fmt.Errorf("multiline rule of source %s needs start or continuation pattern", c.Source)
+	}
+	rule := &multilineRule{
+		source:       c.Source,
+		flushTimeout: c.FlushTimeout,
+		maxLines:     c.MaxLines,
+	}
+	var err error
+	if c.Start != "" {
+		if rule.start, err = regexp.Compile(c.Start); err != nil {
+			return nil, fmt.Errorf("invalid multiline start pattern of source %s: %w", c.Source, err)
+		}
+	}
+	if c.Continuation != "" {
+		if rule.continuation, err = regexp.Compile(c.Continuation); err != nil {
+			return nil, fmt.Errorf("invalid multiline continuation pattern of source %s: %w", c.Source, err)
+		}
+	}
+	if rule.flushTimeout <= 0 {
+		rule.flushTimeout = defaultMultilineFlushTimeout
+	}
+	if rule.maxLines <= 0 {
+		rule.maxLines = defaultMultilineMaxLines
+	}
+	return rule, nil
+}
+
+func (s *multilineStage) Wrap(next Handler) Handler {
+	s.next = next
+	go s.flushLoop()
+	return s.handle
+}
+
+func (s *multilineStage) handle(ctx context.Context, rec *model.LogRecord) {
+	rule, ok := s.rules[rec.Source]
+	if !ok {
+		rule = s.fallback
+	}
+	if rule == nil {
+		s.next(ctx, rec)
+		return
+	}
+	key := rec.Tenant + "\x00" + rec.Topic + "\x00" + rec.Source
+	st := s.acquire(key)
+	defer s.release(key, st)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	p := st.pending
+	switch {
+	case !rec.Raw:
+		// keeps order of the stream
+		if p != nil {
+			st.pending = nil
+			s.next(ctx, p.joined())
+		}
+		s.next(ctx, rec)
+	case p != nil && rule.continues(rec.Message):
+		p.lines = append(p.lines, rec.Message)
+		p.updated = time.Now()
+		// e.g. "ERROR" line inside the group must not be hidden by the info level of its first line
+		if rec.Level.Rank() > p.rec.Level.Rank() {
+			p.rec.Level = rec.Level
+		}
+		if len(p.lines) >= rule.maxLines {
+			st.pending = nil
+			s.next(ctx, p.joined())
+		}
+	default:
+		st.pending = &pendingRecord{
+			rule:    rule,
+			rec:     rec,
+			lines:   []string{rec.Message},
+			updated: time.Now(),
+		}
+		if p != nil {
+			s.next(ctx, p.joined())
+		}
+	}
+}
+
+// acquire returns stream of key, it is not removed before it is released again
+func (s *multilineStage) acquire(key string) *stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[key]
+	if !ok {
+		st = &stream{}
+		s.streams[key] = st
+	}
+	st.users++
+	return st
+}
+
+func (s *multilineStage) release(key string, st *stream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.users--
+	if st.users == 0 && !st.hasPending() {
+		delete(s.streams, key)
+	}
+}
+
+func (st *stream) hasPending() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.pending != nil
+}
+
+// Close passes on all records that are still pending
+func (s *multilineStage) Close() {
+	if s.next == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.flush(func(*pendingRecord) bool { return true })
+}
+
+func (s *multilineStage) flushLoop() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.flush(func(p *pendingRecord) bool {
+				return now.Sub(p.updated) >= p.rule.flushTimeout
+			})
+		}
+	}
+}
+
+func (s *multilineStage) flush(expired func(p *pendingRecord) bool) {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.streams))
+	for key := range s.streams {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+	ctx := app.BackgroundContextWithDefaultLogger()
+	for _, key := range keys {
+		s.mu.Lock()
+		st, ok := s.streams[key]
+		if ok {
+			st.users++
+		}
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+		st.mu.Lock()
+		if p := st.pending; p != nil && expired(p) {
+			st.pending = nil
+			s.next(ctx, p.joined())
+		}
+		st.mu.Unlock()
+		s.release(key, st)
+	}
+}
+
+// joined returns the first record of the group with messages of all records joined by new lines
+func (p *pendingRecord) joined() *model.LogRecord {
+	if len(p.lines) > 1 {
+		p.rec.Message = strings.Join(p.lines, "\n")
+	}
+	return p.rec
+}
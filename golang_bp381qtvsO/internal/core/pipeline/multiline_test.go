@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector keeps messages of records passed on by a stage
+type collector struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (c *collector) handle(_ context.Context, rec *model.LogRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, rec.Message)
+}
+
+func TestMultilineStage(t *testing.T) {
+	raw := func(msg string) *model.LogRecord {
+		return &model.LogRecord{Source: "billing", Message: msg, Raw: true, Level: model.LogLevelInfo}
+	}
+	tests := []struct {
+		name    string
+		config  app.MultilineConfig
+		records []*model.LogRecord
+		want    []string
+	}{
+		{
+			name:   "java stack trace",
+			config: app.MultilineConfig{Source: "billing", Preset: "java"},
+			records: []*model.LogRecord{
+				raw("java.lang.IllegalStateException: boom"),
+				raw("\tat com.example.Foo.bar(Foo.java:42)"),
+				raw("Caused by: java.io.IOException: closed"),
+				raw("\t... 12 more"),
+				raw("next line"),
+			},
+			want: []string{
+				"java.lang.IllegalStateException: boom\n\tat com.example.Foo.bar(Foo.java:42)\nCaused by: java.io.IOException: closed\n\t... 12 more",
+				"next line",
+			},
+		},
+		{
+			name:    "java preset does not join indented text",
+			config:  app.MultilineConfig{Source: "billing", Preset: "java"},
+			records: []*model.LogRecord{raw("config:"), raw("  port: 8080")},
+			want:    []string{"config:", "  port: 8080"},
+		},
+		{
+			name:    "go panic by start pattern",
+			config:  app.MultilineConfig{Source: "*", Preset: "go"},
+			records: []*model.LogRecord{raw("panic: nil map"), raw(""), raw("goroutine 1 [running]:"), raw("2024-05-01 started")},
+			want:    []string{"panic: nil map\n\ngoroutine 1 [running]:", "2024-05-01 started"},
+		},
+		{
+			name:   "structured record ends pending record",
+			config: app.MultilineConfig{Source: "billing", Preset: "java"},
+			records: []*model.LogRecord{
+				raw("Exception: boom"),
+				{Source: "billing", Message: "structured"},
+				raw("\tat com.example.Foo.bar(Foo.java:42)"),
+			},
+			want: []string{"Exception: boom", "structured", "\tat com.example.Foo.bar(Foo.java:42)"},
+		},
+		{
+			name:    "other source is passed on",
+			config:  app.MultilineConfig{Source: "billing", Preset: "java"},
+			records: []*model.LogRecord{{Source: "web", Message: "a", Raw: true}, {Source: "web", Message: "\tat b", Raw: true}},
+			want:    []string{"a", "\tat b"},
+		},
+		{
+			name:    "max lines",
+			config:  app.MultilineConfig{Source: "billing", Continuation: `^\s`, MaxLines: 2},
+			records: []*model.LogRecord{raw("a"), raw(" b"), raw(" c")},
+			want:    []string{"a\n b", " c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, err := NewMultilineStage([]app.MultilineConfig{tt.config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &collector{}
+			handle := stage.Wrap(c.handle)
+			for _, rec := range tt.records {
+				handle(context.Background(), rec)
+			}
+			stage.Close()
+			if !reflect.DeepEqual(c.messages, tt.want) {
+				t.Errorf("messages = %q, want %q", c.messages, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultilineStageKeepsLevel(t *testing.T) {
+	stage, _ := NewMultilineStage([]app.MultilineConfig{{Source: "*", Preset: "java"}})
+	var out []*model.LogRecord
+	handle := stage.Wrap(func(_ context.Context, rec *model.LogRecord) { out = append(out, rec) })
+	handle(context.Background(), &model.LogRecord{Message: "boom", Raw: true, Level: model.LogLevelInfo})
+	handle(context.Background(), &model.LogRecord{Message: "\tat x", Raw: true, Level: model.LogLevelError})
+	stage.Close()
+	if len(out) != 1 || out[0].Level != model.LogLevelError {
+		t.Errorf("got %+v, want one record with level error", out)
+	}
+}
+
+// records of a stream must leave the stage in order while the flush loop passes on expired records
+func TestMultilineStageOrderPerStream(t *testing.T) {
+	stage, _ := NewMultilineStage([]app.MultilineConfig{{Source: "*", Preset: "java", FlushTimeout: time.Millisecond}})
+	var mu sync.Mutex
+	next := map[string]int{}
+	handle := stage.Wrap(func(_ context.Context, rec *model.LogRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		if want := fmt.Sprint(next[rec.Source]); rec.Message != want {
+			t.Errorf("source %s: got %s, want %s", rec.Source, rec.Message, want)
+		}
+		next[rec.Source]++
+	})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(source string) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				handle(context.Background(), &model.LogRecord{Source: source, Message: fmt.Sprint(i), Raw: true})
+				if i%100 == 0 {
+					time.Sleep(250 * time.Millisecond)
+				}
+			}
+		}(fmt.Sprint(g))
+	}
+	wg.Wait()
+	stage.Close()
+	for source, n := range next {
+		if n != 500 {
+			t.Errorf("source %s: got %d records, want 500", source, n)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultTopic is the route name used for records of topics without own route
+const DefaultTopic = "*"
+
+// Handler processes a log record
+type Handler func(ctx context.Context, rec *model.LogRecord)
+
+// Stage transforms the stream of log records before it reaches the sinks. Wrap returns handler that
+// passes zero or more (possibly modified) records on to next, stages holding records back (e.g. to
+// merge them) must pass them on at the latest when Close is called.
+type Stage interface {
+	Wrap(next Handler) Handler
+	Close()
+}
+
+// Pipeline passes ingested log records through stages and fans them out to the sinks configured for their topic
+type Pipeline struct {
+	routes map[string][]outport.LogSink
+	router *Router
+	stages []Stage
+	handle Handler
+}
+
+// New creates pipeline with routes from topic name to sinks, records of topics without route
+// are written to the sinks of DefaultTopic route (if any). Stages are applied in the given order.
+func New(routes map[string][]outport.LogSink, stages ...Stage) *Pipeline {
+	p := &Pipeline{
+		routes: routes,
+		stages: stages,
+	}
+	p.handle = p.dispatch
+	for i := len(stages) - 1; i >= 0; i-- {
+		p.handle = stages[i].Wrap(p.handle)
+	}
+	return p
+}
+
+// UseRouter makes pipeline route records by content before their topic is considered,
+// it has to be called before records are handled
+func (p *Pipeline) UseRouter(r *Router) {
+	p.router = r
+}
+
+// Handle passes record through all stages to the sinks
+func (p *Pipeline) Handle(ctx context.Context, rec *model.LogRecord) {
+	p.handle(ctx, rec)
+}
+
+// Close flushes records held back by stages, the first stage is closed first so its records still
+// pass through the following ones
+func (p *Pipeline) Close() {
+	for _, s := range p.stages {
+		s.Close()
+	}
+}
+
+// dispatch writes record to every sink of matching routes or of its topic. Sinks are expected to buffer on their own,
+// so a failing sink only gets logged and never prevents the record from reaching the others.
+func (p *Pipeline) dispatch(ctx context.Context, rec *model.LogRecord) {
+	if rec.ID == "" {
+		// same id in every sink makes it possible to correlate copies of the record
+		rec.ID = NewRecordID()
+	}
+	var sinks []outport.LogSink
+	final := false
+	if p.router != nil {
+		sinks, final = p.router.route(rec)
+	}
+	if !final {
+		topicSinks, ok := p.routes[rec.Topic]
+		if !ok {
+			topicSinks = p.routes[DefaultTopic]
+		}
+		for _, s := range topicSinks {
+			if !containsSink(sinks, s) {
+				sinks = append(sinks, s)
+			}
+		}
+	}
+	if len(sinks) == 0 {
+		app.Logger(ctx).Debugf("No sinks configured for topic=%s, log record dropped", rec.Topic)
+		return
+	}
+	batch := []*model.LogRecord{rec}
+	for _, s := range sinks {
+		if err := s.Write(ctx, batch); err != nil {
+			app.Logger(ctx).Warnf("Writing log record to sink %s failed: %v", s.Name(), err)
+		}
+	}
+}
+
+// Topics returns names of all topics that have own route
+func (p *Pipeline) Topics() []string {
+	topics := make([]string, 0, len(p.routes))
+	for topic := range p.routes {
+		if topic != DefaultTopic {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// NewRecordID returns unique id of a log record, inputs that have to report ids assign them before ingestion
+func NewRecordID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// IsRecordID returns whether id has the format of ids created by NewRecordID
+func IsRecordID(id string) bool {
+	return primitive.IsValidObjectID(id)
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"example_consumer/internal/core/model"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// recordMatcher selects records by source, topic, levels and regular expressions over record attributes,
+// empty conditions match every record
+type recordMatcher struct {
+	source string
+	topic  string
+	levels []model.LogLevel
+	match  map[string]*regexp.Regexp
+}
+
+func newRecordMatcher(source, topic string, levels []string, match map[string]string) (*recordMatcher, error) {
+	m := &recordMatcher{
+		source: source,
+		topic:  topic,
+		levels: lo.Map(levels, func(item string, _ int) model.LogLevel { return model.ParseLogLevel(item) }),
+		match:  make(map[string]*regexp.Regexp, len(match)),
+	}
+	if m.source == "*" {
+		m.source = ""
+	}
+	for attribute, pattern := range match {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match of %s: %w", attribute, err)
+		}
+		m.match[attribute] = re
+	}
+	return m, nil
+}
+
+func (m *recordMatcher) matches(rec *model.LogRecord) bool {
+	if m.source != "" && rec.Source != m.source {
+		return false
+	}
+	if m.topic != "" && rec.Topic != m.topic {
+		return false
+	}
+	if len(m.levels) > 0 && !lo.Contains(m.levels, rec.Level) {
+		return false
+	}
+	for attribute, re := range m.match {
+		v, ok := RecordAttribute(rec, attribute)
+		if !ok || !re.MatchString(fmt.Sprint(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+// RecordAttribute returns value of record attribute: message, source, topic, level, tenant or dot separated path
+// of a (nested) record field
+func RecordAttribute(rec *model.LogRecord, name string) (any, bool) {
+	switch name {
+	case "message":
+		return rec.Message, true
+	case "source":
+		return rec.Source, true
+	case "topic":
+		return rec.Topic, true
+	case "level":
+		return string(rec.Level), true
+	case "tenant":
+		return rec.Tenant, true
+	}
+	if v, ok := rec.Fields[name]; ok {
+		// field names may contain dots themselves, e.g. attributes of OTel records
+		return v, true
+	}
+	var v any = rec.Fields
+	for _, key := range strings.Split(name, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
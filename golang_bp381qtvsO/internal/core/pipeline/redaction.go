@@ -0,0 +1,260 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// RedactedField lists names of redaction rules that changed the record
+const RedactedField = "redacted"
+
+// RedactionMask replaces masked values
+const RedactionMask = "***"
+
+type redactionDetector struct {
+	re    *regexp.Regexp
+	valid func(string) bool
+	// needsFields is set for detectors matching too many ordinary values to search message and all fields
+	needsFields bool
+}
+
+var redactionDetectors = map[string]redactionDetector{
+	"email": {re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)},
+	// international format only, national numbers look like ids or durations
+	"phone": {re: regexp.MustCompile(
+		`(?:\+|\b00)[1-9]\d{0,3}[\s\-/]?(?:\(0\)[\s\-/]?)?\d{2,5}[\s\-/]?\d{3,10}\b`,
+	)},
+	"phone_national": {
+		re: regexp.MustCompile(
+			`(?:\+|\b00)[1-9]\d{0,3}[\s\-/]?(?:\(0\)[\s\-/]?)?\d{2,5}[\s\-/]?\d{3,10}\b|\b0\d{2,5}[\s\-/]?\d{3,10}\b`,
+		),
+		needsFields: true,
+	},
+	"iban": {
+		re:    regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+		valid: validIBAN,
+	},
+}
+
+type redactionRule struct {
+	name     string
+	source   string
+	paths    [][]string
+	detector *redactionDetector
+	replace  func(string) string
+}
+
+// redactionStage removes personal data from records, whole values at field paths or matches of detectors
+type redactionStage struct {
+	rules []*redactionRule
+}
+
+func NewRedactionStage(cfg []app.RedactionRuleConfig, secret string) (Stage, error) {
+	s := &redactionStage{}
+	for i, rc := range cfg {
+		rule := &redactionRule{name: rc.Name, source: rc.Source}
+		if rule.name == "" {
+			rule.name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if rule.source == "*" {
+			rule.source = ""
+		}
+		for _, path := range rc.Fields {
+			rule.paths = append(rule.paths, strings.Split(path, "."))
+		}
+		switch {
+		case rc.Pattern != "":
+			re, err := regexp.Compile(rc.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of redaction rule %s: %w", rule.name, err)
+			}
+			rule.detector = &redactionDetector{re: re}
+		case rc.Detector != "":
+			d, ok := redactionDetectors[rc.Detector]
+			if !ok {
+				return nil, fmt.Errorf("unknown detector %s of redaction rule %s", rc.Detector, rule.name)
+			}
+			if d.needsFields && len(rule.paths) == 0 {
+				return nil, fmt.Errorf("detector %s of redaction rule %s needs fields", rc.Detector, rule.name)
+			}
+			rule.detector = &d
+		case len(rule.paths) == 0:
+			return nil, fmt.Errorf("redaction rule %s needs fields, detector or pattern", rule.name)
+		}
+		switch rc.Action {
+		case "", "mask":
+			rule.replace = func(string) string { return RedactionMask }
+		case "hash":
+			if secret == "" {
+				return nil, fmt.Errorf("redaction rule %s hashes values but credentials secret is not set", rule.name)
+			}
+			rule.replace = hmacHasher([]byte(secret))
+		default:
+			return nil, fmt.Errorf("unknown action %s of redaction rule %s", rc.Action, rule.name)
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+// hmacHasher replaces values by keyed hash, equal values get equal hashes so they can still be correlated
+func hmacHasher(key []byte) func(string) string {
+	return func(value string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil))
+	}
+}
+
+func (s *redactionStage) Wrap(next Handler) Handler {
+	return func(ctx context.Context, rec *model.LogRecord) {
+		for _, rule := range s.rules {
+			if rule.source != "" && rule.source != rec.Source {
+				continue
+			}
+			if rule.apply(rec) {
+				markRedacted(rec, rule.name)
+			}
+		}
+		next(ctx, rec)
+	}
+}
+
+func (s *redactionStage) Close() {
+	// Nothing to do
+}
+
+// apply redacts record and reports whether anything was changed
+func (r *redactionRule) apply(rec *model.LogRecord) bool {
+	changed := false
+	redact := func(v any) any {
+		if r.detector == nil {
+			return r.replaceValues(v, &changed)
+		}
+		return r.redactMatches(v, &changed)
+	}
+
+	if len(r.paths) == 0 {
+		rec.Message = redact(rec.Message).(string)
+		for k, v := range rec.Fields {
+			if k != RedactedField {
+				rec.Fields[k] = redact(v)
+			}
+		}
+		return changed
+	}
+	for _, path := range r.paths {
+		if len(path) == 1 && path[0] == "message" {
+			rec.Message = fmt.Sprint(redact(rec.Message))
+			continue
+		}
+		replaceAtPath(rec.Fields, path, redact)
+	}
+	return changed
+}
+
+// replaceValues replaces value, nested maps and slices keep their structure and have all their values replaced
+func (r *redactionRule) replaceValues(v any, changed *bool) any {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		for k, e := range t {
+			t[k] = r.replaceValues(e, changed)
+		}
+		return t
+	case []any:
+		for i, e := range t {
+			t[i] = r.replaceValues(e, changed)
+		}
+		return t
+	}
+	*changed = true
+	return r.replace(fmt.Sprint(v))
+}
+
+// redactMatches replaces detected values in strings, nested maps and slices are searched recursively
+func (r *redactionRule) redactMatches(v any, changed *bool) any {
+	switch t := v.(type) {
+	case string:
+		return r.detector.re.ReplaceAllStringFunc(t, func(match string) string {
+			if r.detector.valid != nil && !r.detector.valid(match) {
+				return match
+			}
+			*changed = true
+			return r.replace(match)
+		})
+	case map[string]any:
+		for k, e := range t {
+			t[k] = r.redactMatches(e, changed)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = r.redactMatches(e, changed)
+		}
+	}
+	return v
+}
+
+// replaceAtPath replaces value at path of nested maps, slices on the path apply remaining path to each element
+func replaceAtPath(v any, path []string, replace func(any) any) {
+	switch t := v.(type) {
+	case map[string]any:
+		e, ok := t[path[0]]
+		if !ok || e == nil {
+			return
+		}
+		if len(path) == 1 {
+			t[path[0]] = replace(e)
+			return
+		}
+		replaceAtPath(e, path[1:], replace)
+	case []any:
+		for _, e := range t {
+			replaceAtPath(e, path, replace)
+		}
+	}
+}
+
+func markRedacted(rec *model.LogRecord, rule string) {
+	if rec.Fields == nil {
+		rec.Fields = make(map[string]any)
+	}
+	switch applied := rec.Fields[RedactedField].(type) {
+	case []string:
+		rec.Fields[RedactedField] = append(applied, rule)
+	case []any:
+		rec.Fields[RedactedField] = append(applied, rule)
+	default:
+		rec.Fields[RedactedField] = []string{rule}
+	}
+}
+
+// validIBAN checks ISO 13616 check digits, it keeps detector from masking arbitrary upper case tokens
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, c := range s[4:] + s[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			digits.WriteString(fmt.Sprint(c - 'A' + 10))
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && n.Mod(n, big.NewInt(97)).Int64() == 1
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"reflect"
+	"testing"
+)
+
+func redact(t *testing.T, rules []app.RedactionRuleConfig, rec *model.LogRecord) *model.LogRecord {
+	t.Helper()
+	stage, err := NewRedactionStage(rules, "secret")
+	if err != nil {
+		t.Fatalf("NewRedactionStage() error = %v", err)
+	}
+	var out *model.LogRecord
+	stage.Wrap(func(_ context.Context, rec *model.LogRecord) { out = rec })(context.Background(), rec)
+	return out
+}
+
+func TestRedactionIBAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "compact", message: "refund to DE89370400440532013000 done", want: "refund to *** done"},
+		{name: "grouped", message: "refund to DE89 3704 0044 0532 0130 00 done", want: "refund to *** done"},
+		{name: "other country", message: "iban GB82WEST12345698765432", want: "iban ***"},
+		{name: "wrong check digits", message: "refund to DE00370400440532013000 done", want: "refund to DE00370400440532013000 done"},
+		{name: "upper case token", message: "status AB12CDEFGHIJKLMNOP", want: "status AB12CDEFGHIJKLMNOP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := redact(t, []app.RedactionRuleConfig{{Name: "iban", Detector: "iban"}}, &model.LogRecord{Message: tt.message})
+			if rec.Message != tt.want {
+				t.Errorf("message = %q, want %q", rec.Message, tt.want)
+			}
+			_, redacted := rec.Fields[RedactedField]
+			if redacted != (tt.message != tt.want) {
+				t.Errorf("fields = %v, redacted marker expected %v", rec.Fields, tt.message != tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactionPhone(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector string
+		message  string
+		want     string
+	}{
+		{name: "international", detector: "phone", message: "call +49 30 1234567 now", want: "call *** now"},
+		{name: "international with 00", detector: "phone", message: "call 0049-30-1234567", want: "call ***"},
+		{name: "with (0)", detector: "phone", message: "call +49 (0)30 1234567", want: "call ***"},
+		{name: "national number not detected", detector: "phone", message: "call 030 1234567", want: "call 030 1234567"},
+		{name: "id not detected", detector: "phone", message: "order 0123 4567890 shipped", want: "order 0123 4567890 shipped"},
+		{name: "national trunk zero", detector: "phone_national", message: "call 030 1234567", want: "call ***"},
+		{name: "national slash separated", detector: "phone_national", message: "call 0171/1234567", want: "call ***"},
+		{name: "national international", detector: "phone_national", message: "call +49 30 1234567", want: "call ***"},
+		{name: "plain number", detector: "phone_national", message: "processed 1234567 records", want: "processed 1234567 records"},
+		{name: "short code", detector: "phone_national", message: "error 042", want: "error 042"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := []app.RedactionRuleConfig{{Name: "phone", Detector: tt.detector, Fields: []string{"message"}}}
+			rec := redact(t, rules, &model.LogRecord{Message: tt.message})
+			if rec.Message != tt.want {
+				t.Errorf("message = %q, want %q", rec.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRedactionStageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule app.RedactionRuleConfig
+	}{
+		{name: "national phone without fields", rule: app.RedactionRuleConfig{Detector: "phone_national"}},
+		{name: "unknown detector", rule: app.RedactionRuleConfig{Detector: "passport"}},
+		{name: "nothing to redact", rule: app.RedactionRuleConfig{Name: "empty"}},
+		{name: "invalid pattern", rule: app.RedactionRuleConfig{Pattern: "("}},
+		{name: "unknown action", rule: app.RedactionRuleConfig{Detector: "email", Action: "drop"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRedactionStage([]app.RedactionRuleConfig{tt.rule}, "secret"); err == nil {
+				t.Error("NewRedactionStage() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRedactionFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []app.RedactionRuleConfig
+		fields map[string]any
+		want   map[string]any
+	}{
+		{
+			name:   "whole values at paths",
+			rules:  []app.RedactionRuleConfig{{Name: "customer", Fields: []string{"name", "customer.birth"}}},
+			fields: map[string]any{"name": "Jane", "customer": map[string]any{"birth": "1990-01-01", "id": "C-1"}},
+			want: map[string]any{
+				"name":     RedactionMask,
+				"customer": map[string]any{"birth": RedactionMask, "id": "C-1"},
+				"redacted": []string{"customer"},
+			},
+		},
+		{
+			name:  "nested values keep structure",
+			rules: []app.RedactionRuleConfig{{Name: "customer", Fields: []string{"customer"}}},
+			fields: map[string]any{
+				"customer": map[string]any{"name": "Jane", "age": 34.0, "tags": []any{"vip", nil}},
+				"level":    "info",
+			},
+			want: map[string]any{
+				"customer": map[string]any{"name": RedactionMask, "age": RedactionMask, "tags": []any{RedactionMask, nil}},
+				"level":    "info",
+				"redacted": []string{"customer"},
+			},
+		},
+		{
+			name:   "iban before phone",
+			rules:  []app.RedactionRuleConfig{{Name: "iban", Detector: "iban"}, {Name: "phone", Detector: "phone"}},
+			fields: map[string]any{"account": "DE89370400440532013000", "phone": "+49 30 1234567"},
+			want: map[string]any{
+				"account":  RedactionMask,
+				"phone":    RedactionMask,
+				"redacted": []string{"iban", "phone"},
+			},
+		},
+		{
+			name:   "hash keeps equal values correlated",
+			rules:  []app.RedactionRuleConfig{{Name: "email", Detector: "email", Action: "hash", Fields: []string{"a", "b"}}},
+			fields: map[string]any{"a": "jane@example.com", "b": "jane@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := redact(t, tt.rules, &model.LogRecord{Fields: tt.fields})
+			if tt.want == nil {
+				if rec.Fields["a"] != rec.Fields["b"] || rec.Fields["a"] == "jane@example.com" {
+					t.Errorf("fields = %v, want equal hashes", rec.Fields)
+				}
+				return
+			}
+			if !reflect.DeepEqual(rec.Fields, tt.want) {
+				t.Errorf("fields = %v, want %v", rec.Fields, tt.want)
+			}
+		})
+	}
+}
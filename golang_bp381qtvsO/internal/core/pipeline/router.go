@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/samber/lo"
+)
+
+type route struct {
+	*recordMatcher
+	config model.Route
+	sinks  []outport.LogSink
+}
+
+// Router sends records to sinks by content, e.g. audit records to a collection with longer retention.
+// Routing table can be replaced at runtime, records matching no route are dispatched by their topic.
+type Router struct {
+	sinks  map[string]outport.LogSink
+	routes atomic.Pointer[[]*route]
+}
+
+// NewRouter creates router with empty routing table, routes can refer to given sinks by name
+func NewRouter(sinks map[string]outport.LogSink) *Router {
+	r := &Router{sinks: sinks}
+	r.routes.Store(&[]*route{})
+	return r
+}
+
+// Load compiles routes and replaces the routing table, current table stays in use if a route is invalid
+func (r *Router) Load(cfg []app.RouteConfig) error {
+	routes := make([]*route, 0, len(cfg))
+	for i, rc := range cfg {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("route-%d", i+1)
+		}
+		matcher, err := newRecordMatcher(rc.Source, rc.Topic, rc.Levels, rc.Match)
+		if err != nil {
+			return fmt.Errorf("route %s: %w", name, err)
+		}
+		rt := &route{
+			recordMatcher: matcher,
+			config: model.Route{
+				Name:     name,
+				Topic:    rc.Topic,
+				Source:   rc.Source,
+				Levels:   matcher.levels,
+				Match:    rc.Match,
+				Sinks:    rc.Sinks,
+				Continue: rc.Continue,
+			},
+		}
+		for _, sinkName := range rc.Sinks {
+			s, ok := r.sinks[sinkName]
+			if !ok {
+				return fmt.Errorf("route %s refers to unknown log sink: %s", name, sinkName)
+			}
+			rt.sinks = append(rt.sinks, s)
+		}
+		routes = append(routes, rt)
+	}
+	r.routes.Store(&routes)
+	return nil
+}
+
+// Routes returns current routing table
+func (r *Router) Routes() []*model.Route {
+	return lo.Map(*r.routes.Load(), func(item *route, _ int) *model.Route {
+		c := item.config
+		return &c
+	})
+}
+
+// route returns sinks of routes matching record, final is false if record has to be routed by its topic too
+// because no matching route stops evaluation
+func (r *Router) route(rec *model.LogRecord) (sinks []outport.LogSink, final bool) {
+	for _, rt := range *r.routes.Load() {
+		if !rt.matches(rec) {
+			continue
+		}
+		for _, s := range rt.sinks {
+			if !containsSink(sinks, s) {
+				sinks = append(sinks, s)
+			}
+		}
+		if !rt.config.Continue {
+			return sinks, true
+		}
+	}
+	return sinks, false
+}
+
+func containsSink(sinks []outport.LogSink, sink outport.LogSink) bool {
+	for _, s := range sinks {
+		if s == sink {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"reflect"
+	"testing"
+)
+
+// namedSink is a log sink that is only compared by identity
+type namedSink struct {
+	name string
+}
+
+func (s *namedSink) Name() string                                    { return s.name }
+func (s *namedSink) Write(context.Context, []*model.LogRecord) error { return nil }
+func (s *namedSink) Close()                                          {}
+
+func sinkNames(sinks []outport.LogSink) []string {
+	names := make([]string, 0, len(sinks))
+	for _, s := range sinks {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestRouter(t *testing.T) {
+	r := NewRouter(map[string]outport.LogSink{
+		"audit":   &namedSink{name: "audit"},
+		"alerts":  &namedSink{name: "alerts"},
+		"archive": &namedSink{name: "archive"},
+	})
+	err := r.Load([]app.RouteConfig{
+		{Name: "errors", Levels: []string{"error", "fatal"}, Sinks: []string{"alerts"}, Continue: true},
+		{Name: "audit", Source: "billing", Match: map[string]string{"action": "^refund"}, Sinks: []string{"audit", "archive"}},
+		{Name: "debug", Levels: []string{"debug"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		rec       *model.LogRecord
+		wantSinks []string
+		wantFinal bool
+	}{
+		{
+			name:      "continue to topic",
+			rec:       &model.LogRecord{Source: "shop", Level: model.LogLevelError},
+			wantSinks: []string{"alerts"},
+		},
+		{
+			name:      "continue to next route",
+			rec:       &model.LogRecord{Source: "billing", Level: model.LogLevelError, Fields: map[string]any{"action": "refund"}},
+			wantSinks: []string{"alerts", "audit", "archive"},
+			wantFinal: true,
+		},
+		{
+			name:      "field does not match",
+			rec:       &model.LogRecord{Source: "billing", Level: model.LogLevelInfo, Fields: map[string]any{"action": "pay"}},
+			wantSinks: []string{},
+		},
+		{
+			name:      "route without sinks drops",
+			rec:       &model.LogRecord{Source: "shop", Level: model.LogLevelDebug},
+			wantSinks: []string{},
+			wantFinal: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinks, final := r.route(tt.rec)
+			if got := sinkNames(sinks); !reflect.DeepEqual(got, tt.wantSinks) || final != tt.wantFinal {
+				t.Errorf("route() = %v, %v, want %v, %v", got, final, tt.wantSinks, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestRouterLoadKeepsTableOnError(t *testing.T) {
+	r := NewRouter(map[string]outport.LogSink{"audit": &namedSink{name: "audit"}})
+	if err := r.Load([]app.RouteConfig{{Source: "billing", Sinks: []string{"audit"}}}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := [][]app.RouteConfig{
+		{{Name: "unknown sink", Sinks: []string{"missing"}}},
+		{{Name: "invalid pattern", Match: map[string]string{"message": "("}, Sinks: []string{"audit"}}},
+	}
+	for _, cfg := range invalid {
+		if err := r.Load(cfg); err == nil {
+			t.Errorf("routes %s loaded", cfg[0].Name)
+		}
+	}
+	routes := r.Routes()
+	if len(routes) != 1 || routes[0].Name != "route-1" || routes[0].Source != "billing" {
+		t.Errorf("routing table replaced by invalid one: %+v", routes)
+	}
+	sinks, final := r.route(&model.LogRecord{Source: "billing"})
+	if !final || !reflect.DeepEqual(sinkNames(sinks), []string{"audit"}) {
+		t.Errorf("route() = %v, %v", sinkNames(sinks), final)
+	}
+}
@@ -0,0 +1,333 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/metrics"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultSamplingSummaryInterval = time.Minute
+
+var droppedRecords = metrics.NewCounter("logservice_pipeline_dropped_records_total",
+	"Number of log records dropped by rate limits and sampling", "source", "reason")
+
+// samplingKey identifies the records sharing a token bucket and a summary, sources of different tenants are
+// limited and summarized separately
+type samplingKey struct {
+	tenant string
+	source string
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type samplingDrops struct {
+	topic       string // summary record is routed like the last dropped record
+	rateLimited int
+	sampled     int
+}
+
+// SamplingStage drops records of noisy sources by token buckets per tenant and source and sampling of low
+// levels. Dropped records are counted and summarized in a record per tenant and source written every summary
+// interval. Rules apply to the source of every tenant.
+type SamplingStage struct {
+	interval time.Duration
+
+	mu       sync.Mutex
+	rules    map[string]*model.SamplingRule
+	buckets  map[samplingKey]*tokenBucket
+	drops    map[samplingKey]*samplingDrops
+	next     Handler
+	stop     chan struct{}
+	stopped  chan struct{}
+	lastEmit time.Time
+}
+
+func NewSamplingStage(cfg *app.SamplingConfig) (*SamplingStage, error) {
+	s := &SamplingStage{
+		interval: cfg.SummaryInterval,
+		rules:    make(map[string]*model.SamplingRule),
+		buckets:  make(map[samplingKey]*tokenBucket),
+		drops:    make(map[samplingKey]*samplingDrops),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if s.interval <= 0 {
+		s.interval = defaultSamplingSummaryInterval
+	}
+	for _, rc := range cfg.Rules {
+		levels := make([]model.LogLevel, len(rc.Levels))
+		for i, l := range rc.Levels {
+			levels[i] = model.ParseLogLevel(l)
+		}
+		_, err := s.SetRule(&model.SamplingRule{
+			Source:     rc.Source,
+			Rate:       rc.Rate,
+			Burst:      rc.Burst,
+			Levels:     levels,
+			SampleRate: rc.SampleRate,
+			SampleBy:   rc.SampleBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Rules returns current rules ordered by source
+func (s *SamplingStage) Rules() []*model.SamplingRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules := make([]*model.SamplingRule, 0, len(s.rules))
+	for _, r := range s.rules {
+		c := *r
+		rules = append(rules, &c)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Source < rules[j].Source })
+	return rules
+}
+
+// SetRule adds or replaces rule of its source and returns it with defaults applied,
+// buckets of affected sources start full again
+func (s *SamplingStage) SetRule(rule *model.SamplingRule) (*model.SamplingRule, error) {
+	if err := validateSamplingRule(rule); err != nil {
+		return nil, err
+	}
+	c := *rule
+	if len(c.Levels) == 0 {
+		c.Levels = []model.LogLevel{model.LogLevelDebug, model.LogLevelInfo}
+	}
+	if c.Burst <= 0 && c.Rate > 0 {
+		c.Burst = int(c.Rate)
+		if c.Burst < 1 {
+			c.Burst = 1
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules[c.Source] = &c
+	s.resetBuckets(c.Source)
+	saved := c
+	return &saved, nil
+}
+
+// DeleteRule removes rule of source, returns false if there was none
+func (s *SamplingStage) DeleteRule(source string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[source]; !ok {
+		return false
+	}
+	delete(s.rules, source)
+	s.resetBuckets(source)
+	return true
+}
+
+func (s *SamplingStage) resetBuckets(source string) {
+	if source == DefaultTopic {
+		s.buckets = make(map[samplingKey]*tokenBucket)
+		return
+	}
+	for key := range s.buckets {
+		if key.source == source {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func validateSamplingRule(rule *model.SamplingRule) error {
+	if rule.Source == "" {
+		return fmt.Errorf("sampling rule needs source")
+	}
+	if rule.Rate < 0 || rule.Burst < 0 {
+		return fmt.Errorf("sampling rule of source %s: rate and burst must not be negative", rule.Source)
+	}
+	if rule.SampleRate < 0 || rule.SampleRate > 1 {
+		return fmt.Errorf("sampling rule of source %s: sample rate must be between 0 and 1", rule.Source)
+	}
+	for _, l := range rule.Levels {
+		switch l {
+		case model.LogLevelDebug, model.LogLevelInfo, model.LogLevelWarn:
+		case model.LogLevelError, model.LogLevelFatal:
+			return fmt.Errorf("sampling rule of source %s: %s records are never sampled", rule.Source, l)
+		default:
+			return fmt.Errorf("sampling rule of source %s: unknown level %s", rule.Source, l)
+		}
+	}
+	return nil
+}
+
+func (s *SamplingStage) Wrap(next Handler) Handler {
+	s.next = next
+	s.lastEmit = time.Now()
+	go s.summaryLoop()
+	return func(ctx context.Context, rec *model.LogRecord) {
+		if rec.Level == model.LogLevelError || rec.Level == model.LogLevelFatal {
+			next(ctx, rec)
+			return
+		}
+		if reason := s.drop(rec); reason != "" {
+			droppedRecords.Inc(rec.Source, reason)
+			return
+		}
+		next(ctx, rec)
+	}
+}
+
+// drop decides whether record is dropped, returns the reason or empty string if record is kept
+func (s *SamplingStage) drop(rec *model.LogRecord) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule, ok := s.rules[rec.Source]
+	if !ok {
+		if rule, ok = s.rules[DefaultTopic]; !ok {
+			return ""
+		}
+	}
+	if rule.SampleRate > 0 && sampledLevel(rule, rec.Level) && !keepSample(rule, rec) {
+		s.countDrop(rec).sampled++
+		return "sampling"
+	}
+	if rule.Rate > 0 && !s.takeToken(rule, samplingKey{tenant: rec.Tenant, source: rec.Source}) {
+		s.countDrop(rec).rateLimited++
+		return "rate_limit"
+	}
+	return ""
+}
+
+func sampledLevel(rule *model.SamplingRule, level model.LogLevel) bool {
+	for _, l := range rule.Levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// keepSample keeps SampleRate share of records, records with same SampleBy value are kept or dropped together
+func keepSample(rule *model.SamplingRule, rec *model.LogRecord) bool {
+	if rule.SampleBy != "" {
+		var value string
+		if rule.SampleBy == "message" {
+			value = rec.Message
+		} else if v, ok := rec.Fields[rule.SampleBy]; ok {
+			value = fmt.Sprint(v)
+		}
+		if value != "" {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(value))
+			return float64(h.Sum64()%10000) < rule.SampleRate*10000
+		}
+	}
+	return rand.Float64() < rule.SampleRate
+}
+
+func (s *SamplingStage) takeToken(rule *model.SamplingRule, key samplingKey) bool {
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(rule.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.updated).Seconds() * rule.Rate
+	if b.tokens > float64(rule.Burst) {
+		b.tokens = float64(rule.Burst)
+	}
+	b.updated = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (s *SamplingStage) countDrop(rec *model.LogRecord) *samplingDrops {
+	key := samplingKey{tenant: rec.Tenant, source: rec.Source}
+	d, ok := s.drops[key]
+	if !ok {
+		d = &samplingDrops{}
+		s.drops[key] = d
+	}
+	d.topic = rec.Topic
+	return d
+}
+
+func (s *SamplingStage) Close() {
+	if s.next == nil {
+		return
+	}
+	close(s.stop)
+	<-s.stopped
+	s.emitSummaries(time.Now())
+}
+
+func (s *SamplingStage) summaryLoop() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.emitSummaries(now)
+		}
+	}
+}
+
+// evictFullBuckets removes buckets of sources that were idle long enough to be full again, a new bucket
+// starts full as well, so buckets of sources that stopped logging do not accumulate
+func (s *SamplingStage) evictFullBuckets(now time.Time) {
+	for key, b := range s.buckets {
+		rule, ok := s.rules[key.source]
+		if !ok {
+			if rule, ok = s.rules[DefaultTopic]; !ok {
+				delete(s.buckets, key)
+				continue
+			}
+		}
+		if rule.Rate <= 0 || b.tokens+now.Sub(b.updated).Seconds()*rule.Rate >= float64(rule.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// emitSummaries writes record per tenant and source with drops since the last summary
+func (s *SamplingStage) emitSummaries(now time.Time) {
+	s.mu.Lock()
+	drops := s.drops
+	since := s.lastEmit
+	s.drops = make(map[samplingKey]*samplingDrops)
+	s.lastEmit = now
+	s.evictFullBuckets(now)
+	s.mu.Unlock()
+
+	ctx := app.BackgroundContextWithDefaultLogger()
+	for key, d := range drops {
+		s.next(ctx, &model.LogRecord{
+			Timestamp: now.UTC(),
+			Topic:     d.topic,
+			Tenant:    key.tenant,
+			Source:    key.source,
+			Level:     model.LogLevelWarn,
+			Message: fmt.Sprintf("%d records of source %s were dropped since %s (rate limit: %d, sampling: %d)",
+				d.rateLimited+d.sampled, key.source, since.UTC().Format(time.RFC3339), d.rateLimited, d.sampled),
+			Fields: map[string]any{
+				"samplingSummary":  true,
+				"droppedRateLimit": d.rateLimited,
+				"droppedSampling":  d.sampled,
+				"since":            since.UTC(),
+			},
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordCollector keeps records passed on by a stage
+type recordCollector struct {
+	mu      sync.Mutex
+	records []*model.LogRecord
+}
+
+func (c *recordCollector) handle(_ context.Context, rec *model.LogRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.records = append(c.records, rec)
+}
+
+func TestSamplingStageRateLimitPerTenant(t *testing.T) {
+	s, err := NewSamplingStage(&app.SamplingConfig{
+		SummaryInterval: time.Hour,
+		Rules:           []app.SamplingRuleConfig{{Source: "*", Rate: 0.001, Burst: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &recordCollector{}
+	handle := s.Wrap(out.handle)
+	ctx := context.Background()
+	for _, tenant := range []string{"acme", "globex"} {
+		for i := 0; i < 3; i++ {
+			handle(ctx, &model.LogRecord{Tenant: tenant, Source: "billing", Level: model.LogLevelInfo,
+				Message: fmt.Sprintf("%s %d", tenant, i)})
+		}
+	}
+	handle(ctx, &model.LogRecord{Tenant: "acme", Source: "billing", Level: model.LogLevelError, Message: "error"})
+
+	kept := make([]string, len(out.records))
+	for i, rec := range out.records {
+		kept[i] = rec.Message
+	}
+	want := []string{"acme 0", "acme 1", "globex 0", "globex 1", "error"}
+	if fmt.Sprint(kept) != fmt.Sprint(want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+
+	out.records = nil
+	s.Close()
+	sort.Slice(out.records, func(i, j int) bool { return out.records[i].Tenant < out.records[j].Tenant })
+	if len(out.records) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(out.records))
+	}
+	for i, tenant := range []string{"acme", "globex"} {
+		rec := out.records[i]
+		if rec.Tenant != tenant || rec.Source != "billing" || rec.Fields["droppedRateLimit"] != 1 {
+			t.Errorf("summary %d: tenant=%s source=%s fields=%v", i, rec.Tenant, rec.Source, rec.Fields)
+		}
+	}
+}
+
+func TestSamplingStageSampleBy(t *testing.T) {
+	s, err := NewSamplingStage(&app.SamplingConfig{
+		SummaryInterval: time.Hour,
+		Rules:           []app.SamplingRuleConfig{{Source: "billing", SampleRate: 0.5, SampleBy: "traceId"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &recordCollector{}
+	handle := s.Wrap(out.handle)
+	defer s.Close()
+	ctx := context.Background()
+	for trace := 0; trace < 50; trace++ {
+		kept := len(out.records)
+		for i := 0; i < 3; i++ {
+			handle(ctx, &model.LogRecord{Source: "billing", Level: model.LogLevelDebug,
+				Fields: map[string]any{"traceId": fmt.Sprintf("trace-%d", trace)}})
+		}
+		if n := len(out.records) - kept; n != 0 && n != 3 {
+			t.Fatalf("trace-%d: %d of 3 records kept, want all or none", trace, n)
+		}
+	}
+	if len(out.records) == 0 || len(out.records) == 150 {
+		t.Errorf("%d of 150 records kept, want about half", len(out.records))
+	}
+}
+
+func TestSamplingStageSetRule(t *testing.T) {
+	s, err := NewSamplingStage(&app.SamplingConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.SetRule(&model.SamplingRule{Source: "billing", Levels: []model.LogLevel{model.LogLevelError}}); err == nil {
+		t.Error("rule sampling error records accepted")
+	}
+	rule, err := s.SetRule(&model.SamplingRule{Source: "billing", Rate: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Burst != 1 || len(rule.Levels) != 2 {
+		t.Errorf("defaults not applied: %+v", rule)
+	}
+	if !s.DeleteRule("billing") || s.DeleteRule("billing") {
+		t.Error("rule not deleted exactly once")
+	}
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"strings"
+)
+
+type topicStatements struct {
+	topic      string // DefaultTopic applies to records of all topics
+	statements []*statement
+}
+
+// transformStage drops and rewrites records by expressions configured per topic
+type transformStage struct {
+	transforms []*topicStatements
+}
+
+// NewTransformStage compiles expressions of all topics, error names topic and expression that failed
+func NewTransformStage(cfg []app.TransformConfig) (Stage, error) {
+	s := &transformStage{}
+	for _, tc := range cfg {
+		ts := &topicStatements{topic: tc.Topic}
+		if ts.topic == "" {
+			ts.topic = DefaultTopic
+		}
+		for i, src := range tc.Expressions {
+			st, err := compileStatement(src)
+			if err != nil {
+				return nil, fmt.Errorf("transform %d of topic %s %q: %w", i+1, ts.topic, src, err)
+			}
+			ts.statements = append(ts.statements, st)
+		}
+		s.transforms = append(s.transforms, ts)
+	}
+	return s, nil
+}
+
+func (s *transformStage) Wrap(next Handler) Handler {
+	return func(ctx context.Context, rec *model.LogRecord) {
+		for _, ts := range s.transforms {
+			if ts.topic != DefaultTopic && ts.topic != rec.Topic {
+				continue
+			}
+			for _, st := range ts.statements {
+				if st.cond != nil && !truthy(st.cond(rec)) {
+					continue
+				}
+				switch st.kind {
+				case statementDrop:
+					droppedRecords.Inc(rec.Source, "transform")
+					return
+				case statementSet:
+					setAttribute(rec, st.path, st.value(rec))
+				case statementDelete:
+					deleteField(rec.Fields, strings.Split(st.path, "."))
+				}
+			}
+		}
+		next(ctx, rec)
+	}
+}
+
+func (s *transformStage) Close() {
+	// Nothing to do
+}
+
+// setAttribute sets record attribute or field at dot separated path, missing maps on the path are created
+func setAttribute(rec *model.LogRecord, name string, value any) {
+	text := func() string {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	switch name {
+	case "message":
+		rec.Message = text()
+		return
+	case "source":
+		rec.Source = text()
+		return
+	case "topic":
+		rec.Topic = text()
+		return
+	case "level":
+		rec.Level = model.ParseLogLevel(text())
+		return
+	case "tenant":
+		rec.Tenant = text()
+		return
+	}
+	if rec.Fields == nil {
+		rec.Fields = make(map[string]any)
+	}
+	path := strings.Split(name, ".")
+	m := rec.Fields
+	for _, key := range path[:len(path)-1] {
+		child, ok := m[key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[key] = child
+		}
+		m = child
+	}
+	m[path[len(path)-1]] = value
+}
+
+func deleteField(m map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	if child, ok := m[path[0]].(map[string]any); ok {
+		deleteField(child, path[1:])
+	}
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+)
+
+func (uc *UseCases) LoadCustomerContacts(
+	ctx context.Context,
+	customerNumber string,
+) ([]*model.Contact, error) {
+	app.Logger(ctx).Debugf("Load contacts of customer %s", customerNumber)
+	contacts, err := uc.AddrBook.LoadContactsByCustomerNumber(ctx, customerNumber)
+	if err != nil {
+		app.Logger(ctx).Errorf("Loading contacts of customer %s failed: %v", customerNumber, err)
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// StreamCustomerLogs calls fn for every stored log record of the tenant of context mentioning customer number.
+// With events only records consumed from customer event topics are streamed, otherwise all other records.
+func (uc *UseCases) StreamCustomerLogs(
+	ctx context.Context,
+	customerNumber string,
+	events bool,
+	fn func(store string, rec *model.LogRecord) error,
+) error {
+	app.Logger(ctx).Debugf("Stream log records of customer %s (events=%t)", customerNumber, events)
+	if events && len(uc.Export.EventTopics) == 0 {
+		return nil
+	}
+	q := &model.CustomerLogQuery{
+		CustomerNumber: customerNumber,
+		Tenant:         app.Tenant(ctx),
+		Fields:         uc.Erasure.Fields,
+		Topics:         uc.Export.EventTopics,
+		ExcludeTopics:  !events,
+	}
+	for _, store := range uc.LogStores {
+		err := store.StreamCustomerLogs(ctx, q, func(rec *model.LogRecord) error {
+			return fn(store.Name(), rec)
+		})
+		if err != nil {
+			app.Logger(ctx).Errorf("Streaming log records of customer %s from store %s failed: %v",
+				customerNumber, store.Name(), err)
+			return err
+		}
+	}
+	return nil
+}
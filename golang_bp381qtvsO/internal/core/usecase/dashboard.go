@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"sync"
+	"time"
+)
+
+const (
+	// maxParallelPanels limits queries a single dashboard runs against the log store at the same time
+	maxParallelPanels = 8
+	// timeSeriesBuckets is the number of buckets of time series panels without interval
+	timeSeriesBuckets = 60
+)
+
+func (uc *UseCases) LoadSavedSearches(
+	ctx context.Context,
+) ([]*model.SavedSearch, error) {
+	app.Logger(ctx).Debug("Load all saved searches")
+	searches, err := uc.SavedSearches.LoadAllSavedSearches(ctx, app.Tenant(ctx))
+	if err != nil {
+		app.Logger(ctx).Errorf("Loading all saved searches failed with error: %v", err)
+		return nil, err
+	}
+	return searches, nil
+}
+
+func (uc *UseCases) LoadSavedSearchByID(
+	ctx context.Context,
+	ID string,
+) (*model.SavedSearch, error) {
+	app.Logger(ctx).Debugf("Load saved search by id=%s", ID)
+	search, err := uc.SavedSearches.LoadSavedSearchByID(ctx, app.Tenant(ctx), ID)
+	if err != nil {
+		app.Logger(ctx).Errorf("Loading saved search by id=%s failed: %v", ID, err)
+		return nil, err
+	}
+	return search, nil
+}
+
+func (uc *UseCases) AddSavedSearch(
+	ctx context.Context,
+	search *model.SavedSearch,
+) (*model.SavedSearch, error) {
+	app.Logger(ctx).Debugf("Add saved search: %+v", search)
+	search.Tenant = app.Tenant(ctx)
+	search.CreatedAt = time.Now().UTC()
+	search.UpdatedAt = search.CreatedAt
+	saved, err := uc.SavedSearches.AddSavedSearch(ctx, search)
+	if err != nil {
+		app.Logger(ctx).Errorf("Adding saved search failed with error: %v", err)
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (uc *UseCases) UpdateSavedSearch(
+	ctx context.Context,
+	ID string,
+	search *model.SavedSearch,
+) (updated *model.SavedSearch, found bool, err error) {
+	app.Logger(ctx).Debugf("Update saved search by id=%s with value: %+v", ID, search)
+	existing, err := uc.LoadSavedSearchByID(ctx, ID)
+	if err != nil || existing == nil {
+		return nil, false, err
+	}
+	search.Tenant = existing.Tenant
+	search.CreatedAt = existing.CreatedAt
+	search.UpdatedAt = time.Now().UTC()
+	updated, err = uc.SavedSearches.UpdateSavedSearch(ctx, ID, search)
+	if err != nil {
+		app.Logger(ctx).Errorf("Update saved search by id=%s failed with error: %v", ID, err)
+		return nil, false, err
+	}
+	return updated, updated != nil, nil
+}
+
+func (uc *UseCases) DeleteSavedSearch(
+	ctx context.Context,
+	ID string,
+) (found bool, err error) {
+	app.Logger(ctx).Debugf("Delete saved search by id=%s", ID)
+	found, err = uc.SavedSearches.DeleteSavedSearch(ctx, app.Tenant(ctx), ID)
+	if err != nil {
+		app.Logger(ctx).Errorf("Deleting saved search by id=%s failed with error: %v", ID, err)
+	}
+	return
+}
+
+// RunSavedSearch returns records matching saved search, its time range is resolved against the current time
+func (uc *UseCases) RunSavedSearch(
+	ctx context.Context,
+	ID string,
+) (records []*model.LogRecord, found bool, err error) {
+	search, err := uc.LoadSavedSearchByID(ctx, ID)
+	if err != nil || search == nil {
+		return nil, false, err
+	}
+	q := search.Query
+	q.From, q.To = search.TimeRange.Resolve(time.Now().UTC())
+	records, err = uc.SearchLogs(ctx, &q)
+	return records, true, err
+}
+
+func (uc *UseCases) LoadDashboards(
+	ctx context.Context,
+) ([]*model.Dashboard, error) {
+	app.Logger(ctx).Debug("Load all dashboards")
+	dashboards, err := uc.Dashboards.LoadAllDashboards(ctx, app.Tenant(ctx))
+	if err != nil {
+		app.Logger(ctx).Errorf("Loading all dashboards failed with error: %v", err)
+		return nil, err
+	}
+	return dashboards, nil
+}
+
+func (uc *UseCases) LoadDashboardByID(
+	ctx context.Context,
+	ID string,
+) (*model.Dashboard, error) {
+	app.Logger(ctx).Debugf("Load dashboard by id=%s", ID)
+	dashboard, err := uc.Dashboards.LoadDashboardByID(ctx, app.Tenant(ctx), ID)
+	if err != nil {
+		app.Logger(ctx).Errorf("Loading dashboard by id=%s failed: %v", ID, err)
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+func (uc *UseCases) AddDashboard(
+	ctx context.Context,
+	dashboard *model.Dashboard,
+) (*model.Dashboard, error) {
+	app.Logger(ctx).Debugf("Add dashboard %s with %d panels", dashboard.Name, len(dashboard.Panels))
+	dashboard.Tenant = app.Tenant(ctx)
+	dashboard.CreatedAt = time.Now().UTC()
+	dashboard.UpdatedAt = dashboard.CreatedAt
+	saved, err := uc.Dashboards.AddDashboard(ctx, dashboard)
+	if err != nil {
+		app.Logger(ctx).Errorf("Adding dashboard failed with error: %v", err)
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (uc *UseCases) UpdateDashboard(
+	ctx context.Context,
+	ID string,
+	dashboard *model.Dashboard,
+) (updated *model.Dashboard, found bool, err error) {
+	app.Logger(ctx).Debugf("Update dashboard by id=%s with %d panels", ID, len(dashboard.Panels))
+	existing, err := uc.LoadDashboardByID(ctx, ID)
+	if err != nil || existing == nil {
+		return nil, false, err
+	}
+	dashboard.Tenant = existing.Tenant
+	dashboard.CreatedAt = existing.CreatedAt
+	dashboard.UpdatedAt = time.Now().UTC()
+	updated, err = uc.Dashboards.UpdateDashboard(ctx, ID, dashboard)
+	if err != nil {
+		app.Logger(ctx).Errorf("Update dashboard by id=%s failed with error: %v", ID, err)
+		return nil, false, err
+	}
+	return updated, updated != nil, nil
+}
+
+func (uc *UseCases) DeleteDashboard(
+	ctx context.Context,
+	ID string,
+) (found bool, err error) {
+	app.Logger(ctx).Debugf("Delete dashboard by id=%s", ID)
+	found, err = uc.Dashboards.DeleteDashboard(ctx, app.Tenant(ctx), ID)
+	if err != nil {
+		app.Logger(ctx).Errorf("Deleting dashboard by id=%s failed with error: %v", ID, err)
+	}
+	return
+}
+
+// RunDashboard executes all panels of dashboard in parallel against the same current time. Results are in
+// panel order, a failing panel reports its error in its result without failing the others.
+func (uc *UseCases) RunDashboard(
+	ctx context.Context,
+	ID string,
+) (results []*model.PanelResult, found bool, err error) {
+	dashboard, err := uc.LoadDashboardByID(ctx, ID)
+	if err != nil || dashboard == nil {
+		return nil, false, err
+	}
+	app.Logger(ctx).Debugf("Run %d panels of dashboard %s", len(dashboard.Panels), dashboard.Name)
+	now := time.Now().UTC()
+	results = make([]*model.PanelResult, len(dashboard.Panels))
+	sem := make(chan struct{}, maxParallelPanels)
+	var wg sync.WaitGroup
+	for i, panel := range dashboard.Panels {
+		wg.Add(1)
+		go func(i int, panel *model.DashboardPanel) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = uc.runPanel(ctx, panel, now)
+		}(i, panel)
+	}
+	wg.Wait()
+	return results, true, nil
+}
+
+func (uc *UseCases) runPanel(ctx context.Context, panel *model.DashboardPanel, now time.Time) *model.PanelResult {
+	result := &model.PanelResult{
+		Title:         panel.Title,
+		Visualization: panel.Visualization,
+	}
+	q := panel.Query
+	q.From, q.To = panel.TimeRange.Resolve(now)
+	result.From, result.To = q.From, q.To
+	var err error
+	switch panel.Visualization {
+	case model.PanelVisualizationLogs:
+		result.Records, err = uc.SearchLogs(ctx, &q)
+	case model.PanelVisualizationCount:
+		result.Buckets, err = uc.AggregateLogs(ctx, &model.LogAggregation{Query: q})
+	case model.PanelVisualizationBar:
+		result.Buckets, err = uc.AggregateLogs(ctx, &model.LogAggregation{Query: q, GroupBy: panel.GroupBy})
+	case model.PanelVisualizationTimeSeries:
+		result.Buckets, err = uc.AggregateLogs(ctx, &model.LogAggregation{
+			Query:    q,
+			GroupBy:  panel.GroupBy,
+			Interval: timeSeriesInterval(panel.Interval, q.From, q.To, now),
+		})
+	default:
+		result.Error = "unknown visualization: " + string(panel.Visualization)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// timeSeriesInterval returns interval or width of buckets dividing the time range into about 60 buckets
+func timeSeriesInterval(interval time.Duration, from time.Time, to time.Time, now time.Time) time.Duration {
+	if interval > 0 {
+		return interval
+	}
+	if to.IsZero() {
+		to = now
+	}
+	if from.IsZero() || !from.Before(to) {
+		return time.Hour
+	}
+	interval = to.Sub(from) / timeSeriesBuckets
+	if interval < time.Second {
+		return time.Second
+	}
+	return interval.Round(time.Second)
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"fmt"
+	"time"
+)
+
+// EraseCustomer deletes contacts of the customer and deletes or redacts every stored log record mentioning
+// the customer number, only records of the tenant of context if there is one. Progress is saved as erasure
+// document, completed document is the erasure certificate. Copies of records erasure cannot change are listed
+// as not covered and the erasure is only partial then.
+func (uc *UseCases) EraseCustomer(
+	ctx context.Context,
+	customerNumber string,
+) (*model.Erasure, error) {
+	app.Logger(ctx).Infof("Erase data of customer %s", customerNumber)
+	action := model.ErasureAction(uc.Erasure.Action)
+	if action == "" {
+		action = model.ErasureActionDelete
+	}
+	erasure := &model.Erasure{
+		CustomerNumber: customerNumber,
+		Tenant:         app.Tenant(ctx),
+		Status:         model.ErasureStatusRunning,
+		Action:         action,
+		RequestedAt:    time.Now().UTC(),
+	}
+	if err := uc.Erasures.SaveErasure(ctx, erasure); err != nil {
+		app.Logger(ctx).Errorf("Saving erasure of customer %s failed: %v", customerNumber, err)
+		return nil, err
+	}
+
+	if err := uc.eraseCustomerData(ctx, erasure); err != nil {
+		app.Logger(ctx).Errorf("Erasing data of customer %s failed: %v", customerNumber, err)
+		erasure.Status = model.ErasureStatusFailed
+		erasure.Error = err.Error()
+	} else if len(erasure.NotCovered) > 0 {
+		erasure.Status = model.ErasureStatusPartial
+	} else {
+		erasure.Status = model.ErasureStatusCompleted
+	}
+	completedAt := time.Now().UTC()
+	erasure.CompletedAt = &completedAt
+	if err := uc.Erasures.SaveErasure(ctx, erasure); err != nil {
+		app.Logger(ctx).Errorf("Saving erasure of customer %s failed: %v", customerNumber, err)
+		return nil, err
+	}
+	if erasure.Status == model.ErasureStatusFailed {
+		return erasure, fmt.Errorf("erasure of customer %s failed: %s", customerNumber, erasure.Error)
+	}
+	app.Logger(ctx).Infof("Erased data of customer %s: %d contacts, log stores %v, not covered %v",
+		customerNumber, erasure.ContactsDeleted, erasure.Logs, erasure.NotCovered)
+	return erasure, nil
+}
+
+func (uc *UseCases) eraseCustomerData(ctx context.Context, erasure *model.Erasure) error {
+	contacts, err := uc.AddrBook.LoadContactsByCustomerNumber(ctx, erasure.CustomerNumber)
+	if err != nil {
+		return fmt.Errorf("loading contacts: %w", err)
+	}
+	for _, contact := range contacts {
+		found, err := uc.AddrBook.DeleteContact(ctx, contact.ID)
+		if err != nil {
+			return fmt.Errorf("deleting contact id=%s: %w", contact.ID, err)
+		}
+		if found {
+			erasure.ContactsDeleted++
+		}
+	}
+
+	q := &model.CustomerLogQuery{
+		CustomerNumber: erasure.CustomerNumber,
+		Tenant:         erasure.Tenant,
+		Fields:         uc.Erasure.Fields,
+	}
+	for _, store := range uc.LogStores {
+		matched, erased, err := store.EraseCustomerLogs(ctx, q, erasure.Action)
+		erasure.Logs = append(erasure.Logs, &model.ErasureLogResult{
+			Store:   store.Name(),
+			Matched: matched,
+			Erased:  erased,
+		})
+		if err != nil {
+			return fmt.Errorf("erasing logs in store %s: %w", store.Name(), err)
+		}
+	}
+	erasure.NotCovered = append([]string(nil), uc.LogCopies...)
+	return nil
+}
+
+func (uc *UseCases) LoadErasure(
+	ctx context.Context,
+	customerNumber string,
+) (*model.Erasure, error) {
+	app.Logger(ctx).Debugf("Load erasure of customer %s", customerNumber)
+	erasure, err := uc.Erasures.LoadErasure(ctx, app.Tenant(ctx), customerNumber)
+	if err != nil {
+		app.Logger(ctx).Errorf("Loading erasure of customer %s failed: %v", customerNumber, err)
+		return nil, err
+	}
+	return erasure, nil
+}
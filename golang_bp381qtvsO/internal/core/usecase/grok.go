@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+)
+
+// TestGrokPattern applies pattern on sample lines, pattern can refer to all base and configured patterns.
+// Error is returned only if pattern can not be compiled.
+func (uc *UseCases) TestGrokPattern(
+	ctx context.Context,
+	pattern string,
+	lines []string,
+) ([]*model.GrokMatch, error) {
+	app.Logger(ctx).Debugf("Test grok pattern %q on %d lines", pattern, len(lines))
+	p, err := uc.Grok.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]*model.GrokMatch, len(lines))
+	for i, line := range lines {
+		fields, ok := p.Match(line)
+		matches[i] = &model.GrokMatch{Line: line, Matched: ok, Fields: fields}
+	}
+	return matches, nil
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+)
+
+// IngestLogs passes records to the log pipeline. Records of context with tenant are stored with its id,
+// *QuotaExceededError is returned and no record is ingested if they exceed daily quota of the tenant.
+func (uc *UseCases) IngestLogs(
+	ctx context.Context,
+	records ...*model.LogRecord,
+) error {
+	app.Logger(ctx).Debugf("Ingest %d log records", len(records))
+	if tenant := app.Tenant(ctx); tenant != "" {
+		if err := uc.reserveQuota(ctx, tenant, len(records)); err != nil {
+			app.Logger(ctx).Infof("Rejected %d log records: %v", len(records), err)
+			tenantRejectedRecords.Add(float64(len(records)), tenant)
+			return err
+		}
+		tenantIngestedRecords.Add(float64(len(records)), tenant)
+		for _, rec := range records {
+			rec.Tenant = tenant
+		}
+	}
+	for _, rec := range records {
+		uc.LogPipeline.Handle(ctx, rec)
+	}
+	return nil
+}
+
+// LoadLogPatterns returns the most frequent message patterns of the tenant of context seen by the dedupe stage
+// since start, nothing if deduplication is disabled
+func (uc *UseCases) LoadLogPatterns(
+	ctx context.Context,
+	source string,
+	limit int,
+) []*model.LogPattern {
+	app.Logger(ctx).Debugf("Load top %d log patterns of source %q", limit, source)
+	if uc.Dedupe == nil {
+		return []*model.LogPattern{}
+	}
+	return uc.Dedupe.Patterns(app.Tenant(ctx), source, limit)
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+)
+
+func (uc *UseCases) LoadRoutes(
+	ctx context.Context,
+) []*model.Route {
+	app.Logger(ctx).Debug("Load routing table")
+	return uc.Router.Routes()
+}
+
+// ReloadRoutes reads routing table from configuration file of the deployment and replaces the current one,
+// current routing table stays in use if the configuration cannot be read or is invalid
+func (uc *UseCases) ReloadRoutes(
+	ctx context.Context,
+) ([]*model.Route, error) {
+	app.Logger(ctx).Info("Reload routing table")
+	cfg, err := app.ReadConfig(uc.Deployment)
+	if err != nil {
+		app.Logger(ctx).Errorf("Reading configuration for routing table failed: %v", err)
+		return nil, err
+	}
+	if err = uc.Router.Load(cfg.Routes); err != nil {
+		app.Logger(ctx).Errorf("Invalid routing table, previous one stays in use: %v", err)
+		return nil, err
+	}
+	routes := uc.Router.Routes()
+	app.Logger(ctx).Infof("Reloaded routing table with %d routes", len(routes))
+	return routes, nil
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+)
+
+func (uc *UseCases) LoadSamplingRules(
+	ctx context.Context,
+) []*model.SamplingRule {
+	app.Logger(ctx).Debug("Load sampling rules")
+	return uc.Sampling.Rules()
+}
+
+// SaveSamplingRule adds or replaces sampling rule of the rule's source, error is returned for invalid rule
+func (uc *UseCases) SaveSamplingRule(
+	ctx context.Context,
+	rule *model.SamplingRule,
+) (*model.SamplingRule, error) {
+	app.Logger(ctx).Infof("Save sampling rule: %+v", rule)
+	saved, err := uc.Sampling.SetRule(rule)
+	if err != nil {
+		app.Logger(ctx).Infof("Invalid sampling rule: %v", err)
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (uc *UseCases) DeleteSamplingRule(
+	ctx context.Context,
+	source string,
+) (found bool) {
+	app.Logger(ctx).Infof("Delete sampling rule of source %s", source)
+	return uc.Sampling.DeleteRule(source)
+}
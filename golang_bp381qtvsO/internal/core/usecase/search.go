@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/model"
+	"example_consumer/internal/core/outport"
+	"fmt"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+	defaultBucketLimit = 1000
+	maxBucketLimit     = 10000
+)
+
+// ErrUnknownLogStore is returned for queries naming a store that does not exist or can not be queried
+var ErrUnknownLogStore = errors.New("unknown log store")
+
+// SearchLogs returns newest records matching query, 100 by default and at most 1000
+func (uc *UseCases) SearchLogs(
+	ctx context.Context,
+	q *model.LogQuery,
+) ([]*model.LogRecord, error) {
+	app.Logger(ctx).Debugf("Search log records: %+v", q)
+	store, err := uc.logStore(q.Store)
+	if err != nil {
+		return nil, err
+	}
+	limited := *q
+	limited.Limit = limit(q.Limit, defaultSearchLimit, maxSearchLimit)
+	limited.Tenant = app.Tenant(ctx)
+	records, err := store.SearchLogs(ctx, &limited)
+	if err != nil {
+		app.Logger(ctx).Errorf("Searching log records in store %s failed: %v", store.Name(), err)
+		return nil, err
+	}
+	return records, nil
+}
+
+// AggregateLogs counts records matching query per group and time bucket, query limit caps the number of
+// buckets (1000 by default)
+func (uc *UseCases) AggregateLogs(
+	ctx context.Context,
+	agg *model.LogAggregation,
+) ([]*model.LogBucket, error) {
+	app.Logger(ctx).Debugf("Aggregate log records: %+v", agg)
+	store, err := uc.logStore(agg.Query.Store)
+	if err != nil {
+		return nil, err
+	}
+	limited := *agg
+	limited.Query.Limit = limit(agg.Query.Limit, defaultBucketLimit, maxBucketLimit)
+	limited.Query.Tenant = app.Tenant(ctx)
+	buckets, err := store.AggregateLogs(ctx, &limited)
+	if err != nil {
+		app.Logger(ctx).Errorf("Aggregating log records in store %s failed: %v", store.Name(), err)
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// logStore returns store by name or the first one if name is empty
+func (uc *UseCases) logStore(name string) (outport.LogStore, error) {
+	for _, store := range uc.LogStores {
+		if name == "" || store.Name() == name {
+			return store, nil
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: no log sink can be queried", ErrUnknownLogStore)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownLogStore, name)
+}
+
+func limit(requested int, defaultLimit int, maxLimit int) int {
+	switch {
+	case requested <= 0:
+		return defaultLimit
+	case requested > maxLimit:
+		return maxLimit
+	default:
+		return requested
+	}
+}
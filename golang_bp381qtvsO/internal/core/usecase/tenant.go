@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"example_consumer/internal/core/metrics"
+	"example_consumer/internal/core/outport"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const nsTenantQuota = "TenantQuota"
+
+// TenantQuotaPartition keeps daily ingest counters of tenants, counters of past days expire
+var TenantQuotaPartition = &outport.CachePartition{
+	Namespace:     nsTenantQuota,
+	Ttl:           48 * time.Hour,
+	LocalMaxItems: 10000,
+}
+
+var (
+	tenantIngestedRecords = metrics.NewCounter("logservice_tenant_ingested_records_total",
+		"Number of log records ingested per tenant", "tenant")
+	tenantRejectedRecords = metrics.NewCounter("logservice_tenant_rejected_records_total",
+		"Number of log records rejected because tenant exceeded its daily quota", "tenant")
+)
+
+// QuotaExceededError is returned when records would exceed daily quota of their tenant
+type QuotaExceededError struct {
+	Tenant     string
+	RetryAfter time.Duration // time until quota is reset
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("daily ingest quota of tenant %s exceeded", e.Tenant)
+}
+
+// TenantByAPIKey returns id of tenant owning API key, false if key is unknown
+func (uc *UseCases) TenantByAPIKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, t := range uc.Tenants.Tenants {
+		for _, k := range t.APIKeys {
+			if k == key {
+				return t.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsAdminAPIKey returns whether key may access endpoints covering all tenants
+func (uc *UseCases) IsAdminAPIKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, k := range uc.Tenants.AdminAPIKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantOfKafkaMessage returns id of tenant named by message header or else owning topic by its prefix,
+// empty string if message belongs to no configured tenant
+func (uc *UseCases) TenantOfKafkaMessage(topic, header string) string {
+	tenant := ""
+	prefixLen := 0
+	for _, t := range uc.Tenants.Tenants {
+		if header != "" && t.ID == header {
+			return t.ID
+		}
+		// the longest matching prefix wins
+		if t.TopicPrefix != "" && strings.HasPrefix(topic, t.TopicPrefix) && len(t.TopicPrefix) > prefixLen {
+			tenant = t.ID
+			prefixLen = len(t.TopicPrefix)
+		}
+	}
+	return tenant
+}
+
+// reserveQuota counts n records against daily quota of tenant, nothing is counted if quota would be exceeded.
+// Quota is not enforced if cache cannot keep counters.
+func (uc *UseCases) reserveQuota(ctx context.Context, tenant string, n int) error {
+	quota := uc.dailyQuota(tenant)
+	if quota <= 0 || uc.Cache == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	key := outport.CacheKey{
+		Namespace:  nsTenantQuota,
+		EncodedKey: fmt.Sprintf("%s:%s:%s", nsTenantQuota, tenant, now.Format("2006-01-02")),
+	}
+	count, ok := uc.Cache.Incr(ctx, key, int64(n))
+	if !ok || count <= quota {
+		return nil
+	}
+	uc.Cache.Incr(ctx, key, -int64(n))
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return &QuotaExceededError{Tenant: tenant, RetryAfter: tomorrow.Sub(now)}
+}
+
+func (uc *UseCases) dailyQuota(tenant string) int64 {
+	for _, t := range uc.Tenants.Tenants {
+		if t.ID == tenant && t.DailyQuota > 0 {
+			return t.DailyQuota
+		}
+	}
+	return uc.Tenants.DailyQuota
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/outport"
+	"testing"
+)
+
+// counterCache keeps counters only
+type counterCache struct {
+	counters map[outport.CacheKey]int64
+}
+
+func (c *counterCache) Close()                                          {}
+func (c *counterCache) Register(*outport.CachePartition)                {}
+func (c *counterCache) Set(context.Context, outport.CacheKey, any)      {}
+func (c *counterCache) Get(context.Context, outport.CacheKey, any) bool { return false }
+func (c *counterCache) Del(_ context.Context, key outport.CacheKey)     { delete(c.counters, key) }
+func (c *counterCache) Incr(_ context.Context, key outport.CacheKey, delta int64) (int64, bool) {
+	c.counters[key] += delta
+	return c.counters[key], true
+}
+
+func TestReserveQuota(t *testing.T) {
+	uc := &UseCases{
+		Cache: &counterCache{counters: make(map[outport.CacheKey]int64)},
+		Tenants: app.TenantsConfig{
+			Enabled:    true,
+			DailyQuota: 3,
+			Tenants:    []app.TenantConfig{{ID: "payments"}, {ID: "search", DailyQuota: 10}},
+		},
+	}
+	ctx := context.Background()
+	steps := []struct {
+		tenant  string
+		records int
+		wantErr bool
+	}{
+		{"payments", 2, false},
+		{"payments", 2, true}, // rejected as a whole, nothing is counted
+		{"payments", 1, false},
+		{"payments", 1, true},
+		{"search", 5, false}, // own quota
+		{"search", 5, false},
+		{"search", 1, true},
+	}
+	for i, step := range steps {
+		err := uc.reserveQuota(ctx, step.tenant, step.records)
+		var quotaErr *QuotaExceededError
+		if step.wantErr != errors.As(err, &quotaErr) {
+			t.Fatalf("step %d: reserving %d records of %s: err = %v", i, step.records, step.tenant, err)
+		}
+		if quotaErr != nil && (quotaErr.Tenant != step.tenant || quotaErr.RetryAfter <= 0) {
+			t.Errorf("step %d: quota error = %+v", i, quotaErr)
+		}
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	uc := &UseCases{Tenants: app.TenantsConfig{
+		Enabled:      true,
+		Tenants:      []app.TenantConfig{{ID: "payments", APIKeys: []string{"k1", "k2"}}},
+		AdminAPIKeys: []string{"admin"},
+	}}
+	if tenant, ok := uc.TenantByAPIKey("k2"); !ok || tenant != "payments" {
+		t.Errorf("TenantByAPIKey(k2) = %q, %v", tenant, ok)
+	}
+	for _, key := range []string{"", "admin", "unknown"} {
+		if _, ok := uc.TenantByAPIKey(key); ok {
+			t.Errorf("TenantByAPIKey(%q) found tenant", key)
+		}
+	}
+	if !uc.IsAdminAPIKey("admin") || uc.IsAdminAPIKey("k1") || uc.IsAdminAPIKey("") {
+		t.Error("only admin key may access endpoints of all tenants")
+	}
+}
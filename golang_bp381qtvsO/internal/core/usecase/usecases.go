@@ -1,10 +1,27 @@
 package usecase
 
 import (
+	"example_consumer/internal/core/app"
 	"example_consumer/internal/core/outport"
+	"example_consumer/internal/core/pipeline"
 )
 
 type UseCases struct {
-	AddrBook outport.AddrBook
+	AddrBook      outport.AddrBook
+	LogPipeline   *pipeline.Pipeline
+	Router        *pipeline.Router
+	Grok          *pipeline.Grok
+	Sampling      *pipeline.SamplingStage
+	Dedupe        *pipeline.DedupeStage
+	LogStores     []outport.LogStore
+	LogCopies     []string // sinks and write-ahead logs keeping records that cannot be erased
+	Cache         outport.Cache
+	Erasures      outport.Erasures
+	SavedSearches outport.SavedSearches
+	Dashboards    outport.Dashboards
+	Deployment    string
+	Erasure       app.ErasureConfig
+	Export        app.ExportConfig
+	Tenants       app.TenantsConfig
 	// other output/secondary ports can be added here
 }
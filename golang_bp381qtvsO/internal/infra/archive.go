@@ -0,0 +1,42 @@
+package infra
+
+import (
+	"context"
+	"example_consumer/internal/adapters/persist"
+	"example_consumer/internal/core/app"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RestoreArchive re-imports archived log records of [from, to) into the collection of mongo sink
+func RestoreArchive(deployment string, sinkName string, from time.Time, to time.Time) error {
+	logger, _ := zap.NewDevelopment()
+	zap.ReplaceGlobals(logger)
+	ctx := app.ContextWithLogger(context.Background(), zap.S())
+
+	cfg := app.LoadConfig(deployment)
+	var sinkCfg *app.SinkConfig
+	for i := range cfg.Sinks {
+		sc := &cfg.Sinks[i]
+		if sc.Type != "mongo" {
+			continue
+		}
+		if sc.Name == sinkName || (sinkName == "" && sc.Mongo.Archive.Enabled) {
+			sinkCfg = sc
+			break
+		}
+	}
+	if sinkCfg == nil {
+		return fmt.Errorf("no mongo sink found to restore into, check --sink flag")
+	}
+
+	pers := persist.NewPersistence(cfg)
+	defer pers.Close()
+	zap.S().Infof("Restoring archived log records from %s to %s into collection %s",
+		from.Format(time.RFC3339), to.Format(time.RFC3339), sinkCfg.Mongo.Collection)
+	restored, err := persist.RestoreArchive(ctx, pers, &sinkCfg.Mongo, from, to)
+	zap.S().Infof("Restored %d log records", restored)
+	return err
+}
@@ -0,0 +1,39 @@
+package infra
+
+import (
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/kafka/consumer"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+func wireConsumer(cfg *app.Config, di *di.DI) func() {
+	// records of other inputs are routed by topic as well, but they do not come from kafka
+	topics := lo.Without(di.UseCases.LogPipeline.Topics(), cfg.Ingest.Topics()...)
+	if lo.ContainsBy(cfg.Topics, func(tc app.TopicConfig) bool { return tc.Name == pipeline.DefaultTopic }) {
+		pattern := cfg.Kafka.TopicPattern
+		if pattern == "" {
+			zap.S().Fatalf("topic %q needs kafka.topicPattern of the topics to consume", pipeline.DefaultTopic)
+		}
+		// topics starting with "^" are subscribed as regular expression
+		if _, err := regexp.Compile(pattern); err != nil || !strings.HasPrefix(pattern, "^") {
+			zap.S().Fatalf("kafka.topicPattern must be a regular expression starting with \"^\": %q", pattern)
+		}
+		topics = append(topics, pattern)
+	}
+	if len(topics) == 0 {
+		zap.S().Info("No kafka topics configured, log consumer is not started")
+		return func() {}
+	}
+	c, err := consumer.NewConsumer(&cfg.Kafka, topics, di.UseCases)
+	if err != nil {
+		zap.S().Fatalln("failed to start kafka consumer:", err)
+	}
+	go c.Run(app.BackgroundContextWithDefaultLogger())
+	return c.Close
+}
@@ -0,0 +1,28 @@
+package infra
+
+import (
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+	"example_consumer/internal/kafka/consumer"
+	"example_consumer/internal/kafka/events"
+
+	goChanKafka "github.com/c0olix/goChan/kafka"
+	"go.uber.org/zap"
+)
+
+func wireEventConsumer(cfg *app.Config, di *di.DI) {
+	if !cfg.Erasure.Enabled {
+		zap.S().Infof("Customer erasure is disabled, %s events are not consumed", events.CustomerDeletionTopicName)
+		return
+	}
+	switch action := cfg.Erasure.Action; action {
+	case "", "delete", "redact":
+	default:
+		zap.S().Fatalf("unknown erasure action: %s", action)
+	}
+	ev, err := events.NewDefaultConsumer(newGoChanManager(&cfg.Kafka), goChanKafka.ChannelConfig{})
+	if err != nil {
+		zap.S().Fatalln("failed to create customer event consumer:", err)
+	}
+	consumer.NewEventConsumer(ev, di.UseCases).Run()
+}
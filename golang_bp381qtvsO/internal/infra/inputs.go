@@ -0,0 +1,55 @@
+package infra
+
+import (
+	"example_consumer/internal/adapters/forward"
+	"example_consumer/internal/adapters/gelf"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+
+	"go.uber.org/zap"
+)
+
+// wireInputListeners starts enabled inputs that listen on their own ports, inputs served by the API server
+// are registered with its routes
+func wireInputListeners(cfg *app.Config, di *di.DI) func() {
+	var cleanups []func()
+	if fc := &cfg.Ingest.Forward; fc.Enabled {
+		tenant := listenerTenant(cfg, "forward", fc)
+		s, err := forward.Listen(fc.AddrOr(":24224"), fc.TopicOr("forward"), tenant, di.UseCases)
+		if err != nil {
+			zap.S().Fatalln("failed to start forward input:", err)
+		}
+		go s.Run(app.BackgroundContextWithDefaultLogger())
+		cleanups = append(cleanups, s.Close)
+	}
+	if gc := &cfg.Ingest.Gelf; gc.Enabled {
+		tenant := listenerTenant(cfg, "gelf", &gc.ListenIngestConfig)
+		s, err := gelf.Listen(gc.AddrOr(":12201"), gc.TopicOr("gelf"), tenant, gc.ChunkTimeout, di.UseCases)
+		if err != nil {
+			zap.S().Fatalln("failed to start GELF input:", err)
+		}
+		go s.Run(app.BackgroundContextWithDefaultLogger())
+		cleanups = append(cleanups, s.Close)
+	}
+	return func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}
+}
+
+// listenerTenant returns tenant of records received by listener, listeners do not authenticate senders, so with
+// tenants enabled each of them must name a configured tenant
+func listenerTenant(cfg *app.Config, name string, lc *app.ListenIngestConfig) string {
+	tc := &cfg.Tenants
+	if !tc.Enabled {
+		return ""
+	}
+	for _, t := range tc.Tenants {
+		if t.ID == lc.Tenant {
+			return t.ID
+		}
+	}
+	zap.S().Fatalf("%s input needs tenant of tenants configuration, found %q", name, lc.Tenant)
+	return ""
+}
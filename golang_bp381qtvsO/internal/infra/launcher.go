@@ -10,9 +10,14 @@ import (
 func Start(deployment string) {
 	logger, _ := zap.NewDevelopment()
 	zap.ReplaceGlobals(logger)
-	ctx := app.ContextWithLogger(context.Background(), zap.S())
 
 	cfg := app.LoadConfig(deployment)
+	logger, loggingCleanup := wireLogging(cfg, logger)
+	zap.ReplaceGlobals(logger)
+	ctx := app.ContextWithLogger(context.Background(), zap.S())
+
 	di := wireDependencies(cfg)
 	apiserver.Start(ctx, di)
+	// own logs are shipped until everything else is closed
+	loggingCleanup()
 }
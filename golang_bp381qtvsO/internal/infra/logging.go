@@ -0,0 +1,37 @@
+package infra
+
+import (
+	"example_consumer/internal/adapters/zapkafka"
+	"example_consumer/internal/core/app"
+
+	goChanKafka "github.com/c0olix/goChan/kafka"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// wireLogging tees logger into kafka topic if shipping of own logs is enabled
+func wireLogging(cfg *app.Config, logger *zap.Logger) (*zap.Logger, func()) {
+	kc := &cfg.Logging.Kafka
+	if !kc.Enabled {
+		return logger, func() {}
+	}
+	if kc.Topic == "" {
+		zap.S().Fatal("kafka topic of own logs is not configured")
+	}
+	level := zapcore.InfoLevel
+	if kc.Level != "" {
+		if err := level.UnmarshalText([]byte(kc.Level)); err != nil {
+			zap.S().Fatalf("invalid level of own logs shipped to kafka: %v", err)
+		}
+	}
+	channel, err := newGoChanManager(&cfg.Kafka).CreateChannel(kc.Topic, goChanKafka.ChannelConfig{})
+	if err != nil {
+		zap.S().Fatalln("failed to create kafka channel for own logs:", err)
+	}
+	shipper := zapkafka.NewShipper(channel, kc, logger.Sugar())
+	kafkaCore := shipper.NewCore(level, kc.Source)
+	zap.S().Infof("Own logs of level %s and above are shipped to kafka topic %s", level, kc.Topic)
+	return logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, kafkaCore)
+	})), shipper.Close
+}
@@ -11,12 +11,14 @@ func wirePersistPorts(
 	cfg *app.Config,
 	cache outport.Cache,
 	di *di.DI,
-) func() {
+) (outport.Persistence, func()) {
 	pers := persist.NewPersistence(cfg)
 	addrBook := persist.NewAddrBookAdapter(
 		pers,
 		cache,
 	)
 	di.UseCases.AddrBook = addrBook
-	return pers.Close
+	di.UseCases.Erasures = persist.NewErasureAdapter(pers)
+	di.UseCases.SavedSearches, di.UseCases.Dashboards = persist.NewDashboardAdapters(pers)
+	return pers, pers.Close
 }
@@ -0,0 +1,31 @@
+package infra
+
+import (
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// wireRouteReload reloads routing table from configuration file on SIGHUP
+func wireRouteReload(di *di.DI) func() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		ctx := app.BackgroundContextWithDefaultLogger()
+		for {
+			select {
+			case <-hup:
+				_, _ = di.UseCases.ReloadRoutes(ctx)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(hup)
+		close(done)
+	}
+}
@@ -0,0 +1,118 @@
+package infra
+
+import (
+	"example_consumer/internal/adapters/persist"
+	"example_consumer/internal/adapters/sink"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+	"example_consumer/internal/core/outport"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/kafka/configkafka"
+	"example_consumer/internal/kafka/securechan"
+	"fmt"
+	"strings"
+
+	"github.com/c0olix/goChan"
+	goChanKafka "github.com/c0olix/goChan/kafka"
+	"go.uber.org/zap"
+)
+
+func wireLogPipeline(
+	cfg *app.Config,
+	pers outport.Persistence,
+	cache outport.Cache,
+	di *di.DI,
+) (*pipeline.Pipeline, func()) {
+	var manager goChan.ManagerInterface
+	sinks := make(map[string]outport.LogSink, len(cfg.Sinks))
+	for i := range cfg.Sinks {
+		sc := &cfg.Sinks[i]
+		if _, ok := sinks[sc.Name]; ok {
+			zap.S().Fatalf("log sink with name=%s was already configured", sc.Name)
+		}
+		var s outport.LogSink
+		var err error
+		switch sc.Type {
+		case "mongo":
+			s, err = persist.NewLogSinkAdapter(pers, sc.Name, &sc.Mongo)
+		case "file":
+			s, err = sink.NewFileSink(sc.Name, &sc.File)
+		case "stdout":
+			s = sink.NewStdoutSink(sc.Name)
+		case "kafka":
+			if manager == nil {
+				manager = newGoChanManager(&cfg.Kafka)
+			}
+			s, err = newKafkaSink(manager, sc)
+		case "webhook":
+			s, err = sink.NewWebhookSink(sc.Name, &sc.Webhook)
+		default:
+			zap.S().Fatalf("unknown log sink type: %s", sc.Type)
+		}
+		if store, ok := s.(outport.LogStore); ok {
+			di.UseCases.LogStores = append(di.UseCases.LogStores, store)
+		} else {
+			di.UseCases.LogCopies = append(di.UseCases.LogCopies, fmt.Sprintf("%s sink %s", sc.Type, sc.Name))
+		}
+		if err == nil && sc.WAL.Enabled {
+			di.UseCases.LogCopies = append(di.UseCases.LogCopies, fmt.Sprintf("write-ahead log of sink %s", sc.Name))
+			s, err = sink.NewWALSink(s, &sc.WAL)
+		}
+		if err != nil {
+			zap.S().Fatalf("failed to create log sink %s: %v", sc.Name, err)
+		}
+		sinks[sc.Name] = sink.NewBufferedSink(s, &sc.Buffer)
+	}
+
+	routes := make(map[string][]outport.LogSink, len(cfg.Topics))
+	for _, tc := range cfg.Topics {
+		routes[tc.Name] = make([]outport.LogSink, 0, len(tc.Sinks))
+		for _, name := range tc.Sinks {
+			s, ok := sinks[name]
+			if !ok {
+				zap.S().Fatalf("topic %s refers to unknown log sink: %s", tc.Name, name)
+			}
+			routes[tc.Name] = append(routes[tc.Name], s)
+		}
+	}
+
+	router := pipeline.NewRouter(sinks)
+	if err := router.Load(cfg.Routes); err != nil {
+		zap.S().Fatalf("invalid routing table: %v", err)
+	}
+	di.UseCases.Router = router
+
+	stages := wirePipelineStages(cfg, pers, cache, di)
+	p := pipeline.New(routes, stages...)
+	p.UseRouter(router)
+	return p, func() {
+		p.Close()
+		for _, s := range sinks {
+			s.Close()
+		}
+	}
+}
+
+// newGoChanManager connects goChan to brokers, secured brokers are connected by own manager with the same channels
+func newGoChanManager(cfg *app.KafkaConfig) goChan.ManagerInterface {
+	if configkafka.Protocol(&cfg.Security) != configkafka.ProtocolPlaintext {
+		manager, err := securechan.NewManager(cfg)
+		if err != nil {
+			zap.S().Fatalln("failed to create secured kafka manager:", err)
+		}
+		return manager
+	}
+	manager, err := goChanKafka.NewManager(strings.Split(cfg.Brokers, ","))
+	if err != nil {
+		zap.S().Fatalln("failed to create goChan kafka manager:", err)
+	}
+	return manager
+}
+
+func newKafkaSink(manager goChan.ManagerInterface, sc *app.SinkConfig) (outport.LogSink, error) {
+	channel, err := manager.CreateChannel(sc.Kafka.Topic, goChanKafka.ChannelConfig{})
+	if err != nil {
+		return nil, err
+	}
+	return sink.NewKafkaSink(sc.Name, channel), nil
+}
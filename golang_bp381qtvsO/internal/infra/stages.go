@@ -0,0 +1,84 @@
+package infra
+
+import (
+	"example_consumer/internal/adapters/lookup"
+	"example_consumer/internal/adapters/persist"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+	"example_consumer/internal/core/metrics"
+	"example_consumer/internal/core/outport"
+	"example_consumer/internal/core/pipeline"
+
+	"go.uber.org/zap"
+)
+
+// wirePipelineStages creates configured pipeline stages in the order records pass them
+func wirePipelineStages(cfg *app.Config, pers outport.Persistence, cache outport.Cache, di *di.DI) []pipeline.Stage {
+	var stages []pipeline.Stage
+	pc := &cfg.Pipeline
+	if len(pc.Multiline) > 0 {
+		stages = append(stages, mustStage(pipeline.NewMultilineStage(pc.Multiline)))
+	}
+
+	grok, err := pipeline.NewGrok(pc.Grok.Patterns)
+	if err != nil {
+		zap.S().Fatalf("invalid pipeline configuration: %v", err)
+	}
+	di.UseCases.Grok = grok
+	if len(pc.Grok.Rules) > 0 {
+		stages = append(stages, mustStage(pipeline.NewGrokStage(grok, pc.Grok.Rules)))
+	}
+	if len(pc.Transform) > 0 {
+		stages = append(stages, mustStage(pipeline.NewTransformStage(pc.Transform)))
+	}
+	if pc.Enrich.Enabled {
+		stages = append(stages, mustStage(pipeline.NewEnrichStage(&pc.Enrich, cfg.Deployment, lookupSources(pc.Enrich.Lookups, pers), cache)))
+	}
+	if len(pc.Redaction) > 0 {
+		stages = append(stages, mustStage(pipeline.NewRedactionStage(pc.Redaction, cfg.Credentials.Secret)))
+	}
+	// metrics count records before sampling drops some of them, label values must not contain personal data
+	if len(pc.Metrics) > 0 {
+		stages = append(stages, mustStage(pipeline.NewMetricsStage(pc.Metrics, metrics.Default)))
+	}
+	// sampling stage is always created, rules can be added at runtime
+	sampling, err := pipeline.NewSamplingStage(&pc.Sampling)
+	if err != nil {
+		zap.S().Fatalf("invalid pipeline configuration: %v", err)
+	}
+	di.UseCases.Sampling = sampling
+	stages = append(stages, sampling)
+	// after redaction, pattern statistics must not keep personal data
+	if pc.Dedupe.Enabled {
+		dedupe := pipeline.NewDedupeStage(&pc.Dedupe)
+		di.UseCases.Dedupe = dedupe
+		di.UseCases.LogCopies = append(di.UseCases.LogCopies, "pattern statistics of dedupe stage")
+		stages = append(stages, dedupe)
+	}
+	return stages
+}
+
+func lookupSources(cfg []app.LookupConfig, pers outport.Persistence) map[string]outport.LookupSource {
+	sources := make(map[string]outport.LookupSource, len(cfg))
+	for _, lc := range cfg {
+		if _, ok := sources[lc.Name]; ok {
+			zap.S().Fatalf("lookup table with name=%s was already configured", lc.Name)
+		}
+		switch {
+		case lc.File != "":
+			sources[lc.Name] = lookup.NewCSVSource(lc.File, lc.KeyColumn)
+		case lc.Collection != "":
+			sources[lc.Name] = persist.NewLookupSourceAdapter(pers, lc.Collection, lc.KeyColumn)
+		default:
+			zap.S().Fatalf("lookup table %s needs file or collection", lc.Name)
+		}
+	}
+	return sources
+}
+
+func mustStage(stage pipeline.Stage, err error) pipeline.Stage {
+	if err != nil {
+		zap.S().Fatalf("invalid pipeline configuration: %v", err)
+	}
+	return stage
+}
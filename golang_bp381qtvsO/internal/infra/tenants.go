@@ -0,0 +1,49 @@
+package infra
+
+import (
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/di"
+	"example_consumer/internal/core/outport"
+	"example_consumer/internal/core/usecase"
+
+	"go.uber.org/zap"
+)
+
+func wireTenants(cfg *app.Config, cache outport.Cache, di *di.DI) {
+	tc := &cfg.Tenants
+	if !tc.Enabled {
+		return
+	}
+	ids := make(map[string]bool, len(tc.Tenants))
+	keys := make(map[string]bool)
+	quotas := tc.DailyQuota > 0
+	for _, t := range tc.Tenants {
+		if t.ID == "" || ids[t.ID] {
+			zap.S().Fatalf("tenant id must be set and unique: %q", t.ID)
+		}
+		ids[t.ID] = true
+		for _, key := range t.APIKeys {
+			if key == "" || keys[key] {
+				zap.S().Fatalf("API keys of tenant %s must be set and unique", t.ID)
+			}
+			keys[key] = true
+		}
+		quotas = quotas || t.DailyQuota > 0
+	}
+	for _, key := range tc.AdminAPIKeys {
+		if key == "" || keys[key] {
+			zap.S().Fatal("admin API keys must be set and differ from each other and from keys of tenants")
+		}
+		keys[key] = true
+	}
+	if len(tc.AdminAPIKeys) == 0 {
+		zap.S().Warn("No admin API keys configured, pipeline endpoints and /metrics reject all requests")
+	}
+	if quotas && cfg.Cache.Type == "none" {
+		zap.S().Warn("Daily quotas of tenants are not enforced, cache type none keeps no counters")
+	}
+	cache.Register(usecase.TenantQuotaPartition)
+	di.UseCases.Cache = cache
+	di.UseCases.Tenants = *tc
+	zap.S().Infof("Tenants are enabled, %d tenants configured", len(tc.Tenants))
+}
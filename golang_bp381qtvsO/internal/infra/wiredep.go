@@ -10,20 +10,37 @@ import (
 func wireDependencies(cfg *app.Config) *di.DI {
 	zap.S().Info("Initialize DI objects")
 	newDI := &di.DI{
-		Config:   cfg,
-		UseCases: &usecase.UseCases{},
+		Config: cfg,
+		UseCases: &usecase.UseCases{
+			Deployment: cfg.Deployment,
+			Erasure:    cfg.Erasure,
+			Export:     cfg.Export,
+		},
 	}
 
 	cache, cacheCleanup := wireCachePorts(cfg, newDI)
+	wireTenants(cfg, cache, newDI)
 
-	persistCleanup := wirePersistPorts(
+	pers, persistCleanup := wirePersistPorts(
 		cfg,
 		cache,
 		newDI,
 	)
 
+	logPipeline, pipelineCleanup := wireLogPipeline(cfg, pers, cache, newDI)
+	newDI.UseCases.LogPipeline = logPipeline
+	reloadCleanup := wireRouteReload(newDI)
+
+	consumerCleanup := wireConsumer(cfg, newDI)
+	inputCleanup := wireInputListeners(cfg, newDI)
+	wireEventConsumer(cfg, newDI)
+
 	newDI.Close = func() {
 		zap.S().Info("Performing cleanup of all initialized DI objects")
+		reloadCleanup()
+		consumerCleanup()
+		inputCleanup()
+		pipelineCleanup()
 		persistCleanup()
 		cacheCleanup()
 	}
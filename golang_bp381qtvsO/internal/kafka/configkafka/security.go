@@ -0,0 +1,149 @@
+package configkafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"example_consumer/internal/core/app"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// confluent security properties, values are taken from app.KafkaSecurityConfig
+const (
+	SecurityProtocol = "security.protocol"
+	SaslMechanisms   = "sasl.mechanisms"
+	SaslUsername     = "sasl.username"
+	SaslPassword     = "sasl.password"
+	SslCaLocation    = "ssl.ca.location"
+	SslCertLocation  = "ssl.certificate.location"
+	SslKeyLocation   = "ssl.key.location"
+)
+
+const (
+	ProtocolPlaintext     = "PLAINTEXT"
+	ProtocolSsl           = "SSL"
+	ProtocolSaslPlaintext = "SASL_PLAINTEXT"
+	ProtocolSaslSsl       = "SASL_SSL"
+
+	MechanismPlain       = "PLAIN"
+	MechanismScramSha256 = "SCRAM-SHA-256"
+	MechanismScramSha512 = "SCRAM-SHA-512"
+)
+
+// Protocol returns configured security protocol in upper case, PLAINTEXT if there is none
+func Protocol(cfg *app.KafkaSecurityConfig) string {
+	if cfg.Protocol == "" {
+		return ProtocolPlaintext
+	}
+	return strings.ToUpper(cfg.Protocol)
+}
+
+// UsesSasl reports whether brokers authenticate clients by SASL
+func UsesSasl(cfg *app.KafkaSecurityConfig) bool {
+	p := Protocol(cfg)
+	return p == ProtocolSaslPlaintext || p == ProtocolSaslSsl
+}
+
+// UsesTls reports whether connections to brokers are encrypted
+func UsesTls(cfg *app.KafkaSecurityConfig) bool {
+	p := Protocol(cfg)
+	return p == ProtocolSsl || p == ProtocolSaslSsl
+}
+
+// ValidateSecurity checks protocol, mechanism and credentials without touching any file
+func ValidateSecurity(cfg *app.KafkaSecurityConfig) error {
+	switch Protocol(cfg) {
+	case ProtocolPlaintext, ProtocolSsl, ProtocolSaslPlaintext, ProtocolSaslSsl:
+	default:
+		return fmt.Errorf("unsupported kafka security protocol %s", cfg.Protocol)
+	}
+	if UsesSasl(cfg) {
+		switch strings.ToUpper(cfg.SaslMechanism) {
+		case MechanismPlain, MechanismScramSha256, MechanismScramSha512:
+		default:
+			return fmt.Errorf("unsupported kafka sasl mechanism %q", cfg.SaslMechanism)
+		}
+		if cfg.Username == "" {
+			return fmt.Errorf("kafka sasl mechanism %s needs username", cfg.SaslMechanism)
+		}
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return fmt.Errorf("kafka client certificate needs both certFile and keyFile")
+	}
+	return nil
+}
+
+// ApplySecurity sets security properties of confluent client configuration
+func ApplySecurity(config *kafka.ConfigMap, cfg *app.KafkaSecurityConfig) error {
+	if err := ValidateSecurity(cfg); err != nil {
+		return err
+	}
+	props := map[string]string{SecurityProtocol: Protocol(cfg)}
+	if UsesSasl(cfg) {
+		props[SaslMechanisms] = strings.ToUpper(cfg.SaslMechanism)
+		props[SaslUsername] = cfg.Username
+		props[SaslPassword] = cfg.Password
+	}
+	if UsesTls(cfg) {
+		props[SslCaLocation] = cfg.CaFile
+		props[SslCertLocation] = cfg.CertFile
+		props[SslKeyLocation] = cfg.KeyFile
+	}
+	for key, value := range props {
+		if value == "" {
+			continue
+		}
+		if err := config.SetKey(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaslMechanism returns kafka-go SASL mechanism, nil if brokers do not authenticate clients
+func SaslMechanism(cfg *app.KafkaSecurityConfig) (sasl.Mechanism, error) {
+	if !UsesSasl(cfg) {
+		return nil, nil
+	}
+	switch strings.ToUpper(cfg.SaslMechanism) {
+	case MechanismPlain:
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case MechanismScramSha256:
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case MechanismScramSha512:
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	}
+	return nil, fmt.Errorf("unsupported kafka sasl mechanism %q", cfg.SaslMechanism)
+}
+
+// TlsConfig loads CA and client certificate for kafka-go, nil if connections are not encrypted
+func TlsConfig(cfg *app.KafkaSecurityConfig) (*tls.Config, error) {
+	if !UsesTls(cfg) {
+		return nil, nil
+	}
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CaFile != "" {
+		pem, err := os.ReadFile(cfg.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading kafka CA file: %w", err)
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in kafka CA file %s", cfg.CaFile)
+		}
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading kafka client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
@@ -0,0 +1,197 @@
+package consumer
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/pipeline"
+	"example_consumer/internal/core/usecase"
+	"example_consumer/internal/kafka/configkafka"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+)
+
+const (
+	pollTimeoutMs = 100
+	minBackoff    = 500 * time.Millisecond
+	maxBackoff    = 30 * time.Second
+)
+
+// Consumer reads log records from kafka topics and passes them to the log pipeline
+type Consumer struct {
+	consumer  *kafka.Consumer
+	uc        *usecase.UseCases
+	stats     *statsCollector
+	backoff   time.Duration // wait before next poll after transient error, 0 if the last poll succeeded
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewConsumer(cfg *app.KafkaConfig, topics []string, uc *usecase.UseCases) (*Consumer, error) {
+	config := &kafka.ConfigMap{
+		configkafka.Host:   cfg.Brokers,
+		configkafka.Group:  cfg.Group,
+		configkafka.Offset: cfg.Offset,
+	}
+	if cfg.StatsInterval > 0 {
+		_ = config.SetKey(configkafka.StatsInterval, int(cfg.StatsInterval.Milliseconds()))
+	}
+	if err := configkafka.ApplySecurity(config, &cfg.Security); err != nil {
+		return nil, fmt.Errorf("invalid kafka security configuration: %w", err)
+	}
+	consumer, err := kafka.NewConsumer(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating consumer: %w", err)
+	}
+	c := &Consumer{
+		consumer: consumer,
+		uc:       uc,
+		stats:    newStatsCollector(),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	// rebalance events of polling consumer are only passed to the callback, partitions are assigned by the
+	// client as the callback does not assign them
+	if err = consumer.SubscribeTopics(topics, func(_ *kafka.Consumer, ev kafka.Event) error {
+		c.handleEvent(app.BackgroundContextWithDefaultLogger(), ev)
+		return nil
+	}); err != nil {
+		_ = consumer.Close()
+		return nil, fmt.Errorf("error subscribing to topics %v: %w", topics, err)
+	}
+	zap.S().Infof("Subscribed to kafka topics %v at %s (%s)", topics, cfg.Brokers, configkafka.Protocol(&cfg.Security))
+	return c, nil
+}
+
+// Run polls kafka until Close is called or the consumer fails with fatal error
+func (c *Consumer) Run(ctx context.Context) {
+	defer close(c.stopped)
+	for {
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+		if c.backoff > 0 {
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(c.backoff):
+			}
+		}
+		if fatal := c.handleEvent(ctx, c.consumer.Poll(pollTimeoutMs)); fatal {
+			c.closeConsumer()
+			return
+		}
+	}
+}
+
+// Close stops the poll loop and closes the consumer
+func (c *Consumer) Close() {
+	close(c.stop)
+	<-c.stopped
+	c.closeConsumer()
+}
+
+// closeConsumer commits offsets and leaves the consumer group
+func (c *Consumer) closeConsumer() {
+	c.closeOnce.Do(func() {
+		if err := c.consumer.Close(); err != nil {
+			zap.S().Warn("Failed to properly close kafka consumer:", err)
+		}
+	})
+}
+
+// handleEvent reports whether the consumer failed with fatal error and can not be used anymore. Any poll
+// result but an error, poll timeout (nil) included, ends the backoff of previous errors.
+func (c *Consumer) handleEvent(ctx context.Context, ev kafka.Event) bool {
+	if _, failed := ev.(kafka.Error); !failed {
+		c.backoff = 0
+	}
+	switch e := ev.(type) {
+	case *kafka.Message:
+		c.handleMessage(ctx, e)
+	case kafka.Error:
+		consumerErrors.Inc(e.Code().String())
+		if e.IsFatal() {
+			app.Logger(ctx).Errorf("Kafka consumer failed, records are no longer consumed: %v", e)
+			return true
+		}
+		c.backoff = nextBackoff(c.backoff)
+		app.Logger(ctx).Warnf("Kafka consumer error, polling again in %s: %v", c.backoff, e)
+	case *kafka.Stats:
+		if err := c.stats.collect(e.String()); err != nil {
+			app.Logger(ctx).Warn(err)
+		}
+	case kafka.AssignedPartitions:
+		app.Logger(ctx).Infof("Kafka partitions assigned: %s", formatPartitions(e.Partitions))
+	case kafka.RevokedPartitions:
+		lost := ""
+		if c.consumer.AssignmentLost() {
+			lost = ", assignment was lost"
+		}
+		app.Logger(ctx).Infof("Kafka partitions revoked: %s%s", formatPartitions(e.Partitions), lost)
+	}
+	return false
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff < minBackoff {
+		return minBackoff
+	}
+	if backoff *= 2; backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// formatPartitions lists partitions grouped by topic, e.g. "logs[0 1 2] audit[0]"
+func formatPartitions(partitions []kafka.TopicPartition) string {
+	if len(partitions) == 0 {
+		return "none"
+	}
+	var topics []string
+	byTopic := make(map[string][]int32)
+	for _, p := range partitions {
+		topic := ""
+		if p.Topic != nil {
+			topic = *p.Topic
+		}
+		if _, ok := byTopic[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], p.Partition)
+	}
+	parts := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		parts = append(parts, fmt.Sprintf("%s%v", topic, byTopic[topic]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *Consumer) handleMessage(ctx context.Context, msg *kafka.Message) {
+	topic := *msg.TopicPartition.Topic
+	app.Logger(ctx).Debugf("Received message on topic %s: %s", topic, string(msg.Value))
+	rec := pipeline.DecodeRecord(topic, headerValue(msg, "source"), msg.Timestamp.UTC(), msg.Value)
+	if tc := &c.uc.Tenants; tc.Enabled {
+		if tenant := c.uc.TenantOfKafkaMessage(topic, headerValue(msg, tc.KafkaHeaderOr("tenant"))); tenant != "" {
+			ctx = app.ContextWithTenant(ctx, tenant)
+		}
+	}
+	// records over quota are counted and dropped by the use case
+	_ = c.uc.IngestLogs(ctx, rec)
+}
+
+func headerValue(msg *kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package consumer
+
+import (
+	"context"
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/core/metrics"
+	"example_consumer/internal/core/usecase"
+	"example_consumer/internal/kafka/events"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+var invalidEvents = metrics.NewCounter("logservice_kafka_invalid_events_total",
+	"Events skipped and committed because they can not be read or are invalid", "topic")
+
+// EventConsumer handles customer events that have to change stored data
+type EventConsumer struct {
+	events events.ConsumerInterface
+	uc     *usecase.UseCases
+}
+
+func NewEventConsumer(ev events.ConsumerInterface, uc *usecase.UseCases) *EventConsumer {
+	return &EventConsumer{
+		events: ev,
+		uc:     uc,
+	}
+}
+
+// Run starts consuming CUSTOMER_DELETION events, consume errors are logged
+func (c *EventConsumer) Run() {
+	errs := c.events.ConsumeCustomerDeleteEvent(c.handleCustomerDelete)
+	go func() {
+		for err := range errs {
+			zap.S().Errorf("Consuming %s events failed: %v", events.CustomerDeletionTopicName, err)
+		}
+	}()
+	zap.S().Infof("Consuming %s events", events.CustomerDeletionTopicName)
+}
+
+// handleCustomerDelete returns error only if erasure failed and may succeed when the event is handled again,
+// invalid events would fail every time, they are logged and skipped
+func (c *EventConsumer) handleCustomerDelete(ctx context.Context, msg kafkaGo.Message) error {
+	ctx = app.ContextWithLogger(ctx, zap.S().With("customerEvent", events.CustomerDeletionTopicName))
+	value, err := events.UnmarshalEvent(events.CustomerDeleteEventBody{}, msg.Value)
+	if err != nil {
+		skipInvalidEvent(ctx, msg, err)
+		return nil
+	}
+	event := value.(*events.CustomerDeleteEventBody)
+	if err = event.Validate(); err != nil {
+		skipInvalidEvent(ctx, msg, err)
+		return nil
+	}
+	// events of a tenant only erase logs of the tenant
+	if tc := &c.uc.Tenants; tc.Enabled {
+		if tenant := c.uc.TenantOfKafkaMessage(msg.Topic, eventHeader(msg, tc.KafkaHeaderOr("tenant"))); tenant != "" {
+			ctx = app.ContextWithTenant(ctx, tenant)
+		}
+	}
+	_, err = c.uc.EraseCustomer(ctx, event.CustomerNumber)
+	return err
+}
+
+func skipInvalidEvent(ctx context.Context, msg kafkaGo.Message, err error) {
+	invalidEvents.Inc(msg.Topic)
+	app.Logger(ctx).Errorf("Skipping invalid event at offset %d of topic %s: %v", msg.Offset, msg.Topic, err)
+}
+
+func eventHeader(msg kafkaGo.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package consumer
+
+import (
+	"context"
+	"example_consumer/internal/core/usecase"
+	"example_consumer/internal/kafka/events"
+	"testing"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+func TestHandleCustomerDeleteSkipsInvalidEvents(t *testing.T) {
+	c := NewEventConsumer(nil, &usecase.UseCases{})
+	for _, value := range []string{`not json`, `{}`, `{"customerNumber": ""}`} {
+		msg := kafkaGo.Message{Topic: events.CustomerDeletionTopicName, Value: []byte(value)}
+		if err := c.handleCustomerDelete(context.Background(), msg); err != nil {
+			t.Errorf("event %s: err = %v, want nil so that it is committed", value, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"encoding/json"
+	"example_consumer/internal/core/metrics"
+	"fmt"
+)
+
+var (
+	consumerLag = metrics.NewGauge("logservice_kafka_consumer_lag",
+		"Messages of partition not yet consumed", "topic", "partition")
+	consumedMessages = metrics.NewCounter("logservice_kafka_consumed_messages_total",
+		"Messages fetched from kafka", "topic")
+	consumedBytes = metrics.NewCounter("logservice_kafka_consumed_bytes_total",
+		"Bytes of messages fetched from kafka", "topic")
+	consumerErrors = metrics.NewCounter("logservice_kafka_consumer_errors_total",
+		"Errors reported by kafka consumer", "code")
+)
+
+// statistics is the part of librdkafka statistics the consumer exports as metrics
+type statistics struct {
+	Topics map[string]struct {
+		Partitions map[string]struct {
+			Partition   int32 `json:"partition"`
+			ConsumerLag int64 `json:"consumer_lag"`
+			RxMsgs      int64 `json:"rxmsgs"`
+			RxBytes     int64 `json:"rxbytes"`
+		} `json:"partitions"`
+	} `json:"topics"`
+}
+
+type partitionTotals struct {
+	msgs  int64
+	bytes int64
+}
+
+// statsCollector turns cumulative librdkafka statistics into metrics, it is only used from the poll loop
+type statsCollector struct {
+	last map[string]partitionTotals
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{last: make(map[string]partitionTotals)}
+}
+
+func (s *statsCollector) collect(statsJSON string) error {
+	var stats statistics
+	if err := json.Unmarshal([]byte(statsJSON), &stats); err != nil {
+		return fmt.Errorf("invalid kafka statistics: %w", err)
+	}
+	for topic, t := range stats.Topics {
+		for _, p := range t.Partitions {
+			// partition -1 holds messages not yet assigned to a partition
+			if p.Partition < 0 {
+				continue
+			}
+			partition := fmt.Sprint(p.Partition)
+			// lag is -1 until committed and high watermark offsets are known
+			if p.ConsumerLag >= 0 {
+				consumerLag.Set(float64(p.ConsumerLag), topic, partition)
+			}
+			key := topic + "/" + partition
+			last := s.last[key]
+			// totals start over when the partition is assigned again
+			if p.RxMsgs < last.msgs || p.RxBytes < last.bytes {
+				last = partitionTotals{}
+			}
+			consumedMessages.Add(float64(p.RxMsgs-last.msgs), topic)
+			consumedBytes.Add(float64(p.RxBytes-last.bytes), topic)
+			s.last[key] = partitionTotals{msgs: p.RxMsgs, bytes: p.RxBytes}
+		}
+	}
+	return nil
+}
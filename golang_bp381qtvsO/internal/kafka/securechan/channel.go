@@ -0,0 +1,99 @@
+package securechan
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/c0olix/goChan"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+const (
+	minRetryBackoff = time.Second
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// channel produces to and consumes from one topic like goChan kafka channel does
+type channel struct {
+	topic        string
+	readerConfig kafkaGo.ReaderConfig
+	writer       *kafkaGo.Writer
+
+	mu       sync.Mutex
+	readerMw []goChan.Middleware
+	writerMw []goChan.Middleware
+}
+
+func (c *channel) SetReaderMiddleWares(mw ...goChan.Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readerMw = mw
+}
+
+func (c *channel) SetWriterMiddleWares(mw ...goChan.Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writerMw = mw
+}
+
+// Produce writes message to the topic after passing writer middlewares
+func (c *channel) Produce(ctx context.Context, msg kafkaGo.Message) error {
+	c.mu.Lock()
+	handler := chain(func(ctx context.Context, msg kafkaGo.Message) error {
+		return c.writer.WriteMessages(ctx, msg)
+	}, c.writerMw)
+	c.mu.Unlock()
+	return handler(ctx, msg)
+}
+
+// Consume passes messages of the topic to handler in background. Errors of handler are sent to returned
+// channel and the message is handled again after a backoff, it is only committed once handler succeeded.
+// Handlers therefore return errors only if handling again may succeed, messages that can never be handled
+// (e.g. invalid ones) have to be skipped by returning nil. The channel is closed when reading fails.
+func (c *channel) Consume(handler goChan.Handler) chan error {
+	c.mu.Lock()
+	handler = chain(handler, c.readerMw)
+	c.mu.Unlock()
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		reader := kafkaGo.NewReader(c.readerConfig)
+		defer reader.Close()
+		ctx := context.Background()
+		for {
+			msg, err := reader.FetchMessage(ctx)
+			if err != nil {
+				errs <- fmt.Errorf("error reading topic %s: %w", c.topic, err)
+				return
+			}
+			for backoff := minRetryBackoff; ; backoff = nextRetryBackoff(backoff) {
+				if err = handler(ctx, msg); err == nil {
+					break
+				}
+				errs <- fmt.Errorf("offset %d of topic %s is handled again in %s: %w", msg.Offset, c.topic, backoff, err)
+				time.Sleep(backoff)
+			}
+			if err = reader.CommitMessages(ctx, msg); err != nil {
+				errs <- fmt.Errorf("error committing offset %d of topic %s: %w", msg.Offset, c.topic, err)
+			}
+		}
+	}()
+	return errs
+}
+
+func nextRetryBackoff(backoff time.Duration) time.Duration {
+	if backoff *= 2; backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// chain wraps handler so that the first middleware runs first
+func chain(handler goChan.Handler, mw []goChan.Middleware) goChan.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		handler = mw[i](handler)
+	}
+	return handler
+}
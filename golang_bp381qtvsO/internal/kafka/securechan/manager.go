@@ -0,0 +1,78 @@
+package securechan
+
+import (
+	"example_consumer/internal/core/app"
+	"example_consumer/internal/kafka/configkafka"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/c0olix/goChan"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+const writeBatchTimeout = 5 * time.Millisecond
+
+// Manager creates goChan channels connected to brokers by SASL and/or TLS. goChan kafka manager is created from
+// broker addresses only and its channels use kafka-go's default dialer and transport, there is no way to pass
+// SASL mechanism or TLS config into it. Channels of this manager implement goChan.ChannelInterface on the same
+// kafka-go reader and writer, so producers, consumers and middlewares do not notice which manager is used.
+// Plain text brokers are still connected by goChan.
+type Manager struct {
+	brokers   []string
+	group     string
+	dialer    *kafkaGo.Dialer
+	transport *kafkaGo.Transport
+}
+
+func NewManager(cfg *app.KafkaConfig) (*Manager, error) {
+	if err := configkafka.ValidateSecurity(&cfg.Security); err != nil {
+		return nil, err
+	}
+	mechanism, err := configkafka.SaslMechanism(&cfg.Security)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := configkafka.TlsConfig(&cfg.Security)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Group == "" {
+		return nil, fmt.Errorf("kafka group is not configured")
+	}
+	return &Manager{
+		brokers: strings.Split(cfg.Brokers, ","),
+		group:   cfg.Group,
+		dialer: &kafkaGo.Dialer{
+			Timeout:       10 * time.Second,
+			DualStack:     true,
+			SASLMechanism: mechanism,
+			TLS:           tlsConfig,
+		},
+		transport: &kafkaGo.Transport{
+			SASL: mechanism,
+			TLS:  tlsConfig,
+		},
+	}, nil
+}
+
+// CreateChannel returns channel of topic name, config is accepted for compatibility with goChan and ignored
+func (m *Manager) CreateChannel(name string, _ interface{}) (goChan.ChannelInterface, error) {
+	return &channel{
+		topic: name,
+		readerConfig: kafkaGo.ReaderConfig{
+			Brokers: m.brokers,
+			GroupID: m.group,
+			Topic:   name,
+			Dialer:  m.dialer,
+		},
+		writer: &kafkaGo.Writer{
+			Addr:     kafkaGo.TCP(m.brokers...),
+			Topic:    name,
+			Balancer: &kafkaGo.Hash{},
+			// every Produce writes a single message and waits for it, default 1s would throttle producers
+			BatchTimeout: writeBatchTimeout,
+			Transport:    m.transport,
+		},
+	}, nil
+}